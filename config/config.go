@@ -59,6 +59,7 @@ type appConfig struct {
 		Assets                map[string]int `mapstructure:"assets"`
 		AssetType             map[string]int `mapstructure:"asset_type"`
 		AssetCopyright        map[string]int `mapstructure:"asset_copyright"`
+		AuthorType            map[string]int `mapstructure:"author_type"`
 		Cards                 map[string]int `mapstructure:"cards"`
 		CardStatus            map[string]int `mapstructure:"card_status"`
 		Members               map[string]int `mapstructure:"members"`
@@ -84,6 +85,8 @@ type appConfig struct {
 		PointType             map[string]int `mapstructure:"point_type"`
 		PointStatus           map[string]int `mapstructure:"point_status"`
 		HotTagsWeight         map[string]int `mapstructure:"hot_tags_wieght"`
+		RelatedPostsWeight    map[string]int `mapstructure:"related_posts_weight"`
+		TrendingPostsWeight   map[string]int `mapstructure:"trending_posts_weight"`
 		Promotions            map[string]int `mapstructure:"promotions"`
 	} `mapstructure:"models"`
 
@@ -112,6 +115,109 @@ type appConfig struct {
 	Slack struct {
 		NotifyWebhook string `mapstructure:"notify_webhook"`
 	} `mapstructure:"slack"`
+
+	Sanitizer struct {
+		Policies       map[string]map[string][]string `mapstructure:"policies"`
+		CSSBlocklist   []string                       `mapstructure:"css_blocklist"`
+		JSBlocklist    []string                       `mapstructure:"js_blocklist"`
+		CodePermission string                         `mapstructure:"code_permission"`
+	} `mapstructure:"sanitizer"`
+
+	Feed struct {
+		Title          string `mapstructure:"title"`
+		Description    string `mapstructure:"description"`
+		MaxItems       uint8  `mapstructure:"max_items"`
+		AbstractLength int64  `mapstructure:"abstract_length"`
+		CacheTTL       int    `mapstructure:"cache_ttl"`
+	} `mapstructure:"feed"`
+
+	Sitemap struct {
+		PageSize        int    `mapstructure:"page_size"`
+		NewsPublication string `mapstructure:"news_publication"`
+		NewsLanguage    string `mapstructure:"news_language"`
+		NewsHours       int    `mapstructure:"news_hours"`
+		CacheTTL        int    `mapstructure:"cache_ttl"`
+	} `mapstructure:"sitemap"`
+
+	JSONLD struct {
+		PublisherName   string `mapstructure:"publisher_name"`
+		PublisherLogo   string `mapstructure:"publisher_logo"`
+		PaywallSelector string `mapstructure:"paywall_selector"`
+	} `mapstructure:"jsonld"`
+
+	RelatedPosts struct {
+		MaxResult      int `mapstructure:"max_result"`
+		CandidateLimit int `mapstructure:"candidate_limit"`
+		HalfLifeDays   int `mapstructure:"half_life_days"`
+		HotPosts       int `mapstructure:"hot_posts"`
+		HotDays        int `mapstructure:"hot_days"`
+		CacheTTL       int `mapstructure:"cache_ttl"`
+	} `mapstructure:"related_posts"`
+
+	Views struct {
+		MaxBatch    int      `mapstructure:"max_batch"`
+		BotPatterns []string `mapstructure:"bot_patterns"`
+	} `mapstructure:"views"`
+
+	TrendingPosts struct {
+		WindowHours int     `mapstructure:"window_hours"`
+		Gravity     float64 `mapstructure:"gravity"`
+		MaxPosts    int     `mapstructure:"max_posts"`
+	} `mapstructure:"trending_posts"`
+
+	Translation struct {
+		SourceLanguage string   `mapstructure:"source_language"`
+		Languages      []string `mapstructure:"languages"`
+	} `mapstructure:"translation"`
+
+	Trash struct {
+		RetentionDays int `mapstructure:"retention_days"`
+		MaxPurge      int `mapstructure:"max_purge"`
+	} `mapstructure:"trash"`
+
+	CacheControl struct {
+		Default string            `mapstructure:"default"`
+		Routes  map[string]string `mapstructure:"routes"`
+	} `mapstructure:"cache_control"`
+
+	Unfurl struct {
+		TimeoutSeconds   int   `mapstructure:"timeout_seconds"`
+		MaxBodyBytes     int64 `mapstructure:"max_body_bytes"`
+		MaxRedirects     int   `mapstructure:"max_redirects"`
+		CacheTTL         int   `mapstructure:"cache_ttl"`
+		NegativeCacheTTL int   `mapstructure:"negative_cache_ttl"`
+		DomainRateLimit  int   `mapstructure:"domain_rate_limit"`
+	} `mapstructure:"unfurl"`
+
+	CommentLink struct {
+		Interval     int `mapstructure:"interval"`
+		BatchSize    int `mapstructure:"batch_size"`
+		MaxAttempts  int `mapstructure:"max_attempts"`
+		RetryBackoff int `mapstructure:"retry_backoff"`
+	} `mapstructure:"comment_link"`
+
+	LinkCheck struct {
+		IntervalHours    int  `mapstructure:"interval_hours"`
+		BackoffMinutes   int  `mapstructure:"backoff_minutes"`
+		BatchSize        int  `mapstructure:"batch_size"`
+		FailureThreshold int  `mapstructure:"failure_threshold"`
+		NotifySlack      bool `mapstructure:"notify_slack"`
+		NotifyMail       bool `mapstructure:"notify_mail"`
+	} `mapstructure:"link_check"`
+
+	HotTags struct {
+		// EngagementSource is one of "views", "es" and "fixture"
+		EngagementSource string `mapstructure:"engagement_source"`
+		Fixture          string `mapstructure:"fixture"`
+	} `mapstructure:"hot_tags"`
+
+	TagSuggest struct {
+		MaxDocs   int `mapstructure:"max_docs"`
+		MinDF     int `mapstructure:"min_df"`
+		MaxResult int `mapstructure:"max_result"`
+		// Dictionary is path of words to segment texts with besides tags, one word per line
+		Dictionary string `mapstructure:"dictionary"`
+	} `mapstructure:"tag_suggest"`
 }
 
 func LoadConfig(configPath string, configName string) error {
@@ -0,0 +1,6 @@
+# Modify posts table, remove text statistics columns
+ALTER TABLE posts DROP COLUMN char_count;
+ALTER TABLE posts DROP COLUMN word_count;
+ALTER TABLE posts DROP COLUMN reading_time;
+ALTER TABLE posts DROP COLUMN image_count;
+ALTER TABLE posts DROP COLUMN embed_count;
@@ -0,0 +1,9 @@
+# Modify posts table, add text statistics columns computed from content and cards
+# Stats of posts stored before are filled by PUT /posts/stats, since counting them requires parsing content
+ALTER TABLE posts ADD COLUMN char_count int unsigned DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN word_count int unsigned DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN reading_time int unsigned DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN image_count smallint unsigned DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN embed_count smallint unsigned DEFAULT NULL;
+ALTER TABLE posts ADD INDEX (reading_time);
+ALTER TABLE posts ADD INDEX (word_count);
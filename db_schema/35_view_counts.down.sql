@@ -0,0 +1,4 @@
+# Remove view counts flushed from Redis
+ALTER TABLE posts DROP COLUMN views;
+ALTER TABLE posts DROP COLUMN unique_views;
+ALTER TABLE projects DROP COLUMN unique_views;
@@ -0,0 +1,4 @@
+# Add view counts flushed from Redis
+ALTER TABLE posts ADD COLUMN views int unsigned DEFAULT 0;
+ALTER TABLE posts ADD COLUMN unique_views int unsigned DEFAULT 0;
+ALTER TABLE projects ADD COLUMN unique_views int unsigned DEFAULT 0;
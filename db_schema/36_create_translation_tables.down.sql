@@ -0,0 +1,2 @@
+DROP TABLE newscard_translations;
+DROP TABLE post_translations;
@@ -0,0 +1,28 @@
+# Create translation tables of posts and news cards
+CREATE TABLE IF NOT EXISTS `post_translations` (
+    `post_id` bigint(20) unsigned NOT NULL,
+    `language` varchar(16) NOT NULL,
+    `title` varchar(256) DEFAULT NULL,
+    `subtitle` varchar(256) DEFAULT NULL,
+    `content` text,
+    `og_title` varchar(256) DEFAULT NULL,
+    `og_description` varchar(256) DEFAULT NULL,
+    `og_image` varchar(512) DEFAULT NULL,
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `updated_by` bigint(20) unsigned DEFAULT NULL,
+    PRIMARY KEY (`post_id`, `language`),
+    INDEX (`language`),
+    FOREIGN KEY (post_id)
+        REFERENCES posts (post_id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `newscard_translations` (
+    `card_id` bigint(20) unsigned NOT NULL,
+    `language` varchar(16) NOT NULL,
+    `title` varchar(256) DEFAULT NULL,
+    `description` text DEFAULT NULL,
+    PRIMARY KEY (`card_id`, `language`),
+    FOREIGN KEY (card_id)
+        REFERENCES newscards (id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
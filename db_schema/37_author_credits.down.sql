@@ -0,0 +1,6 @@
+# Remove credit position of authors
+DROP INDEX resource_credits ON authors;
+ALTER TABLE authors DROP COLUMN position;
+
+ALTER TABLE project_authors DROP COLUMN author_type;
+ALTER TABLE project_authors DROP COLUMN position;
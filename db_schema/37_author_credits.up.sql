@@ -0,0 +1,14 @@
+# Add credit position to authors of posts and projects, ordered within the same author type
+ALTER TABLE authors ADD COLUMN position smallint unsigned NOT NULL DEFAULT 0;
+CREATE INDEX resource_credits ON authors(resource_type, resource_id, author_type, position);
+
+ALTER TABLE project_authors ADD COLUMN author_type tinyint DEFAULT 0;
+ALTER TABLE project_authors ADD COLUMN position smallint unsigned NOT NULL DEFAULT 0;
+
+# Credit projects with the authors of their reports (type 4) and memos (type 5), which used to be derived on every read
+INSERT IGNORE INTO project_authors (project_id, author_id, author_type, position)
+	SELECT posts.project_id, authors.author_id, MIN(authors.author_type), MIN(authors.position) FROM authors
+	INNER JOIN posts ON authors.resource_id = posts.post_id AND authors.resource_type = posts.type
+	WHERE posts.type IN (4, 5) AND posts.project_id > 0
+	AND NOT EXISTS (SELECT 1 FROM project_authors AS pa WHERE pa.project_id = posts.project_id AND pa.author_id = authors.author_id)
+	GROUP BY posts.project_id, authors.author_id;
@@ -0,0 +1,2 @@
+# Drop trash table
+DROP TABLE IF EXISTS `trash`;
@@ -0,0 +1,8 @@
+# Create trash table recording when posts, members, tags and assets are deactivated
+CREATE TABLE IF NOT EXISTS `trash` (
+    `resource_type` varchar(16) NOT NULL,
+    `resource_id` bigint(20) unsigned NOT NULL,
+    `deleted_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`resource_type`, `resource_id`),
+    INDEX (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+# Remove version columns for optimistic concurrency control
+ALTER TABLE posts DROP COLUMN version;
+ALTER TABLE projects DROP COLUMN version;
+ALTER TABLE newscards DROP COLUMN version;
+ALTER TABLE promotions DROP COLUMN version;
@@ -0,0 +1,5 @@
+# Add version columns for optimistic concurrency control, bumped by every update
+ALTER TABLE posts ADD COLUMN version int unsigned NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN version int unsigned NOT NULL DEFAULT 1;
+ALTER TABLE newscards ADD COLUMN version int unsigned NOT NULL DEFAULT 1;
+ALTER TABLE promotions ADD COLUMN version int unsigned NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+# Drop comment_links table
+DROP TABLE IF EXISTS `comment_links`;
@@ -0,0 +1,19 @@
+# Create comment_links table keeping link previews of comments, enriched in background
+CREATE TABLE IF NOT EXISTS `comment_links` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `comment_id` bigint(20) unsigned NOT NULL,
+    `url` varchar(2048) NOT NULL,
+    `position` int unsigned NOT NULL DEFAULT 0,
+    `og_title` text,
+    `og_description` text,
+    `og_image` varchar(2048) DEFAULT NULL,
+    `status` varchar(16) NOT NULL DEFAULT 'pending',
+    `attempts` int unsigned NOT NULL DEFAULT 0,
+    `error` varchar(256) DEFAULT NULL,
+    `next_attempt_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    INDEX (`comment_id`, `position`),
+    INDEX (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
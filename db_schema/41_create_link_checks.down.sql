@@ -0,0 +1,3 @@
+# Drop link_checks and link_check_history tables
+DROP TABLE IF EXISTS `link_check_history`;
+DROP TABLE IF EXISTS `link_checks`;
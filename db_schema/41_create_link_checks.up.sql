@@ -0,0 +1,32 @@
+# Create link_checks table keeping the latest check of stored urls, with link_check_history of every check
+CREATE TABLE IF NOT EXISTS `link_checks` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `resource_type` varchar(16) NOT NULL,
+    `resource_id` bigint(20) unsigned NOT NULL,
+    `url` varchar(2048) NOT NULL,
+    `member_id` bigint(20) unsigned DEFAULT NULL,
+    `status` varchar(16) NOT NULL DEFAULT 'pending',
+    `http_status` int DEFAULT NULL,
+    `final_url` varchar(2048) DEFAULT NULL,
+    `failures` int unsigned NOT NULL DEFAULT 0,
+    `checked_at` datetime DEFAULT NULL,
+    `next_check_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `slack_notified_at` datetime DEFAULT NULL,
+    `mail_notified_at` datetime DEFAULT NULL,
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`resource_type`, `resource_id`),
+    INDEX (`status`, `failures`),
+    INDEX (`next_check_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `link_check_history` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `link_check_id` bigint(20) unsigned NOT NULL,
+    `status` varchar(16) NOT NULL,
+    `http_status` int DEFAULT NULL,
+    `final_url` varchar(2048) DEFAULT NULL,
+    `checked_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    INDEX (`link_check_id`, `checked_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+# Remove alias_of of tags
+ALTER TABLE tags DROP INDEX alias_of;
+ALTER TABLE tags DROP COLUMN alias_of;
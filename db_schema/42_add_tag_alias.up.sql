@@ -0,0 +1,3 @@
+# Add alias_of to tags, pointing tags merged into another to their canonical tag
+ALTER TABLE tags ADD COLUMN alias_of bigint(20) unsigned DEFAULT NULL;
+ALTER TABLE tags ADD INDEX alias_of (alias_of);
@@ -0,0 +1,4 @@
+# Remove tag hierarchy
+DROP TABLE IF EXISTS `tag_closure`;
+ALTER TABLE tags DROP INDEX parent_id;
+ALTER TABLE tags DROP COLUMN parent_id;
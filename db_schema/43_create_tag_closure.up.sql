@@ -0,0 +1,11 @@
+# Add parent_id to tags, and tag_closure keeping every ancestor of tags with depth, including tags themselves at depth 0
+ALTER TABLE tags ADD COLUMN parent_id bigint(20) unsigned DEFAULT NULL;
+ALTER TABLE tags ADD INDEX parent_id (parent_id);
+CREATE TABLE IF NOT EXISTS `tag_closure` (
+    `ancestor_id` bigint(20) unsigned NOT NULL,
+    `descendant_id` bigint(20) unsigned NOT NULL,
+    `depth` int unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (`ancestor_id`, `descendant_id`),
+    KEY `descendant_id` (`descendant_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+INSERT IGNORE INTO tag_closure (ancestor_id, descendant_id, depth) SELECT tag_id, tag_id, 0 FROM tags;
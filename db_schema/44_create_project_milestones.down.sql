@@ -0,0 +1,4 @@
+# Drop project_milestones table and progress source of projects
+ALTER TABLE projects DROP COLUMN planned_memos;
+ALTER TABLE projects DROP COLUMN progress_source;
+DROP TABLE IF EXISTS `project_milestones`;
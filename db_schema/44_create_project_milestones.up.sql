@@ -0,0 +1,20 @@
+# Create project_milestones table, and let project progress be computed from milestones or published memos
+CREATE TABLE IF NOT EXISTS `project_milestones` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `project_id` bigint(20) unsigned NOT NULL,
+    `title` varchar(256) NOT NULL,
+    `description` text,
+    `weight` int unsigned NOT NULL DEFAULT 1,
+    `target_at` datetime DEFAULT NULL,
+    `done_at` datetime DEFAULT NULL,
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP,
+    `updated_by` bigint(20) unsigned DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    INDEX (`project_id`, `target_at`),
+    FOREIGN KEY (`project_id`)
+        REFERENCES projects (`project_id`) ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE projects ADD COLUMN progress_source varchar(16) NOT NULL DEFAULT 'manual';
+ALTER TABLE projects ADD COLUMN planned_memos int unsigned DEFAULT NULL;
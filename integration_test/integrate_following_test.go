@@ -205,7 +205,7 @@ func TestFollowing(t *testing.T) {
 			}
 		}
 		for _, v := range mockedProjects {
-			err := models.ProjectAPI.InsertProject(v)
+			_, err := models.ProjectAPI.InsertProject(v)
 			if err != nil {
 				t.Fatalf("init project data fail: %s ", err.Error())
 			}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+)
+
+// bufferedWriter holds response back, so that it could be replaced by 304 after handler is done.
+// Status code still goes to the underlying writer, which doesn't send it until the body is written.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// CacheControl returns Cache-Control policy of route in config, or the default one
+func CacheControl(route string) string {
+	if policy, ok := config.Config.CacheControl.Routes[route]; ok {
+		return policy
+	}
+	return config.Config.CacheControl.Default
+}
+
+// ETag is the strong entity tag of body last modified at lastModified
+func ETag(body []byte, lastModified time.Time) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%d:", lastModified.Unix())
+	hash.Write(body)
+	return fmt.Sprintf(`"%x"`, hash.Sum(nil))
+}
+
+// LastModified finds the latest updated_at in JSON body, including those of nested resources
+// such as tags, authors and comments, so that lists are modified whenever any of them is.
+func LastModified(body []byte) (latest time.Time) {
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, field := range value {
+				if s, ok := field.(string); ok && key == "updated_at" {
+					if t, err := time.Parse(time.RFC3339Nano, s); err == nil && t.After(latest) {
+						latest = t
+					}
+					continue
+				}
+				walk(field)
+			}
+		case []interface{}:
+			for _, item := range value {
+				walk(item)
+			}
+		}
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		walk(decoded)
+	}
+	return latest
+}
+
+// matchETag tells whether If-None-Match header lists etag
+func matchETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Conditional answers GET requests with 304 Not Modified when If-None-Match or If-Modified-Since
+// shows the client already has the response, and sets ETag, Last-Modified and Cache-Control of route.
+func Conditional(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		origin := c.Writer
+		w := &bufferedWriter{ResponseWriter: origin}
+		c.Writer = w
+		c.Next()
+		c.Writer = origin
+
+		if origin.Status() != http.StatusOK {
+			origin.Write(w.body.Bytes())
+			return
+		}
+
+		body := w.body.Bytes()
+		lastModified := LastModified(body)
+		etag := ETag(body, lastModified)
+
+		header := origin.Header()
+		header.Set("ETag", etag)
+		if !lastModified.IsZero() {
+			header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+		if policy := CacheControl(route); policy != "" {
+			header.Set("Cache-Control", policy)
+		}
+
+		// If-Modified-Since is ignored when If-None-Match is present, following RFC 7232
+		notModified := false
+		if inm := c.GetHeader("If-None-Match"); inm != "" {
+			notModified = matchETag(inm, etag)
+		} else if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+			if since, err := http.ParseTime(ims); err == nil && !lastModified.Truncate(time.Second).After(since) {
+				notModified = true
+			}
+		}
+
+		if notModified {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			origin.WriteHeader(http.StatusNotModified)
+			origin.WriteHeaderNow()
+			return
+		}
+		origin.Write(body)
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastModified(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		body     string
+		expected time.Time
+	}{
+		{"Empty", ``, time.Time{}},
+		{"NoUpdatedAt", `{"_items":[{"id":1}]}`, time.Time{}},
+		{"NullUpdatedAt", `{"_items":[{"id":1,"updated_at":null}]}`, time.Time{}},
+		{"Single", `{"_items":{"id":1,"updated_at":"2018-05-01T10:00:00Z"}}`, time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"Nested", `{"_items":[{"id":1,"updated_at":"2018-05-01T10:00:00Z","tags":[{"id":2,"updated_at":"2018-06-01T10:00:00Z"}]},{"id":3,"updated_at":"2018-05-02T10:00:00+08:00"}]}`, time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, tc.expected.Equal(LastModified([]byte(tc.body))))
+		})
+	}
+}
+
+func TestConditional(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	config.Config.CacheControl.Default = "no-cache"
+	config.Config.CacheControl.Routes = map[string]string{"test": "public, max-age=60"}
+
+	body := `{"_items":[{"id":1,"updated_at":"2018-05-01T10:00:00Z"}]}`
+	server := gin.New()
+	server.GET("/test", Conditional("test"), func(c *gin.Context) { c.String(http.StatusOK, body) })
+	server.GET("/default", Conditional("default"), func(c *gin.Context) { c.String(http.StatusOK, body) })
+	server.GET("/missing", Conditional("test"), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Post Not Found"})
+	})
+	etag := ETag([]byte(body), time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	for _, tc := range []struct {
+		name         string
+		url          string
+		header       map[string]string
+		httpcode     int
+		resp         string
+		cacheControl string
+	}{
+		{"Full", "/test", nil, http.StatusOK, body, "public, max-age=60"},
+		{"DefaultPolicy", "/default", nil, http.StatusOK, body, "no-cache"},
+		{"IfNoneMatch", "/test", map[string]string{"If-None-Match": etag}, http.StatusNotModified, ``, "public, max-age=60"},
+		{"IfNoneMatchList", "/test", map[string]string{"If-None-Match": `"stale", ` + etag}, http.StatusNotModified, ``, "public, max-age=60"},
+		{"IfNoneMatchStale", "/test", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK, body, "public, max-age=60"},
+		{"IfModifiedSince", "/test", map[string]string{"If-Modified-Since": "Tue, 01 May 2018 10:00:00 GMT"}, http.StatusNotModified, ``, "public, max-age=60"},
+		{"IfModifiedSinceEarlier", "/test", map[string]string{"If-Modified-Since": "Tue, 01 May 2018 09:59:59 GMT"}, http.StatusOK, body, "public, max-age=60"},
+		{"IfNoneMatchOverIfModifiedSince", "/test", map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": "Tue, 01 May 2018 10:00:00 GMT"}, http.StatusOK, body, "public, max-age=60"},
+		{"NotFound", "/missing", map[string]string{"If-None-Match": "*"}, http.StatusNotFound, `{"Error":"Post Not Found"}`, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.httpcode, w.Code)
+			assert.Equal(t, tc.resp, w.Body.String())
+			assert.Equal(t, tc.cacheControl, w.Header().Get("Cache-Control"))
+			if tc.httpcode != http.StatusNotFound {
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+				assert.Equal(t, "Tue, 01 May 2018 10:00:00 GMT", w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
@@ -311,6 +311,17 @@ func GenerateSQLStmt(mode string, tableName string, input ...interface{}) (query
 	return
 }
 
+// Versioned makes UPDATE query optimistic. Column version is bumped by every update,
+// and when version is valid, rows are only updated if they are still at that version.
+// Version should be left out of the fields updated by query.
+func Versioned(query string, version NullInt) string {
+	query = strings.Replace(strings.TrimSuffix(query, ";"), " WHERE ", ", version = version + 1 WHERE ", 1)
+	if version.Valid {
+		query = fmt.Sprintf("%s AND version = %d", query, version.Int)
+	}
+	return query
+}
+
 func GetResourceMetadata(resource string) (table, key string, followtype int, err error) {
 	if _, ok := config.Config.SQL.TableMeta[resource]; !ok {
 		return "", "", 0, errors.New("Unsupported Resource")
@@ -16,6 +16,7 @@ var (
 	InternalServerError      = errors.New("Internal Server Error")
 	ItemNotFoundError        = errors.New("Item Not Found")
 	MultipleRowAffectedError = errors.New("More Than One Rows Affected")
+	VersionConflictError     = errors.New("Version Conflict")
 
 	SQLInsertionFail = errors.New("SQL Insertion Fail")
 	SQLUpdateFail    = errors.New("SQL Update Fail")
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
@@ -56,6 +57,21 @@ func main() {
 	// Init SearchFeed
 	models.SearchFeed.Init(true)
 
+	// Start enriching link previews of comments
+	models.StartCommentLinkWorker()
+
+	// Build tag suggestion model in background, which is rebuilt by PUT /tags/suggest later
+	go func() {
+		if err := models.TagSuggestAPI.Build(); err != nil {
+			log.Printf("Error building tag suggestion model: %v\n", err)
+		}
+	}()
+
+	// Set where hot tags get engagements of tagged resources
+	if err := models.SetEngagementSource(config.Config.HotTags.EngagementSource); err != nil {
+		panic(fmt.Errorf("Invalid hot tags configuration: %s", err))
+	}
+
 	// Set gin routings
 	routes.SetRoutes(router)
 
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Status of comment links. Links are pending until enriched, or failed after max_attempts tries.
+const (
+	CommentLinkPending = "pending"
+	CommentLinkDone    = "done"
+	CommentLinkFailed  = "failed"
+)
+
+// CommentLink is a link found in comment body, with its preview.
+// The preview of the first link is copied to og fields of comment as well.
+type CommentLink struct {
+	ID            int64            `json:"id" db:"id"`
+	CommentID     int64            `json:"comment_id" db:"comment_id"`
+	URL           string           `json:"url" db:"url"`
+	Position      int              `json:"position" db:"position"`
+	OgTitle       rrsql.NullString `json:"og_title" db:"og_title"`
+	OgDescription rrsql.NullString `json:"og_description" db:"og_description"`
+	OgImage       rrsql.NullString `json:"og_image" db:"og_image"`
+	Status        string           `json:"status" db:"status"`
+	Attempts      int              `json:"-" db:"attempts"`
+	Error         rrsql.NullString `json:"-" db:"error"`
+	NextAttemptAt rrsql.NullTime   `json:"-" db:"next_attempt_at"`
+	CreatedAt     rrsql.NullTime   `json:"created_at" db:"created_at"`
+	UpdatedAt     rrsql.NullTime   `json:"updated_at" db:"updated_at"`
+}
+
+type CommentLinkInterface interface {
+	SetLinks(commentID int64, urls []string) error
+	GetLinks(commentIDs []int64) (map[int64][]CommentLink, error)
+	Enrich(limit int) (int, error)
+}
+
+type commentLinkAPI struct{}
+
+// commentLinkWake wakes up worker when there are new links, so they need not wait for the next tick
+var commentLinkWake = make(chan struct{}, 1)
+
+// syncCommentPreview copies preview of the first link to comment, unless comment already has one
+const syncCommentPreview = `UPDATE comments AS c INNER JOIN comment_links AS l ON l.comment_id = c.id AND l.position = 0 AND l.status = ?
+	SET c.og_title = l.og_title, c.og_description = l.og_description, c.og_image = l.og_image
+	WHERE c.id = ? AND c.og_title IS NULL;`
+
+// SetLinks replaces links of comment with urls in order. Previews of urls kept are reused,
+// and og fields of comment are reset if its first link changes.
+func (a *commentLinkAPI) SetLinks(commentID int64, urls []string) (err error) {
+
+	positions := make(map[string]int)
+	primary := ""
+	for _, u := range urls {
+		if _, ok := positions[u]; !ok {
+			positions[u] = len(positions)
+		}
+	}
+	if len(urls) > 0 {
+		primary = urls[0]
+	}
+
+	added := false
+	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		var existing []CommentLink
+		if err := tx.Select(&existing, `SELECT id, url, position FROM comment_links WHERE comment_id = ? FOR UPDATE;`, commentID); err != nil {
+			return err
+		}
+
+		stmts := make([]*rrsql.PipelineStmt, 0)
+		// Comments without links yet keep their og fields, which may be given by clients
+		primaryChanged := false
+		kept := make(map[string]bool)
+		for _, link := range existing {
+			if link.Position == 0 && link.URL != primary {
+				primaryChanged = true
+			}
+			position, ok := positions[link.URL]
+			switch {
+			case !ok || kept[link.URL]:
+				stmts = append(stmts, &rrsql.PipelineStmt{Query: `DELETE FROM comment_links WHERE id = ?;`, Args: []interface{}{link.ID}})
+				continue
+			case position != link.Position:
+				stmts = append(stmts, &rrsql.PipelineStmt{Query: `UPDATE comment_links SET position = ? WHERE id = ?;`, Args: []interface{}{position, link.ID}})
+			}
+			kept[link.URL] = true
+		}
+		for u, position := range positions {
+			if kept[u] {
+				continue
+			}
+			stmts = append(stmts, &rrsql.PipelineStmt{
+				Query: `INSERT INTO comment_links (comment_id, url, position, status) VALUES (?, ?, ?, ?);`,
+				Args:  []interface{}{commentID, u, position, CommentLinkPending},
+			})
+			added = true
+		}
+		if primaryChanged {
+			stmts = append(stmts,
+				&rrsql.PipelineStmt{Query: `UPDATE comments SET og_title = NULL, og_description = NULL, og_image = NULL WHERE id = ?;`, Args: []interface{}{commentID}},
+				&rrsql.PipelineStmt{Query: syncCommentPreview, Args: []interface{}{CommentLinkDone, commentID}},
+			)
+		}
+		if len(stmts) == 0 {
+			return nil
+		}
+		_, _, err := rrsql.RunPipeline(tx, stmts...)
+		return err
+	})
+	if err == nil && added {
+		select {
+		case commentLinkWake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+func (a *commentLinkAPI) GetLinks(commentIDs []int64) (result map[int64][]CommentLink, err error) {
+
+	result = make(map[int64][]CommentLink)
+	if len(commentIDs) == 0 {
+		return result, nil
+	}
+	query, args, err := sqlx.In(`SELECT * FROM comment_links WHERE comment_id IN (?) ORDER BY comment_id, position;`, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	var links []CommentLink
+	if err = rrsql.DB.Select(&links, rrsql.DB.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		result[link.CommentID] = append(result[link.CommentID], link)
+	}
+	return result, nil
+}
+
+// Enrich fetches previews of at most limit links due, and returns how many links are tried.
+// Failed links are retried with exponential backoff of retry_backoff seconds.
+func (a *commentLinkAPI) Enrich(limit int) (tried int, err error) {
+
+	backoff := config.Config.CommentLink.RetryBackoff
+	if backoff <= 0 {
+		backoff = 600
+	}
+
+	var due []CommentLink
+	if err = rrsql.DB.Select(&due, `SELECT * FROM comment_links WHERE status = ? AND next_attempt_at <= NOW() ORDER BY next_attempt_at LIMIT ?;`, CommentLinkPending, limit); err != nil {
+		return 0, err
+	}
+
+	for _, link := range due {
+		// Claim the link by pushing its next attempt away, in case there are other workers
+		result, err := rrsql.DB.Exec(`UPDATE comment_links SET next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND) WHERE id = ? AND status = ? AND next_attempt_at = ?;`,
+			backoff, link.ID, CommentLinkPending, link.NextAttemptAt)
+		if err != nil {
+			return tried, err
+		}
+		if rowCnt, _ := result.RowsAffected(); rowCnt == 0 {
+			continue
+		}
+		tried++
+
+		info, fetchErr := UnfurlAPI.Unfurl(link.URL)
+		if fetchErr != nil {
+			link.Attempts++
+			status := CommentLinkPending
+			if link.Attempts >= config.Config.CommentLink.MaxAttempts {
+				status = CommentLinkFailed
+			}
+			delay := backoff << uint(link.Attempts-1)
+			if _, err = rrsql.DB.Exec(`UPDATE comment_links SET status = ?, attempts = ?, error = ?, next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND) WHERE id = ?;`,
+				status, link.Attempts, truncateError(fetchErr.Error(), 256), delay, link.ID); err != nil {
+				log.Printf("Error update comment link %d: %v\n", link.ID, err)
+			}
+			continue
+		}
+
+		link.OgTitle = rrsql.NullString{String: info.Title, Valid: true}
+		link.OgDescription = rrsql.NullString{String: info.Description, Valid: info.Description != ""}
+		link.OgImage = rrsql.NullString{String: info.Image, Valid: info.Image != ""}
+		err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+			_, _, err := rrsql.RunPipeline(tx,
+				&rrsql.PipelineStmt{
+					Query: `UPDATE comment_links SET og_title = ?, og_description = ?, og_image = ?, status = ?, attempts = attempts + 1, error = NULL WHERE id = ?;`,
+					Args:  []interface{}{link.OgTitle, link.OgDescription, link.OgImage, CommentLinkDone, link.ID},
+				},
+				&rrsql.PipelineStmt{Query: syncCommentPreview, Args: []interface{}{CommentLinkDone, link.CommentID}},
+			)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error update comment link %d: %v\n", link.ID, err)
+		}
+	}
+	return tried, nil
+}
+
+func truncateError(msg string, max int) string {
+	if r := []rune(msg); len(r) > max {
+		return string(r[:max])
+	}
+	return msg
+}
+
+// StartCommentLinkWorker enriches comment links in background, every interval seconds
+// or as soon as new links are set
+func StartCommentLinkWorker() {
+
+	interval := time.Duration(config.Config.CommentLink.Interval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	batch := config.Config.CommentLink.BatchSize
+	if batch <= 0 {
+		batch = 20
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-commentLinkWake:
+			}
+			for {
+				tried, err := CommentLinkAPI.Enrich(batch)
+				if err != nil {
+					log.Printf("Error enrich comment links: %v\n", err)
+					break
+				}
+				if tried < batch {
+					break
+				}
+			}
+		}
+	}()
+}
+
+var CommentLinkAPI CommentLinkInterface = new(commentLinkAPI)
@@ -36,6 +36,7 @@ type CommentAuthor struct {
 	AuthorImage    rrsql.NullString `json:"author_image" db:"author_image"`
 	AuthorRole     rrsql.NullInt    `json:"author_role" db:"author_role"`
 	CommentAmount  rrsql.NullInt    `json:"comment_amount" db:"comment_amount"`
+	Links          []CommentLink    `json:"links,omitempty" db:"-"`
 }
 
 type ReportedComment struct {
@@ -275,6 +276,11 @@ func (c *commentAPI) GetComment(id int) (CommentAuthor, error) {
 		log.Println(err.Error())
 		comment = CommentAuthor{}
 	default:
+		if links, err := CommentLinkAPI.GetLinks([]int64{comment.ID}); err == nil {
+			comment.Links = links[comment.ID]
+		} else {
+			log.Printf("Fail to get links of comment %d: %v", comment.ID, err)
+		}
 		err = nil
 	}
 	return comment, err
@@ -314,6 +320,19 @@ func (c *commentAPI) GetComments(args *GetCommentArgs) (result []CommentAuthor,
 		result = append(result, comment)
 	}
 
+	ids := make([]int64, len(result))
+	for i, comment := range result {
+		ids[i] = comment.ID
+	}
+	links, err := CommentLinkAPI.GetLinks(ids)
+	if err != nil {
+		log.Printf("Fail to get links of comments: %v", err)
+		return result, nil
+	}
+	for i := range result {
+		result[i].Links = links[result[i].ID]
+	}
+
 	return result, err
 }
 
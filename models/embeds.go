@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Embed describes how a link of post is embedded, so that clients need not parse links themselves
+type Embed struct {
+	Provider    string `json:"provider"`
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	EmbedURL    string `json:"embed_url"`
+	AspectRatio string `json:"aspect_ratio,omitempty"`
+	// Height is set for audio players, which have fixed height instead of aspect ratio
+	Height int `json:"height,omitempty"`
+}
+
+// embedProvider recognizes links of a provider. The first submatch of patterns is the provider ID,
+// unless id is given to build it from all submatches.
+type embedProvider struct {
+	name        string
+	patterns    []*regexp.Regexp
+	id          func(match []string) string
+	canonical   func(id string) string
+	embed       func(id string) string
+	aspectRatio string
+	height      int
+}
+
+var embedProviders = []embedProvider{
+	{
+		name: "youtube",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://(?:www\.|m\.)?youtube\.com/watch\?(?:.*&)?v=([\w-]{11})`),
+			regexp.MustCompile(`^https?://(?:www\.|m\.)?youtube\.com/(?:embed|shorts|live)/([\w-]{11})`),
+			regexp.MustCompile(`^https?://youtu\.be/([\w-]{11})`),
+		},
+		canonical:   func(id string) string { return "https://www.youtube.com/watch?v=" + id },
+		embed:       func(id string) string { return "https://www.youtube.com/embed/" + id },
+		aspectRatio: "16:9",
+	},
+	{
+		name: "vimeo",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://(?:www\.)?vimeo\.com/(?:channels/[\w-]+/|groups/[\w-]+/videos/)?(\d+)`),
+			regexp.MustCompile(`^https?://player\.vimeo\.com/video/(\d+)`),
+		},
+		canonical:   func(id string) string { return "https://vimeo.com/" + id },
+		embed:       func(id string) string { return "https://player.vimeo.com/video/" + id },
+		aspectRatio: "16:9",
+	},
+	{
+		name: "facebook",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://(?:www\.|m\.)?facebook\.com/[\w.-]+/videos/(?:[\w.-]+/)?(\d+)`),
+			regexp.MustCompile(`^https?://(?:www\.|m\.)?facebook\.com/watch/?\?(?:.*&)?v=(\d+)`),
+		},
+		canonical: func(id string) string { return "https://www.facebook.com/watch/?v=" + id },
+		embed: func(id string) string {
+			return "https://www.facebook.com/plugins/video.php?href=" + url.QueryEscape("https://www.facebook.com/watch/?v="+id)
+		},
+		aspectRatio: "16:9",
+	},
+	{
+		name: "spotify",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://open\.spotify\.com/(?:embed/)?((?:episode|show)/[0-9A-Za-z]{22})`),
+		},
+		canonical: func(id string) string { return "https://open.spotify.com/" + id },
+		embed:     func(id string) string { return "https://open.spotify.com/embed/" + id },
+		height:    152,
+	},
+	{
+		name: "soundcloud",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://(?:www\.|m\.)?soundcloud\.com/([\w-]+/[\w-]+)/?(?:$|\?)`),
+		},
+		canonical: func(id string) string { return "https://soundcloud.com/" + id },
+		embed: func(id string) string {
+			return "https://w.soundcloud.com/player/?url=" + url.QueryEscape("https://soundcloud.com/"+id)
+		},
+		height: 166,
+	},
+	{
+		name: "apple_podcasts",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^https?://(?:embed\.)?podcasts\.apple\.com/((?:[a-z]{2}/)?podcast/(?:[^/?#]+/)?id\d+)(?:\?(?:[^#]*&)?(i=\d+))?`),
+		},
+		// Episode is kept in query, while other parameters are dropped
+		id: func(match []string) string {
+			if match[2] != "" {
+				return match[1] + "?" + match[2]
+			}
+			return match[1]
+		},
+		canonical: func(id string) string { return "https://podcasts.apple.com/" + id },
+		embed:     func(id string) string { return "https://embed.podcasts.apple.com/" + id },
+		height:    175,
+	},
+}
+
+// ParseEmbed returns embed of link, or nil if link is not from any known provider
+func ParseEmbed(link string) *Embed {
+
+	for _, p := range embedProviders {
+		for _, pattern := range p.patterns {
+			match := pattern.FindStringSubmatch(link)
+			if match == nil {
+				continue
+			}
+			id := match[1]
+			if p.id != nil {
+				id = p.id(match)
+			}
+			return &Embed{
+				Provider:    p.name,
+				ID:          id,
+				URL:         p.canonical(id),
+				EmbedURL:    p.embed(id),
+				AspectRatio: p.aspectRatio,
+				Height:      p.height,
+			}
+		}
+	}
+	return nil
+}
+
+// FillPostLink normalizes embed link of post and extracts its video_id.
+// Fields link_* not given are filled with meta of the link,
+// which is fetched only if the link differs from current one stored.
+func FillPostLink(p *Post, current string) {
+
+	if !p.Link.Valid || p.Link.String == "" {
+		return
+	}
+	embed := ParseEmbed(p.Link.String)
+	if embed != nil {
+		p.Link.String = embed.URL
+		if !p.VideoID.Valid || p.VideoID.String == "" {
+			p.VideoID = rrsql.NullString{String: embed.ID, Valid: true}
+		}
+	}
+	if p.Link.String == current {
+		return
+	}
+
+	if !p.LinkTitle.Valid || !p.LinkDescription.Valid || !p.LinkImage.Valid || !p.LinkName.Valid {
+		if info, err := UnfurlAPI.Unfurl(p.Link.String); err != nil {
+			log.Printf("Fail to fetch meta of post link %s: %v\n", p.Link.String, err)
+		} else {
+			for _, field := range []struct {
+				target *rrsql.NullString
+				value  string
+			}{
+				{&p.LinkTitle, info.Title},
+				{&p.LinkDescription, info.Description},
+				{&p.LinkImage, info.Image},
+				{&p.LinkName, info.SiteName},
+			} {
+				if !field.target.Valid && field.value != "" {
+					*field.target = rrsql.NullString{String: field.value, Valid: true}
+				}
+			}
+		}
+	}
+	// Thumbnails of YouTube are always there even if meta is not
+	if !p.LinkImage.Valid && embed != nil && embed.Provider == "youtube" {
+		p.LinkImage = rrsql.NullString{String: fmt.Sprintf("https://img.youtube.com/vi/%s/hqdefault.jpg", embed.ID), Valid: true}
+	}
+}
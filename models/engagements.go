@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/olivere/elastic"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+// Engagement is how readers engage with a resource, by which hot tags are scored
+type Engagement struct {
+	Clicks    int `json:"clicks"`
+	PageViews int `json:"pageviews"`
+	Visitors  int `json:"visitors"`
+}
+
+// EngagementSource provides engagements of posts or projects.
+// Resources without any engagement could be left out of the result.
+type EngagementSource interface {
+	Engagements(resourceType string, ids []int) (map[int]Engagement, error)
+}
+
+// viewEngagementSource reads views counted by ViewAPI, both flushed to MySQL and still buffered in Redis
+type viewEngagementSource struct{}
+
+func (s *viewEngagementSource) Engagements(resourceType string, ids []int) (result map[int]Engagement, err error) {
+
+	result = make(map[int]Engagement)
+	res, ok := viewResources[resourceType]
+	if !ok || res.uniqueField == "" {
+		return nil, errors.New("Invalid Resource Type")
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(`SELECT %s, IFNULL(%s, 0), IFNULL(%s, 0) FROM %s WHERE %s IN (?);`,
+		res.idField, res.totalField, res.uniqueField, res.table, res.idField), ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := rrsql.DB.Queryx(rrsql.DB.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var e Engagement
+		if err = rows.Scan(&id, &e.PageViews, &e.Visitors); err != nil {
+			return nil, err
+		}
+		result[id] = e
+	}
+
+	// Views not flushed yet are added, while MySQL is good enough if Redis fails
+	views := viewAPI{}
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+	for _, id := range ids {
+		conn.Send("GET", views.totalKey(resourceType, id))
+		conn.Send("PFCOUNT", views.uniqueKey(resourceType, id))
+	}
+	if err = conn.Flush(); err != nil {
+		log.Printf("Error getting buffered views of %s: %v\n", resourceType, err)
+		return result, nil
+	}
+	for _, id := range ids {
+		pending, err := redis.Int(conn.Receive())
+		if err != nil && err != redis.ErrNil {
+			log.Printf("Error getting buffered views of %s %d: %v\n", resourceType, id, err)
+			return result, nil
+		}
+		unique, err := redis.Int(conn.Receive())
+		if err != nil {
+			log.Printf("Error counting buffered unique views of %s %d: %v\n", resourceType, id, err)
+			return result, nil
+		}
+		if pending == 0 && unique == 0 {
+			continue
+		}
+		e := result[id]
+		e.PageViews += pending
+		if unique > e.Visitors {
+			e.Visitors = unique
+		}
+		result[id] = e
+	}
+	return result, nil
+}
+
+// esEngagementSource counts click and pageview events of resource urls logged in Elasticsearch
+type esEngagementSource struct{}
+
+func (s *esEngagementSource) Engagements(resourceType string, ids []int) (result map[int]Engagement, err error) {
+
+	result = make(map[int]Engagement)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	// Events are logged by url, which is made of slug for projects
+	urls := make(map[string]int)
+	switch resourceType {
+	case "post":
+		for _, id := range ids {
+			urls[utils.GenerateResourceInfo(resourceType, id, "")] = id
+		}
+	case "project":
+		query, args, err := sqlx.In(`SELECT project_id, slug FROM projects WHERE project_id IN (?) AND slug IS NOT NULL;`, ids)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := rrsql.DB.Queryx(rrsql.DB.Rebind(query), args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int
+			var slug string
+			if err = rows.Scan(&id, &slug); err != nil {
+				return nil, err
+			}
+			urls[utils.GenerateResourceInfo(resourceType, id, slug)] = id
+		}
+	default:
+		return nil, errors.New("Invalid Resource Type")
+	}
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	client, err := ESConn(map[string]string{
+		"url": config.Config.ES.Url,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]interface{}, 0, len(urls))
+	for u := range urls {
+		terms = append(terms, u)
+	}
+	filter := elastic.NewBoolQuery().Must(
+		elastic.NewTermsQuery("jsonPayload.curr-url.keyword", terms...),
+		elastic.NewTermsQuery("jsonPayload.event-type.keyword", "click", "pageview"),
+	)
+	aggs := elastic.NewTermsAggregation().
+		CollectionMode("breadth_first").
+		Field("jsonPayload.curr-url.keyword").
+		Size(10000).
+		SubAggregation("evt", elastic.NewTermsAggregation().CollectionMode("breadth_first").Field("jsonPayload.event-type.keyword"))
+
+	searchResult, err := client.Search(config.Config.ES.LogIndices).Query(elastic.NewConstantScoreQuery(filter)).Aggregation("counts", aggs).Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	counts, found := searchResult.Aggregations.Terms("counts")
+	if !found {
+		return nil, errors.New("Aggregation Not Found")
+	}
+	for _, bucket := range counts.Buckets {
+		key, _ := bucket.Key.(string)
+		id, ok := urls[key]
+		if !ok {
+			continue
+		}
+		events, found := bucket.Terms("evt")
+		if !found {
+			continue
+		}
+		e := result[id]
+		for _, event := range events.Buckets {
+			switch event.Key {
+			case "click":
+				e.Clicks = int(event.DocCount)
+			case "pageview":
+				e.PageViews = int(event.DocCount)
+			}
+		}
+		result[id] = e
+	}
+	return result, nil
+}
+
+// FixtureEngagementSource serves fixed engagements by resource type and id, for tests and deployments without any counter
+type FixtureEngagementSource map[string]map[int]Engagement
+
+func (s FixtureEngagementSource) Engagements(resourceType string, ids []int) (map[int]Engagement, error) {
+
+	result := make(map[int]Engagement)
+	for _, id := range ids {
+		if e, ok := s[resourceType][id]; ok {
+			result[id] = e
+		}
+	}
+	return result, nil
+}
+
+// LoadEngagementFixture reads fixture in JSON, such as {"post":{"1":{"clicks":1,"pageviews":2,"visitors":1}}}
+func LoadEngagementFixture(path string) (FixtureEngagementSource, error) {
+
+	fixture := make(FixtureEngagementSource)
+	if path == "" {
+		return fixture, nil
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &fixture); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// SetEngagementSource sets EngagementAPI by name, which is "views" by default
+func SetEngagementSource(name string) error {
+
+	switch name {
+	case "", "views":
+		EngagementAPI = new(viewEngagementSource)
+	case "es":
+		EngagementAPI = new(esEngagementSource)
+	case "fixture":
+		fixture, err := LoadEngagementFixture(config.Config.HotTags.Fixture)
+		if err != nil {
+			return err
+		}
+		EngagementAPI = fixture
+	default:
+		return errors.New("Invalid Engagement Source")
+	}
+	return nil
+}
+
+var EngagementAPI EngagementSource = new(viewEngagementSource)
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/readr-media/readr-restful/config"
+)
+
+// CachedFeed is a rendered feed stored in Redis together with its validators
+type CachedFeed struct {
+	Body         []byte `redis:"body"`
+	ETag         string `redis:"etag"`
+	LastModified string `redis:"last_modified"`
+}
+
+type feedCache struct {
+	prefix string
+	ttl    *int
+}
+
+// FeedCache keeps rendered feeds in Redis until the next publishing
+var FeedCache = feedCache{prefix: "feedcache", ttl: &config.Config.Feed.CacheTTL}
+
+// Key assembles the redis key for feed in format with scope, like "tagging=3"
+func (f feedCache) Key(format string, scope string) string {
+	return fmt.Sprintf("%s_%s_%s", f.prefix, format, scope)
+}
+
+// Get returns the cached feed under key. ok is false if there is no such cache
+func (f feedCache) Get(key string) (feed CachedFeed, ok bool) {
+
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+
+	res, err := redis.Values(conn.Do("HGETALL", key))
+	if err != nil || len(res) == 0 {
+		return feed, false
+	}
+	if err = redis.ScanStruct(res, &feed); err != nil {
+		log.Printf("Error scan feed cache %s: %v\n", key, err)
+		return feed, false
+	}
+	return feed, len(feed.Body) > 0
+}
+
+// Set saves feed under key, expiring after ttl seconds in config
+func (f feedCache) Set(key string, feed CachedFeed) error {
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("HMSET", redis.Args{}.Add(key).AddFlat(&feed)...)
+	if f.ttl != nil && *f.ttl > 0 {
+		conn.Send("EXPIRE", key, *f.ttl)
+	}
+	if _, err := conn.Do("EXEC"); err != nil {
+		log.Printf("Error set feed cache %s: %v\n", key, err)
+		return err
+	}
+	return nil
+}
+
+// Invalidate removes every cached feed
+func (f feedCache) Invalidate() {
+
+	keys, err := RedisHelper.GetRedisKeys(fmt.Sprintf("%s_*", f.prefix))
+	if err != nil || len(keys) == 0 {
+		return
+	}
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	if _, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...); err != nil {
+		log.Printf("Error invalidate feed cache: %v\n", err)
+	}
+}
@@ -16,6 +16,7 @@ type FilterArgs struct {
 	Description []string             `form:"description"`
 	Content     []string             `form:"content"`
 	Author      []string             `form:"author"`
+	AuthorType  []int                `form:"author_type"`
 	Tag         []string             `form:"tag"`
 	PublishedAt map[string]time.Time `form:"published_at"`
 	CreatedAt   map[string]time.Time `form:"created_at"`
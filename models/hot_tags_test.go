@@ -0,0 +1,71 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreHotTags(t *testing.T) {
+
+	backup := config.Config.Models.HotTagsWeight
+	config.Config.Models.HotTagsWeight = map[string]int{
+		"click": 1, "pv": 2, "uv": 3, "follow": 4, "emotion": 5, "comment": 6,
+		"tag_follow": 10, "tagged_post": 1,
+	}
+	defer func() { config.Config.Models.HotTagsWeight = backup }()
+
+	source := FixtureEngagementSource{
+		"post":    {1: {Clicks: 10, PageViews: 100, Visitors: 10}},
+		"project": {3: {PageViews: 1000}},
+	}
+	tags := map[int]tagStats{
+		1: {PostIDs: []int{1, 2}, TagFollows: 1, TaggedPosts: 2},
+		2: {ProjectIDs: []int{3}},
+		3: {PostIDs: []int{2}, TaggedPosts: 1},
+		4: {ProjectIDs: []int{3}},
+	}
+	resources := map[string]map[int]tagResStats{
+		"post":    {1: {Comments: 1}, 2: {Follows: 2}},
+		"project": {3: {}},
+	}
+
+	assert.Nil(t, fillEngagements(source, resources))
+	assert.Equal(t, 10, resources["post"][1].Clicks)
+	assert.Equal(t, 1000, resources["project"][3].PageView)
+
+	// post 1: 1*1 + 2*2 + 1*3 + 1*6, post 2: 2*4, project 3: 3*2
+	expected := sortableList{{1, 34}, {3, 9}, {2, 6}, {4, 6}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, scoreHotTags(tags, resources))
+	}
+}
+
+func TestEngagementSource(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "engagements")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"post":{"1":{"clicks":1,"pageviews":2,"visitors":1}}}`), 0644))
+
+	fixture, err := LoadEngagementFixture(path)
+	assert.Nil(t, err)
+	engagements, err := fixture.Engagements("post", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]Engagement{1: {Clicks: 1, PageViews: 2, Visitors: 1}}, engagements)
+
+	backup, fixturePath := EngagementAPI, config.Config.HotTags.Fixture
+	defer func() { EngagementAPI, config.Config.HotTags.Fixture = backup, fixturePath }()
+
+	config.Config.HotTags.Fixture = path
+	assert.Nil(t, SetEngagementSource("fixture"))
+	assert.Equal(t, fixture, EngagementAPI)
+	assert.Nil(t, SetEngagementSource("es"))
+	assert.IsType(t, new(esEngagementSource), EngagementAPI)
+	assert.EqualError(t, SetEngagementSource("ga"), "Invalid Engagement Source")
+}
@@ -0,0 +1,356 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Status of checked links. Links other than ok and pending are broken after failure_threshold checks in a row.
+const (
+	LinkPending       = "pending"
+	LinkOK            = "ok"
+	LinkNotFound      = "not_found"
+	LinkSoft404       = "soft_404"
+	LinkDomainChanged = "domain_changed"
+	LinkError         = "error"
+)
+
+// linkCheckSources select id, url and the member responsible for resources with links to check
+var linkCheckSources = map[string]func() string{
+	"post": func() string {
+		return fmt.Sprintf(`SELECT post_id AS id, link AS url, updated_by AS member_id FROM posts WHERE active = %d AND link IS NOT NULL AND link != ''`,
+			config.Config.Models.Posts["active"])
+	},
+	"card_image": func() string {
+		return fmt.Sprintf(`SELECT n.id, n.image AS url, p.updated_by AS member_id FROM newscards AS n LEFT JOIN posts AS p ON p.post_id = n.post_id WHERE n.active = %d AND n.image IS NOT NULL AND n.image != ''`,
+			config.Config.Models.Cards["active"])
+	},
+	"card_video": func() string {
+		return fmt.Sprintf(`SELECT n.id, n.video AS url, p.updated_by AS member_id FROM newscards AS n LEFT JOIN posts AS p ON p.post_id = n.post_id WHERE n.active = %d AND n.video IS NOT NULL AND n.video != ''`,
+			config.Config.Models.Cards["active"])
+	},
+	"asset": func() string {
+		return fmt.Sprintf(`SELECT id, destination AS url, updated_by AS member_id FROM assets WHERE active = %d AND destination IS NOT NULL AND destination != ''`,
+			config.Config.Models.Assets["active"])
+	},
+	"comment": func() string {
+		return fmt.Sprintf(`SELECT l.id, l.url, NULL AS member_id FROM comment_links AS l INNER JOIN comments AS c ON c.id = l.comment_id WHERE c.active = %d`,
+			config.Config.Models.Comment["active"])
+	},
+}
+
+// linkNotifyChannels map channels notifying broken links to the column recording notification through it
+var linkNotifyChannels = map[string]string{
+	"slack": "slack_notified_at",
+	"mail":  "mail_notified_at",
+}
+
+// soft404Title matches titles of pages saying not found with status 200
+var soft404Title = regexp.MustCompile(`(?i)(\b404\b|not found|page not found|找不到|不存在)`)
+
+// ValidateLinkCheckType checks resource type of checked links
+func ValidateLinkCheckType(resource string) error {
+	if _, ok := linkCheckSources[resource]; !ok {
+		return errors.New("Invalid Type")
+	}
+	return nil
+}
+
+// LinkCheck is the latest check of an url stored in resource
+type LinkCheck struct {
+	ID              int64              `json:"id" db:"id"`
+	ResourceType    string             `json:"resource_type" db:"resource_type"`
+	ResourceID      int64              `json:"resource_id" db:"resource_id"`
+	URL             string             `json:"url" db:"url"`
+	MemberID        rrsql.NullInt      `json:"member_id" db:"member_id"`
+	Status          string             `json:"status" db:"status"`
+	HTTPStatus      rrsql.NullInt      `json:"http_status" db:"http_status"`
+	FinalURL        rrsql.NullString   `json:"final_url" db:"final_url"`
+	Failures        int                `json:"failures" db:"failures"`
+	CheckedAt       rrsql.NullTime     `json:"checked_at" db:"checked_at"`
+	NextCheckAt     rrsql.NullTime     `json:"-" db:"next_check_at"`
+	SlackNotifiedAt rrsql.NullTime     `json:"slack_notified_at" db:"slack_notified_at"`
+	MailNotifiedAt  rrsql.NullTime     `json:"mail_notified_at" db:"mail_notified_at"`
+	CreatedAt       rrsql.NullTime     `json:"created_at" db:"created_at"`
+	History         []LinkCheckHistory `json:"history,omitempty" db:"-"`
+}
+
+type LinkCheckHistory struct {
+	LinkCheckID int64            `json:"-" db:"link_check_id"`
+	Status      string           `json:"status" db:"status"`
+	HTTPStatus  rrsql.NullInt    `json:"http_status" db:"http_status"`
+	FinalURL    rrsql.NullString `json:"final_url" db:"final_url"`
+	CheckedAt   rrsql.NullTime   `json:"checked_at" db:"checked_at"`
+}
+
+// LinkCheckNotice is a broken link not notified yet, with the member responsible for it
+type LinkCheckNotice struct {
+	LinkCheck
+	MemberNickname rrsql.NullString `json:"member_nickname" db:"member_nickname"`
+	MemberMail     rrsql.NullString `json:"member_mail" db:"member_mail"`
+}
+
+type GetBrokenLinksArgs struct {
+	Type        string `form:"type"`
+	MemberID    int64  `form:"member_id"`
+	ShowHistory bool   `form:"show_history"`
+	MaxResult   int    `form:"max_result"`
+	Page        int    `form:"page"`
+}
+
+type LinkCheckInterface interface {
+	Sync() error
+	Check(limit int) (int, error)
+	GetBroken(args *GetBrokenLinksArgs) ([]LinkCheck, error)
+	Unnotified(channels []string) ([]LinkCheckNotice, error)
+	MarkNotified(ids []int64, channel string) error
+}
+
+type linkCheckAPI struct{}
+
+var LinkCheckAPI LinkCheckInterface = new(linkCheckAPI)
+
+func linkCheckThreshold() int {
+	if config.Config.LinkCheck.FailureThreshold <= 0 {
+		return 3
+	}
+	return config.Config.LinkCheck.FailureThreshold
+}
+
+// Sync adds urls of resources to check, and drops checks of resources gone.
+// Checks of changed urls start over.
+func (a *linkCheckAPI) Sync() error {
+
+	for resource, source := range linkCheckSources {
+		_, err := rrsql.DB.Exec(fmt.Sprintf(`
+			INSERT INTO link_checks (resource_type, resource_id, url, member_id) SELECT ?, s.id, s.url, s.member_id FROM (%s) AS s
+			ON DUPLICATE KEY UPDATE member_id = VALUES(member_id),
+				status = IF(url = VALUES(url), status, ?), failures = IF(url = VALUES(url), failures, 0),
+				next_check_at = IF(url = VALUES(url), next_check_at, NOW()),
+				slack_notified_at = IF(url = VALUES(url), slack_notified_at, NULL), mail_notified_at = IF(url = VALUES(url), mail_notified_at, NULL),
+				url = VALUES(url);`, source()), resource, LinkPending)
+		if err != nil {
+			return err
+		}
+		_, err = rrsql.DB.Exec(fmt.Sprintf(`DELETE FROM link_checks WHERE resource_type = ? AND resource_id NOT IN (SELECT s.id FROM (%s) AS s);`, source()), resource)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := rrsql.DB.Exec(`DELETE h FROM link_check_history AS h LEFT JOIN link_checks AS l ON l.id = h.link_check_id WHERE l.id IS NULL;`)
+	return err
+}
+
+// probe requests target with HEAD, or GET if HEAD is not allowed, and tells status of it
+func (a *linkCheckAPI) probe(client *http.Client, target string) (status string, httpStatus int, finalURL string) {
+
+	unfurl := new(unfurlAPI)
+	resp, err := unfurl.request(client, "HEAD", target)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusForbidden) {
+		resp.Body.Close()
+		resp, err = unfurl.request(client, "GET", target)
+	} else if err != nil {
+		resp, err = unfurl.request(client, "GET", target)
+	}
+	if err != nil {
+		return LinkError, 0, ""
+	}
+	defer resp.Body.Close()
+
+	httpStatus = resp.StatusCode
+	finalURL = resp.Request.URL.String()
+	switch {
+	case httpStatus == http.StatusNotFound || httpStatus == http.StatusGone:
+		return LinkNotFound, httpStatus, finalURL
+	case httpStatus >= 400:
+		return LinkError, httpStatus, finalURL
+	}
+
+	origin, err := url.Parse(target)
+	if err != nil {
+		return LinkError, httpStatus, finalURL
+	}
+	final := resp.Request.URL
+	if strings.TrimPrefix(strings.ToLower(origin.Hostname()), "www.") != strings.TrimPrefix(strings.ToLower(final.Hostname()), "www.") {
+		return LinkDomainChanged, httpStatus, finalURL
+	}
+	// Pages gone are often redirected to home page
+	if strings.Trim(origin.Path, "/") != "" && strings.Trim(final.Path, "/") == "" {
+		return LinkSoft404, httpStatus, finalURL
+	}
+	if resp.Request.Method == "GET" && strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		if body, err := readBody(resp.Body); err == nil {
+			if doc, err := goquery.NewDocumentFromReader(bytes.NewBuffer(body)); err == nil && soft404Title.MatchString(doc.Find("title").First().Text()) {
+				return LinkSoft404, httpStatus, finalURL
+			}
+		}
+	}
+	return LinkOK, httpStatus, finalURL
+}
+
+// Check re-validates at most limit links due, and returns how many links are checked.
+// Healthy links are checked every interval_hours, while failing ones are retried with exponential backoff.
+func (a *linkCheckAPI) Check(limit int) (checked int, err error) {
+
+	interval := time.Duration(config.Config.LinkCheck.IntervalHours) * time.Hour
+	if interval <= 0 {
+		interval = 7 * 24 * time.Hour
+	}
+	backoff := time.Duration(config.Config.LinkCheck.BackoffMinutes) * time.Minute
+	if backoff <= 0 {
+		backoff = time.Hour
+	}
+
+	var due []LinkCheck
+	if err = rrsql.DB.Select(&due, `SELECT * FROM link_checks WHERE next_check_at <= NOW() ORDER BY next_check_at LIMIT ?;`, limit); err != nil {
+		return 0, err
+	}
+
+	client := new(unfurlAPI).client()
+	for _, link := range due {
+		status, httpStatus, finalURL := a.probe(client, link.URL)
+
+		next := interval
+		failures := 0
+		if status != LinkOK {
+			failures = link.Failures + 1
+			if next = backoff << uint(failures-1); next > interval || next <= 0 {
+				next = interval
+			}
+		}
+		code := rrsql.NullInt{Int: int64(httpStatus), Valid: httpStatus != 0}
+		final := rrsql.NullString{String: finalURL, Valid: finalURL != ""}
+
+		err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+			_, _, err := rrsql.RunPipeline(tx,
+				&rrsql.PipelineStmt{
+					Query: `UPDATE link_checks SET status = ?, http_status = ?, final_url = ?, failures = ?, checked_at = NOW(),
+						next_check_at = DATE_ADD(NOW(), INTERVAL ? SECOND), slack_notified_at = IF(? = 0, NULL, slack_notified_at),
+						mail_notified_at = IF(? = 0, NULL, mail_notified_at) WHERE id = ?;`,
+					Args: []interface{}{status, code, final, failures, int(next.Seconds()), failures, failures, link.ID},
+				},
+				&rrsql.PipelineStmt{
+					Query: `INSERT INTO link_check_history (link_check_id, status, http_status, final_url) VALUES (?, ?, ?, ?);`,
+					Args:  []interface{}{link.ID, status, code, final},
+				},
+			)
+			return err
+		})
+		if err != nil {
+			return checked, err
+		}
+		checked++
+	}
+	return checked, nil
+}
+
+// GetBroken lists links failed failure_threshold checks in a row, the latest checked first
+func (a *linkCheckAPI) GetBroken(args *GetBrokenLinksArgs) (result []LinkCheck, err error) {
+
+	where := []string{"status NOT IN (?)", "failures >= ?"}
+	values := []interface{}{[]string{LinkOK, LinkPending}, linkCheckThreshold()}
+	if args.Type != "" {
+		where = append(where, "resource_type = ?")
+		values = append(values, args.Type)
+	}
+	if args.MemberID != 0 {
+		where = append(where, "member_id = ?")
+		values = append(values, args.MemberID)
+	}
+	values = append(values, args.MaxResult, (args.Page-1)*args.MaxResult)
+
+	query, values, err := sqlx.In(fmt.Sprintf(`SELECT * FROM link_checks WHERE %s ORDER BY checked_at DESC LIMIT ? OFFSET ?;`, strings.Join(where, " AND ")), values...)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]LinkCheck, 0)
+	if err = rrsql.DB.Select(&result, rrsql.DB.Rebind(query), values...); err != nil {
+		return nil, err
+	}
+	if !args.ShowHistory || len(result) == 0 {
+		return result, nil
+	}
+
+	ids := make([]int64, len(result))
+	for i, link := range result {
+		ids[i] = link.ID
+	}
+	query, values, err = sqlx.In(`SELECT link_check_id, status, http_status, final_url, checked_at FROM link_check_history WHERE link_check_id IN (?) ORDER BY checked_at DESC;`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var history []LinkCheckHistory
+	if err = rrsql.DB.Select(&history, rrsql.DB.Rebind(query), values...); err != nil {
+		return nil, err
+	}
+	for i := range result {
+		for _, h := range history {
+			if h.LinkCheckID == result[i].ID {
+				result[i].History = append(result[i].History, h)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Unnotified lists broken links not notified through any of channels since they broke
+func (a *linkCheckAPI) Unnotified(channels []string) (result []LinkCheckNotice, err error) {
+
+	pending := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		column, ok := linkNotifyChannels[channel]
+		if !ok {
+			return nil, errors.New("Invalid Channel")
+		}
+		pending = append(pending, fmt.Sprintf("l.%s IS NULL", column))
+	}
+	result = make([]LinkCheckNotice, 0)
+	if len(pending) == 0 {
+		return result, nil
+	}
+	query, values, err := sqlx.In(fmt.Sprintf(`
+		SELECT l.*, m.nickname AS member_nickname, m.mail AS member_mail FROM link_checks AS l
+		LEFT JOIN members AS m ON m.id = l.member_id
+		WHERE l.status NOT IN (?) AND l.failures >= ? AND (%s) ORDER BY l.member_id, l.id;`, strings.Join(pending, " OR ")),
+		[]string{LinkOK, LinkPending}, linkCheckThreshold())
+	if err != nil {
+		return nil, err
+	}
+	if err = rrsql.DB.Select(&result, rrsql.DB.Rebind(query), values...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkNotified records links notified through channel
+func (a *linkCheckAPI) MarkNotified(ids []int64, channel string) error {
+
+	column, ok := linkNotifyChannels[channel]
+	if !ok {
+		return errors.New("Invalid Channel")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	query, values, err := sqlx.In(fmt.Sprintf(`UPDATE link_checks SET %s = NOW() WHERE id IN (?);`, column), ids)
+	if err != nil {
+		return err
+	}
+	if _, err = rrsql.DB.Exec(rrsql.DB.Rebind(query), values...); err != nil {
+		log.Printf("Error mark link checks %v notified through %s: %v\n", ids, channel, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkCheckProbe(t *testing.T) {
+
+	// moved serves redirection target on another host name of loopback
+	moved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer moved.Close()
+	movedURL := strings.Replace(moved.URL, "127.0.0.1", "localhost", 1)
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	for _, tc := range []struct {
+		name       string
+		handler    http.HandlerFunc
+		path       string
+		status     string
+		httpStatus int
+		methods    []string
+	}{
+		{"OK", func(w http.ResponseWriter, r *http.Request) {}, "/post/1", LinkOK, http.StatusOK, []string{"HEAD"}},
+		{"HeadNotAllowed", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><title>readr</title></head></html>`)
+		}, "/post/1", LinkOK, http.StatusOK, []string{"HEAD", "GET"}},
+		{"NotFound", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) }, "/post/1", LinkNotFound, http.StatusNotFound, []string{"HEAD"}},
+		{"Gone", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGone) }, "/post/1", LinkNotFound, http.StatusGone, []string{"HEAD"}},
+		{"ServerError", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) }, "/post/1", LinkError, http.StatusInternalServerError, []string{"HEAD"}},
+		{"DomainChanged", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, movedURL+"/post/1", http.StatusMovedPermanently)
+		}, "/post/1", LinkDomainChanged, http.StatusOK, []string{"HEAD"}},
+		{"Soft404RedirectedHome", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.Redirect(w, r, "/", http.StatusFound)
+			}
+		}, "/post/1", LinkSoft404, http.StatusOK, []string{"HEAD", "HEAD"}},
+		{"HomeNotSoft404", func(w http.ResponseWriter, r *http.Request) {}, "/", LinkOK, http.StatusOK, []string{"HEAD"}},
+		{"Soft404Title", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><title>Page Not Found | readr</title></head></html>`)
+		}, "/post/1", LinkSoft404, http.StatusOK, []string{"HEAD", "GET"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			methods := make([]string, 0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+				tc.handler(w, r)
+			}))
+			defer server.Close()
+
+			status, httpStatus, _ := new(linkCheckAPI).probe(&http.Client{}, server.URL+tc.path)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, tc.httpStatus, httpStatus)
+			assert.Equal(t, tc.methods, methods)
+		})
+	}
+
+	t.Run("Unreachable", func(t *testing.T) {
+		status, httpStatus, finalURL := new(linkCheckAPI).probe(&http.Client{}, closed.URL+"/post/1")
+		assert.Equal(t, LinkError, status)
+		assert.Equal(t, 0, httpStatus)
+		assert.Equal(t, "", finalURL)
+	})
+}
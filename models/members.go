@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -509,6 +510,11 @@ func (a *memberAPI) DeleteMember(idType string, id string) error {
 	} else if rowCnt == 0 {
 		return errors.New("User Not Found")
 	}
+	if idType == "id" {
+		if memberID, err := strconv.Atoi(id); err == nil {
+			recordTrash("member", []int{memberID})
+		}
+	}
 	return err
 }
 
@@ -529,6 +535,13 @@ func (a *memberAPI) UpdateAll(ids []int64, active int) (err error) {
 	} else if rowCnt == 0 {
 		return errors.New("Members Not Found")
 	}
+	if active == config.Config.Models.Members["delete"] {
+		deleted := make([]int, 0, len(ids))
+		for _, id := range ids {
+			deleted = append(deleted, int(id))
+		}
+		recordTrash("member", deleted)
+	}
 	return err
 }
 
@@ -58,6 +58,8 @@ type Memo struct {
 	LinkName        rrsql.NullString `json:"link_name" db:"link_name" redis:"link_name"`
 	VideoID         rrsql.NullString `json:"video_id" db:"video_id" redis:"video_id"`
 	VideoViews      rrsql.NullInt    `json:"video_views" db:"video_views" redis:"video_views"`
+	Views           rrsql.NullInt    `json:"views" db:"views" redis:"views"`
+	UniqueViews     rrsql.NullInt    `json:"unique_views" db:"unique_views" redis:"unique_views"`
 	PublishStatus   rrsql.NullInt    `json:"publish_status" db:"publish_status" redis:"publish_status"`
 	ProjectID       rrsql.NullInt    `json:"project_id" db:"project_id" redis:"project_id"`
 	Order           rrsql.NullInt    `json:"post_order" db:"post_order" redis:"post_order"`
@@ -65,6 +67,12 @@ type Memo struct {
 	Slug            rrsql.NullString `json:"slug" db:"slug" redis:"slug"`
 	CSS             rrsql.NullString `json:"css" db:"css" redis:"css"`
 	JS              rrsql.NullString `json:"javascript" db:"javascript" redis:"javascript"`
+	CharCount       rrsql.NullInt    `json:"char_count" db:"char_count" redis:"char_count"`
+	WordCount       rrsql.NullInt    `json:"word_count" db:"word_count" redis:"word_count"`
+	ReadingTime     rrsql.NullInt    `json:"reading_time" db:"reading_time" redis:"reading_time"`
+	ImageCount      rrsql.NullInt    `json:"image_count" db:"image_count" redis:"image_count"`
+	EmbedCount      rrsql.NullInt    `json:"embed_count" db:"embed_count" redis:"embed_count"`
+	Version         rrsql.NullInt    `json:"version" db:"version" redis:"version"`
 }
 
 type MemoInterface interface {
@@ -413,6 +421,7 @@ func (m *memoAPI) GetMemos(args *MemoGetArgs) (memos []MemoDetail, err error) {
 func (m *memoAPI) InsertMemo(memo Memo) (lastID int, err error) {
 
 	memo.Type = rrsql.NullInt{int64(config.Config.Models.PostType["memo"]), true}
+	memo.Version = rrsql.NullInt{Int: 1, Valid: true}
 
 	tags := rrsql.GetStructDBTags("full", Memo{})
 	query := fmt.Sprintf(`INSERT INTO posts (%s) VALUES (:%s)`,
@@ -449,10 +458,12 @@ func (m *memoAPI) InsertMemo(memo Memo) (lastID int, err error) {
 
 func (m *memoAPI) UpdateMemo(memo Memo) (err error) {
 
+	// Memos share version with posts, which is bumped so that post editors see the change
+	memo.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", memo)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, tags)
-	query := fmt.Sprintf(`UPDATE posts SET %s WHERE post_id = :post_id`,
-		strings.Join(fields, ", "))
+	query := rrsql.Versioned(fmt.Sprintf(`UPDATE posts SET %s WHERE post_id = :post_id`,
+		strings.Join(fields, ", ")), rrsql.NullInt{})
 
 	result, err := rrsql.DB.NamedExec(query, memo)
 
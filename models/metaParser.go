@@ -5,63 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
-	"crypto/tls"
-	"io/ioutil"
 	"net/http"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/readr-media/readr-restful/config"
 )
 
 type OGInfo struct {
-	Title       string `meta:"og:title" json:"og_title"`
-	Description string `meta:"og:description" json:"og_description"`
-	Image       string `meta:"og:image,og:image:url" json:"og_image"`
-	SiteName    string `meta:"og:site_name" json:"og_site_name"`
+	Title       string      `meta:"og:title" json:"og_title"`
+	Description string      `meta:"og:description" json:"og_description"`
+	Image       string      `meta:"og:image,og:image:url" json:"og_image"`
+	SiteName    string      `meta:"og:site_name" json:"og_site_name"`
+	Twitter     TwitterCard `json:"twitter"`
+	Favicon     string      `json:"favicon"`
+	OEmbed      *OEmbed     `json:"oembed,omitempty"`
 }
 
 type ogParser struct{}
 
+// GetOGInfoFromUrl is kept for compatibility, and goes through UnfurlAPI
 func (o *ogParser) GetOGInfoFromUrl(urlStr string) (*OGInfo, error) {
-
-	tr := &http.Transport{TLSClientConfig: &tls.Config{
-		NextProtos: []string{"h1"},
-	}}
-
-	client := &http.Client{Transport: tr, Timeout: time.Duration(5 * time.Second)}
-
-	req, err := http.NewRequest("GET", urlStr, nil)
-
-	// for k, v := range OGParserHeaders {
-	for k, v := range config.Config.Crawler.Headers {
-		req.Header.Add(k, v)
-	}
-
-	if !regexp.MustCompile("\\.readr\\.tw\\/").MatchString(urlStr) {
-		req.Header.Del("Cookie")
-	}
-
-	if regexp.MustCompile("\\.youtube\\.com\\/").MatchString(urlStr) {
-		req.Header.Del("User-Agent")
-		req.Header.Add("User-Agent", "facebookexternalhit/1.1")
-	}
-
-	resp, err := client.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
-	return o.GetPageInfoFromResponse(resp)
+	return UnfurlAPI.Unfurl(urlStr)
 }
 
 func (o *ogParser) GetPageInfoFromResponse(response *http.Response) (*OGInfo, error) {
 	info := OGInfo{}
-	html, err := ioutil.ReadAll(response.Body)
+	html, err := readBody(response.Body)
 
 	if err != nil {
 		return nil, err
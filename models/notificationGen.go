@@ -356,7 +356,7 @@ func (c notificationGenerator) GenerateCommentNotifications(comment InsertCommen
 	return err
 }
 
-func (c notificationGenerator) GenerateProjectNotifications(resource interface{}, resourceTyep string) (err error) { //memo, report, project
+func (c notificationGenerator) GenerateProjectNotifications(resource interface{}, resourceTyep string) (err error) { //memo, report, project, milestone
 	ns := Notifications{}
 
 	switch resourceTyep {
@@ -446,6 +446,30 @@ func (c notificationGenerator) GenerateProjectNotifications(resource interface{}
 			ns = append(ns, n)
 		}
 
+	case "milestone":
+		m := resource.(ProjectMilestone)
+		eventType := "follow_project_milestone"
+		tagEventType := "follow_tag_project_milestone"
+
+		projectFollowers, err := c.getFollowers(m.Project.ID, config.Config.Models.FollowingType["project"], []int{0})
+		if err != nil {
+			log.Println("Error get project followers", m.Project.ID, err.Error())
+		}
+
+		_ = c.generateTagNotifications(m.Project, tagEventType)
+
+		for _, v := range projectFollowers {
+			n := NewNotification(eventType, v)
+			n.SubjectID = strconv.Itoa(m.ID)
+			n.Nickname = m.Title.String
+			n.ProfileImage = m.Project.HeroImage.String
+			n.ObjectName = m.Project.Title.String
+			n.ObjectType = "project"
+			n.ObjectID = strconv.Itoa(m.Project.ID)
+			n.ObjectSlug = m.Project.Slug.String
+			ns = append(ns, n)
+		}
+
 	default:
 	}
 
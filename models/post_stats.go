@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/pkg/textstats"
+)
+
+type postStatsCard struct {
+	Title           rrsql.NullString `db:"title"`
+	Description     rrsql.NullString `db:"description"`
+	Image           rrsql.NullString `db:"image"`
+	BackgroundImage rrsql.NullString `db:"background_image"`
+	Video           rrsql.NullString `db:"video"`
+}
+
+// postStats computes text statistics from post content and its news cards
+func postStats(content string, cards []postStatsCard) textstats.Stats {
+
+	stats := textstats.HTML(content)
+	for _, card := range cards {
+		stats.Add(textstats.Text(card.Title.String))
+		stats.Add(textstats.HTML(card.Description.String))
+		if card.Image.String != "" || card.BackgroundImage.String != "" {
+			stats.Images++
+		}
+		if card.Video.String != "" {
+			stats.Embeds++
+		}
+	}
+	return stats
+}
+
+// updateStats recomputes text statistics of post id within tx.
+// It reads back what is stored, so partial updates of content or cards are counted correctly.
+func (a *postAPI) updateStats(tx *sqlx.Tx, id uint32) error {
+
+	var content rrsql.NullString
+	if err := tx.Get(&content, `SELECT content FROM posts WHERE post_id = ?`, id); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var cards []postStatsCard
+	if err := tx.Select(&cards, `SELECT title, description, image, background_image, video FROM newscards WHERE post_id = ? AND active = ?`,
+		id, config.Config.Models.Cards["active"]); err != nil {
+		return err
+	}
+
+	stats := postStats(content.String, cards)
+	_, err := tx.Exec(`UPDATE posts SET char_count = ?, word_count = ?, reading_time = ?, image_count = ?, embed_count = ? WHERE post_id = ?`,
+		stats.Characters, stats.Words, stats.ReadingTime(), stats.Images, stats.Embeds, id)
+	return err
+}
+
+// BackfillStats computes text statistics of at most limit posts stored before they were counted,
+// and returns how many posts are updated. It is called repeatedly until none is left.
+func (a *postAPI) BackfillStats(limit int) (updated int, err error) {
+
+	var ids []uint32
+	if err = rrsql.DB.Select(&ids, `SELECT post_id FROM posts WHERE reading_time IS NULL ORDER BY post_id LIMIT ?;`, limit); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+			return a.updateStats(tx, id)
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// PostTranslation is the localized content of a post in language other than the source language
+type PostTranslation struct {
+	PostID        uint32            `json:"post_id" db:"post_id"`
+	Language      string            `json:"language" db:"language"`
+	Title         rrsql.NullString  `json:"title" db:"title"`
+	Subtitle      rrsql.NullString  `json:"subtitle" db:"subtitle"`
+	Content       rrsql.NullString  `json:"content" db:"content"`
+	OgTitle       rrsql.NullString  `json:"og_title" db:"og_title"`
+	OgDescription rrsql.NullString  `json:"og_description" db:"og_description"`
+	OgImage       rrsql.NullString  `json:"og_image" db:"og_image"`
+	CreatedAt     rrsql.NullTime    `json:"created_at" db:"created_at"`
+	UpdatedAt     rrsql.NullTime    `json:"updated_at" db:"updated_at"`
+	UpdatedBy     rrsql.NullInt     `json:"updated_by" db:"updated_by"`
+	Cards         []CardTranslation `json:"cards,omitempty" db:"-"`
+}
+
+// CardTranslation is the localized title and description of a news card
+type CardTranslation struct {
+	CardID      uint32           `json:"card_id" db:"card_id"`
+	Language    string           `json:"-" db:"language"`
+	Title       rrsql.NullString `json:"title" db:"title"`
+	Description rrsql.NullString `json:"description" db:"description"`
+}
+
+// IsSourceLanguage tells whether lang is the language posts are written in
+func IsSourceLanguage(lang string) bool {
+	return lang == config.Config.Translation.SourceLanguage
+}
+
+// Languages lists all languages posts could be translated into, including the source language
+func Languages() []string {
+	if len(config.Config.Translation.Languages) == 0 {
+		return []string{config.Config.Translation.SourceLanguage}
+	}
+	return config.Config.Translation.Languages
+}
+
+// ValidateLanguage checks lang against languages in config
+func ValidateLanguage(lang string) error {
+	for _, v := range Languages() {
+		if v == lang {
+			return nil
+		}
+	}
+	return errors.New("Invalid Language")
+}
+
+// Localize overwrites fields of post with translated ones. Fields not translated are kept in source language.
+func (t PostTranslation) Localize(post *TaggedPostMember) {
+
+	for _, field := range []struct {
+		translated rrsql.NullString
+		source     *rrsql.NullString
+	}{
+		{t.Title, &post.Title},
+		{t.Subtitle, &post.Subtitle},
+		{t.Content, &post.Content},
+		{t.OgTitle, &post.OgTitle},
+		{t.OgDescription, &post.OgDescription},
+		{t.OgImage, &post.OgImage},
+	} {
+		if field.translated.Valid && field.translated.String != "" {
+			*field.source = field.translated
+		}
+	}
+
+	cards := make(map[uint32]CardTranslation, len(t.Cards))
+	for _, card := range t.Cards {
+		cards[card.CardID] = card
+	}
+	for i, card := range post.Cards {
+		if translated, ok := cards[card.ID]; ok {
+			if translated.Title.Valid && translated.Title.String != "" {
+				post.Cards[i].Title = translated.Title
+			}
+			if translated.Description.Valid && translated.Description.String != "" {
+				post.Cards[i].Description = translated.Description
+			}
+		}
+	}
+	post.Language = t.Language
+}
+
+type PostTranslationInterface interface {
+	Get(ids []uint32, lang string) (map[uint32]PostTranslation, error)
+	Languages(id uint32) ([]string, error)
+	Upsert(t PostTranslation) error
+	Delete(id uint32, lang string) error
+}
+
+type postTranslationAPI struct{}
+
+var PostTranslationAPI PostTranslationInterface = new(postTranslationAPI)
+
+// Get returns translations of posts in lang, together with their card translations, keyed by post id
+func (a *postTranslationAPI) Get(ids []uint32, lang string) (result map[uint32]PostTranslation, err error) {
+
+	result = make(map[uint32]PostTranslation)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM post_translations WHERE post_id IN (?) AND language = ?;`, ids, lang)
+	if err != nil {
+		return nil, err
+	}
+	var translations []PostTranslation
+	if err = rrsql.DB.Select(&translations, rrsql.DB.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	if len(translations) == 0 {
+		return result, nil
+	}
+
+	query, args, err = sqlx.In(`
+		SELECT newscards.post_id, newscard_translations.* FROM newscard_translations
+		INNER JOIN newscards ON newscards.id = newscard_translations.card_id
+		WHERE newscards.post_id IN (?) AND newscard_translations.language = ?;`, ids, lang)
+	if err != nil {
+		return nil, err
+	}
+	var cards []struct {
+		PostID uint32 `db:"post_id"`
+		CardTranslation
+	}
+	if err = rrsql.DB.Select(&cards, rrsql.DB.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	for _, t := range translations {
+		result[t.PostID] = t
+	}
+	for _, card := range cards {
+		if t, ok := result[card.PostID]; ok {
+			t.Cards = append(t.Cards, card.CardTranslation)
+			result[card.PostID] = t
+		}
+	}
+	return result, nil
+}
+
+// Languages lists languages post id is available in, starting with the source language
+func (a *postTranslationAPI) Languages(id uint32) (languages []string, err error) {
+
+	languages = []string{config.Config.Translation.SourceLanguage}
+	var translated []string
+	if err = rrsql.DB.Select(&translated, `SELECT language FROM post_translations WHERE post_id = ? ORDER BY language;`, id); err != nil {
+		return nil, err
+	}
+	return append(languages, translated...), nil
+}
+
+// Upsert creates or replaces the translation of post in t.Language, including its card translations
+func (a *postTranslationAPI) Upsert(t PostTranslation) error {
+
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		var exist int
+		if err := tx.Get(&exist, `SELECT COUNT(*) FROM posts WHERE post_id = ?`, t.PostID); err != nil {
+			return err
+		} else if exist == 0 {
+			return errors.New("Post Not Found")
+		}
+
+		if _, err := tx.NamedExec(`
+			INSERT INTO post_translations (post_id, language, title, subtitle, content, og_title, og_description, og_image, updated_at, updated_by)
+			VALUES (:post_id, :language, :title, :subtitle, :content, :og_title, :og_description, :og_image, :updated_at, :updated_by)
+			ON DUPLICATE KEY UPDATE title = VALUES(title), subtitle = VALUES(subtitle), content = VALUES(content),
+			og_title = VALUES(og_title), og_description = VALUES(og_description), og_image = VALUES(og_image),
+			updated_at = VALUES(updated_at), updated_by = VALUES(updated_by);`, t); err != nil {
+			return err
+		}
+
+		// Card translations are replaced as a whole, and only cards of this post could be translated
+		if _, err := tx.Exec(`
+			DELETE newscard_translations FROM newscard_translations
+			INNER JOIN newscards ON newscards.id = newscard_translations.card_id
+			WHERE newscards.post_id = ? AND newscard_translations.language = ?;`, t.PostID, t.Language); err != nil {
+			return err
+		}
+		for _, card := range t.Cards {
+			card.Language = t.Language
+			res, err := tx.Exec(`
+				INSERT INTO newscard_translations (card_id, language, title, description)
+				SELECT id, ?, ?, ? FROM newscards WHERE id = ? AND post_id = ?;`,
+				card.Language, card.Title, card.Description, card.CardID, t.PostID)
+			if err != nil {
+				return err
+			}
+			if rows, _ := res.RowsAffected(); rows == 0 {
+				return errors.New("Card Not Found")
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes translation of post id in lang, together with its card translations
+func (a *postTranslationAPI) Delete(id uint32, lang string) error {
+
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(`DELETE FROM post_translations WHERE post_id = ? AND language = ?;`, id, lang)
+		if err != nil {
+			return err
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return errors.New("Translation Not Found")
+		}
+		_, err = tx.Exec(`
+			DELETE newscard_translations FROM newscard_translations
+			INNER JOIN newscards ON newscards.id = newscard_translations.card_id
+			WHERE newscards.post_id = ? AND newscard_translations.language = ?;`, id, lang)
+		return err
+	})
+}
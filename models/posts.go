@@ -45,6 +45,8 @@ type Post struct {
 	LinkName        rrsql.NullString `json:"link_name" db:"link_name" redis:"link_name"`
 	VideoID         rrsql.NullString `json:"video_id" db:"video_id" redis:"video_id"`
 	VideoViews      rrsql.NullInt    `json:"video_views" db:"video_views" redis:"video_views"`
+	Views           rrsql.NullInt    `json:"views" db:"views" redis:"views"`
+	UniqueViews     rrsql.NullInt    `json:"unique_views" db:"unique_views" redis:"unique_views"`
 	PublishStatus   rrsql.NullInt    `json:"publish_status" db:"publish_status" redis:"publish_status"`
 	ProjectID       rrsql.NullInt    `json:"project_id" db:"project_id" redis:"project_id"`
 	Order           rrsql.NullInt    `json:"post_order" db:"post_order" redis:"post_order"`
@@ -52,6 +54,12 @@ type Post struct {
 	Slug            rrsql.NullString `json:"slug" db:"slug" redis:"slug"`
 	CSS             rrsql.NullString `json:"css" db:"css" redis:"css"`
 	JS              rrsql.NullString `json:"javascript" db:"javascript" redis:"javascript"`
+	CharCount       rrsql.NullInt    `json:"char_count" db:"char_count" redis:"char_count"`
+	WordCount       rrsql.NullInt    `json:"word_count" db:"word_count" redis:"word_count"`
+	ReadingTime     rrsql.NullInt    `json:"reading_time" db:"reading_time" redis:"reading_time"`
+	ImageCount      rrsql.NullInt    `json:"image_count" db:"image_count" redis:"image_count"`
+	EmbedCount      rrsql.NullInt    `json:"embed_count" db:"embed_count" redis:"embed_count"`
+	Version         rrsql.NullInt    `json:"version" db:"version" redis:"version"`
 }
 
 type FilteredPost struct {
@@ -83,10 +91,11 @@ type PostInterface interface {
 	UpdateAll(req PostUpdateArgs) error
 	UpdatePost(p PostDescription) error
 	Count(req args.ArgsParser) (result int, err error)
-	//Hot() (result []HotPost, err error)
 	UpdateAuthors(p Post, authors []AuthorInput) (err error)
 	SchedulePublish() (ids []uint32, err error)
 	GetPostAuthor(id uint32) (member Member, err error)
+	ImportPosts(records []PostDescription, dryRun bool) ([]PostImportResult, error)
+	BackfillStats(limit int) (int, error)
 }
 
 type TaggedPostMember struct {
@@ -97,6 +106,8 @@ type TaggedPostMember struct {
 	Comment   []CommentAuthor `json:"comments,omitempty"`
 	Cards     []postCard      `json:"cards,omitempty"`
 	Project   *ProjectBasic   `json:"project,omitempty" db:"project"`
+	Language  string          `json:"language,omitempty" db:"-"`
+	Embed     *Embed          `json:"embed,omitempty" db:"-"`
 }
 
 // ------------ ↓↓↓ Requirement to satisfy LastPNRInterface  ↓↓↓ ------------
@@ -125,6 +136,10 @@ func (tpm TaggedPostMember) ReturnUpdatedAt() time.Time {
 	return time.Time{}
 }
 
+func (tpm TaggedPostMember) ReturnID() int { return int(tpm.ID) }
+
+func (tpm TaggedPostMember) ReturnResourceType() string { return "post" }
+
 // ------------ ↑↑↑ End of requirement to satisfy LastPNRInterface  ↑↑↑ ------------
 
 type TagBasic struct {
@@ -149,6 +164,7 @@ type AuthorBasic struct {
 	Description  rrsql.NullString `json:"description" db:"description"`
 	Role         rrsql.NullInt    `json:"role" db:"role"`
 	Type         rrsql.NullInt    `json:"author_type" db:"author_type"`
+	Position     rrsql.NullInt    `json:"position" db:"position"`
 	ResourceID   rrsql.NullInt    `json:"resource_id" db:"resource_id"`
 }
 
@@ -172,9 +188,36 @@ type PostUpdateArgs struct {
 	PublishStatus rrsql.NullInt  `json:"-" db:"publish_status"`
 }
 
+// AuthorInput credits member as author_type of a resource.
+// Position orders authors of the same type, and follows the input order if absent.
 type AuthorInput struct {
 	Type     rrsql.NullInt `json:"author_type" db:"author_type"`
 	MemberID rrsql.NullInt `json:"member_id" db:"member_id"`
+	Position rrsql.NullInt `json:"position" db:"position"`
+}
+
+// ValidateAuthorType checks author type against credit types in config
+func ValidateAuthorType(authorType int64) error {
+	for _, v := range config.Config.Models.AuthorType {
+		if int64(v) == authorType {
+			return nil
+		}
+	}
+	return errors.New("Invalid Author Type")
+}
+
+// positionAuthors fills in positions absent from authors with their order within the same author type
+func positionAuthors(authors []AuthorInput) []AuthorInput {
+	positioned := make([]AuthorInput, len(authors))
+	next := make(map[int64]int64)
+	for i, v := range authors {
+		if !v.Position.Valid {
+			v.Position = rrsql.NullInt{Int: next[v.Type.Int], Valid: true}
+		}
+		next[v.Type.Int] = v.Position.Int + 1
+		positioned[i] = v
+	}
+	return positioned
 }
 
 func (p *PostUpdateArgs) parse() (updates string, values []interface{}) {
@@ -220,12 +263,18 @@ type PostArgs struct {
 	ShowCommment  bool               `form:"show_comment"`
 	ShowCard      bool               `form:"show_card"`
 	ProjectID     int64              `form:"project_id"`
+	Tagging       int64              `form:"tagging"`
 	Slug          string             `form:"slug"`
 	IDs           []uint32           `form:"ids"`
 	Active        map[string][]int   `form:"active"`
 	PublishStatus map[string][]int   `form:"publish_status"`
 	Author        map[string][]int64 `form:"author"`
+	Credited      int64              `form:"credited"`
 	Type          map[string][]int   `form:"type"`
+	ReadingTime   map[string]int     `form:"reading_time"`
+	WordCount     map[string]int     `form:"word_count"`
+	Lang          string             `form:"lang"`
+	Language      string             `form:"language"`
 	Total         bool               `form:"total"`
 	Filter        Filter
 }
@@ -250,7 +299,21 @@ func (p *PostArgs) DefaultActive() {
 }
 
 func (p *PostArgs) anyFilter() (result bool) {
-	return p.Active != nil || p.PublishStatus != nil || p.Author != nil || p.Type != nil
+	return p.Active != nil || p.PublishStatus != nil || p.Author != nil || p.Credited != 0 || p.Type != nil || p.Tagging != 0 ||
+		p.ReadingTime != nil || p.WordCount != nil || (p.Language != "" && !IsSourceLanguage(p.Language))
+}
+
+// rangeOperators maps range operators in query to SQL
+var rangeOperators = map[string]string{"$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}
+
+// ValidateRange checks that every operator in ranges is supported
+func ValidateRange(ranges map[string]int) error {
+	for k := range ranges {
+		if _, ok := rangeOperators[k]; !ok {
+			return fmt.Errorf("Invalid range operator %s", k)
+		}
+	}
+	return nil
 }
 
 func (a *PostArgs) ParseQuery() (query string, values []interface{}) {
@@ -326,12 +389,28 @@ func (p *PostArgs) parseRestricts() (restricts string, values []interface{}) {
 			values = append(values, v)
 		}
 	}
+	if p.Credited != 0 {
+		// Posts credited to member in authors, including co-authored ones
+		where = append(where, "posts.post_id IN (SELECT resource_id FROM authors WHERE author_id = ? AND resource_type = posts.type)")
+		values = append(values, p.Credited)
+	}
 	if p.Type != nil {
 		for k, v := range p.Type {
 			where = append(where, fmt.Sprintf("%s %s (?)", "posts.type", rrsql.OperatorHelper(k)))
 			values = append(values, v)
 		}
 	}
+	for _, r := range []struct {
+		field  string
+		ranges map[string]int
+	}{{"posts.reading_time", p.ReadingTime}, {"posts.word_count", p.WordCount}} {
+		for _, k := range []string{"$gt", "$gte", "$lt", "$lte"} {
+			if v, ok := r.ranges[k]; ok {
+				where = append(where, fmt.Sprintf("%s %s ?", r.field, rangeOperators[k]))
+				values = append(values, v)
+			}
+		}
+	}
 	if p.Slug != "" {
 		where = append(where, fmt.Sprintf("%s = ?", "posts.slug"))
 		values = append(values, p.Slug)
@@ -344,6 +423,16 @@ func (p *PostArgs) parseRestricts() (restricts string, values []interface{}) {
 		where = append(where, fmt.Sprintf("%s %s (?)", "posts.post_id", "IN"))
 		values = append(values, p.IDs)
 	}
+	if p.Tagging != 0 {
+		// Posts tagged with descendants of tag are included
+		where = append(where, "posts.post_id IN (SELECT target_id FROM tagging WHERE type = ? AND tag_id IN ("+tagDescendants+"))")
+		values = append(values, config.Config.Models.TaggingType["post"], p.Tagging)
+	}
+	// Every post is available in the source language
+	if p.Language != "" && !IsSourceLanguage(p.Language) {
+		where = append(where, "posts.post_id IN (SELECT post_id FROM post_translations WHERE language = ?)")
+		values = append(values, p.Language)
+	}
 	if p.Filter != (Filter{}) {
 		where = append(where, fmt.Sprintf("posts.%s %s ?", p.Filter.Field, p.Filter.Operator))
 		values = append(values, p.Filter.Condition)
@@ -409,7 +498,7 @@ func (p *FilterPostArgs) parse(doCount bool) (query string, values []interface{}
 		LEFT JOIN tagging AS tagging ON tagging.target_id = posts.post_id AND tagging.type = %d LEFT JOIN tags AS tags ON tags.tag_id = tagging.tag_id
 		`, config.Config.Models.TaggingType["post"]))
 	}
-	if len(p.Author) > 0 {
+	if len(p.Author) > 0 || len(p.AuthorType) > 0 {
 		joinedTables = append(joinedTables, `LEFT JOIN authors AS authors ON authors.resource_id = posts.post_id LEFT JOIN members AS members ON authors.author_id = members.id `)
 	}
 
@@ -464,6 +553,14 @@ func (p *FilterPostArgs) parseRestricts() (restrictString string, values []inter
 		}
 		restricts = append(restricts, fmt.Sprintf("%s%s%s", "(", strings.Join(subRestricts, " OR "), ")"))
 	}
+	if len(p.AuthorType) != 0 {
+		subRestricts := make([]string, 0)
+		for _, v := range p.AuthorType {
+			subRestricts = append(subRestricts, `authors.author_type = ?`)
+			values = append(values, v)
+		}
+		restricts = append(restricts, fmt.Sprintf("(%s)", strings.Join(subRestricts, " OR ")))
+	}
 	if len(p.Tag) != 0 {
 		subRestricts := make([]string, 0)
 		for _, v := range p.Tag {
@@ -547,6 +644,7 @@ func (a *postAPI) GetPosts(req *PostArgs) (result []TaggedPostMember, err error)
 			result = []TaggedPostMember{}
 			return result, err
 		}
+		singlePost.Embed = ParseEmbed(singlePost.Link.String)
 		result = append(result, singlePost)
 	}
 
@@ -587,10 +685,40 @@ func (a *postAPI) GetPosts(req *PostArgs) (result []TaggedPostMember, err error)
 			}
 		}
 	}
+	if req.Lang != "" {
+		if err := a.localize(result, req.Lang); err != nil {
+			log.Println("Error localizing posts:", err.Error())
+		}
+	}
 
 	return result, err
 }
 
+// localize replaces content of posts with translations in lang,
+// and falls back to the source language for posts not translated
+func (a *postAPI) localize(posts []TaggedPostMember, lang string) error {
+
+	ids := make([]uint32, 0, len(posts))
+	for i := range posts {
+		posts[i].Language = config.Config.Translation.SourceLanguage
+		ids = append(ids, posts[i].ID)
+	}
+	if IsSourceLanguage(lang) || len(posts) == 0 {
+		return nil
+	}
+
+	translations, err := PostTranslationAPI.Get(ids, lang)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if t, ok := translations[posts[i].ID]; ok {
+			t.Localize(&posts[i])
+		}
+	}
+	return nil
+}
+
 func (a *postAPI) GetPost(id uint32, req *PostArgs) (post TaggedPostMember, err error) {
 
 	req.IDs = []uint32{id}
@@ -616,6 +744,7 @@ func (a *postAPI) GetPost(id uint32, req *PostArgs) (post TaggedPostMember, err
 			err = nil
 		}
 	}
+	post.Embed = ParseEmbed(post.Link.String)
 
 	comments, err := a.fetchPostComments([]int{int(post.Post.ID)})
 	if err == nil {
@@ -637,6 +766,13 @@ func (a *postAPI) GetPost(id uint32, req *PostArgs) (post TaggedPostMember, err
 			post.Cards = cards[int(post.Post.ID)]
 		}
 	}
+	if req.Lang != "" {
+		posts := []TaggedPostMember{post}
+		if err := a.localize(posts, req.Lang); err != nil {
+			log.Println("Error localizing post:", err.Error())
+		}
+		post = posts[0]
+	}
 
 	return post, err
 }
@@ -763,10 +899,11 @@ func (a *postAPI) fetchPostTags(ids []int) (tags map[int][]TagBasic, err error)
 }
 
 func (a *postAPI) fetchPostAuthors(ids []int) (authors map[int][]AuthorBasic, err error) {
-	query := `SELECT members.id "id",members.uuid "uuid",members.nickname "nickname",members.profile_image "profile_image",members.description "description",members.role "role",authors.author_type "author_type",authors.resource_id "resource_id" FROM posts
+	query := `SELECT members.id "id",members.uuid "uuid",members.nickname "nickname",members.profile_image "profile_image",members.description "description",members.role "role",authors.author_type "author_type",authors.position "position",authors.resource_id "resource_id" FROM posts
 		LEFT JOIN authors ON posts.post_id = authors.resource_id
 		LEFT JOIN members ON authors.author_id = members.id
-		WHERE posts.post_id IN (?);`
+		WHERE posts.post_id IN (?)
+		ORDER BY authors.author_type, authors.position;`
 
 	authors = make(map[int][]AuthorBasic, 0)
 
@@ -867,10 +1004,10 @@ func (a *postAPI) insertPostStms() string {
 	return query
 }
 
-func (a *postAPI) InsertPost(p PostDescription) (lastID int, err error) {
-
-	stmts := []*rrsql.PipelineStmt{}
+// insertPostPipeline builds statements inserting post with its authors, tags and cards
+func (a *postAPI) insertPostPipeline(p PostDescription) (stmts []*rrsql.PipelineStmt, err error) {
 
+	p.Post.Version = rrsql.NullInt{Int: 1, Valid: true}
 	stmts = append(stmts, &rrsql.PipelineStmt{
 		Query:        a.insertPostStms(),
 		Args:         []interface{}{},
@@ -891,14 +1028,27 @@ func (a *postAPI) InsertPost(p PostDescription) (lastID int, err error) {
 	cardSyncStmts, err := cards.BuildSyncStmts(p.Post.ID, p.NewsCards)
 	if err != nil {
 		log.Println(fmt.Sprintf("Update Post Error while building card sync sql query: %s", err.Error()))
-		return 0, err
+		return nil, err
 	}
 	stmts = append(stmts, cardSyncStmts...)
 
+	return stmts, nil
+}
+
+func (a *postAPI) InsertPost(p PostDescription) (lastID int, err error) {
+
+	stmts, err := a.insertPostPipeline(p)
+	if err != nil {
+		return 0, err
+	}
+
 	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
 		id, _, err := rrsql.RunPipeline(tx, stmts...)
+		if err != nil {
+			return err
+		}
 		lastID = int(id)
-		return err
+		return a.updateStats(tx, uint32(id))
 	})
 
 	return lastID, err
@@ -906,23 +1056,27 @@ func (a *postAPI) InsertPost(p PostDescription) (lastID int, err error) {
 
 func (a *postAPI) updatePostStms(p PostDescription) string {
 
+	version := p.Post.Version
+	p.Post.Version = rrsql.NullInt{}
+
 	tags := rrsql.GetStructDBTags("partial", p.Post)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, tags)
 	query := fmt.Sprintf(`UPDATE posts SET %s WHERE post_id = :post_id`,
 		strings.Join(fields, ", "))
 
-	return query
+	return rrsql.Versioned(query, version)
 }
 
-func (a *postAPI) UpdatePost(p PostDescription) (err error) {
-
-	stmts := []*rrsql.PipelineStmt{}
+// updatePostPipeline builds statements updating post with its authors, tags and cards
+func (a *postAPI) updatePostPipeline(p PostDescription) (stmts []*rrsql.PipelineStmt, err error) {
 
 	stmts = append(stmts, &rrsql.PipelineStmt{
 		Query:     a.updatePostStms(p),
 		Args:      []interface{}{},
 		NamedArgs: p.Post,
 		NamedExec: true,
+		// Nothing updated means post was saved by someone else in the meantime
+		RowsAffected: p.Post.Version.Valid,
 	})
 
 	if len(p.Authors) > 0 {
@@ -936,13 +1090,28 @@ func (a *postAPI) UpdatePost(p PostDescription) (err error) {
 	cardSyncStmts, err := cards.BuildSyncStmts(p.Post.ID, p.NewsCards)
 	if err != nil {
 		log.Println(fmt.Sprintf("Update Post Error while building card sync sql query: %s", err.Error()))
-		return err
+		return nil, err
 	}
 	stmts = append(stmts, cardSyncStmts...)
 
-	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
-		_, _, err := rrsql.RunPipeline(tx, stmts...)
+	return stmts, nil
+}
+
+func (a *postAPI) UpdatePost(p PostDescription) (err error) {
+
+	stmts, err := a.updatePostPipeline(p)
+	if err != nil {
 		return err
+	}
+
+	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		if _, _, err := rrsql.RunPipeline(tx, stmts...); err != nil {
+			if err == rrsql.ItemNotFoundError {
+				return rrsql.VersionConflictError
+			}
+			return err
+		}
+		return a.updateStats(tx, p.ID)
 	})
 
 	return err
@@ -962,6 +1131,7 @@ func (a *postAPI) DeletePost(id uint32) error {
 		return errors.New("Post Not Found")
 	}
 
+	recordTrash("post", []int{int(id)})
 	go PostCache.Delete(id)
 	go SearchFeed.DeletePost([]int{int(id)})
 
@@ -991,6 +1161,9 @@ func (a *postAPI) UpdateAll(req PostUpdateArgs) error {
 		return errors.New("Posts Not Found")
 	}
 
+	if req.Active.Valid && req.Active.Int == int64(config.Config.Models.Posts["deactive"]) {
+		recordTrash("post", req.IDs)
+	}
 	return nil
 }
 
@@ -1015,17 +1188,6 @@ func (a *postAPI) Count(req args.ArgsParser) (result int, err error) {
 	return result, err
 }
 
-/*
-func (a *postAPI) Hot() (result []HotPost, err error) {
-	result, err = RedisHelper.GetHotPosts("postcache_hot_%d", 20)
-	if err != nil {
-		log.Printf("Error getting popular list: %v", err)
-		return result, err
-	}
-	return result, err
-}
-*/
-
 func (a *postAPI) updateAuthorsStms(post Post, authors []AuthorInput) (stmts []*rrsql.PipelineStmt) {
 
 	// If post has no id, then give a placeholder for sql trasaction
@@ -1039,10 +1201,10 @@ func (a *postAPI) updateAuthorsStms(post Post, authors []AuthorInput) (stmts []*
 		authorCodition = append(authorCodition, fmt.Sprintf(`AND NOT (author_id = %d and author_type = %d)`, v.MemberID.Int, v.Type.Int))
 	}
 
-	// Add / update auhtors
+	// Add / update auhtors, keeping credits in order
 	authorInsertions := make([]string, 0)
-	for _, v := range authors {
-		authorInsertions = append(authorInsertions, fmt.Sprintf(`(%s, %d, %d ,%d)`, postIDString, v.MemberID.Int, post.Type.Int, v.Type.Int))
+	for _, v := range positionAuthors(authors) {
+		authorInsertions = append(authorInsertions, fmt.Sprintf(`(%s, %d, %d ,%d, %d)`, postIDString, v.MemberID.Int, post.Type.Int, v.Type.Int, v.Position.Int))
 	}
 
 	stmts = append(stmts,
@@ -1050,7 +1212,7 @@ func (a *postAPI) updateAuthorsStms(post Post, authors []AuthorInput) (stmts []*
 			Query: fmt.Sprintf(`DELETE FROM authors WHERE resource_id = ? AND resource_type = ? %s ;`, strings.Join(authorCodition, " ")),
 			Args:  []interface{}{postIDString, post.Type.Int}},
 		&rrsql.PipelineStmt{
-			Query: fmt.Sprintf(`INSERT IGNORE authors (resource_id, author_id, resource_type, author_type) VALUES %s;`, strings.Join(authorInsertions, ",")),
+			Query: fmt.Sprintf(`INSERT INTO authors (resource_id, author_id, resource_type, author_type, position) VALUES %s ON DUPLICATE KEY UPDATE position = VALUES(position);`, strings.Join(authorInsertions, ",")),
 		})
 	return stmts
 }
@@ -155,6 +155,27 @@ func (p *postCache) UpdateAll(params PostUpdateArgs) {
 	}
 }
 
+// Invalidate syncs caches holding any of ids, each of them once
+func (p *postCache) Invalidate(ids []uint32) {
+
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+
+	for _, cache := range p.caches {
+		index, err := redis.StringMap(conn.Do("HGETALL", fmt.Sprintf("%s_index", cache.Key())))
+		if err != nil {
+			log.Printf("Error get post cache index: %v", err)
+			continue
+		}
+		for _, id := range ids {
+			if _, ok := index[strconv.Itoa(int(id))]; ok {
+				cache.SyncFromDataStorage()
+				break
+			}
+		}
+	}
+}
+
 /*
 func (p *postCache) UpdateFollowing(action string, user_id int64, post_id int64) {
 	conn := RedisHelper.WriteConn()
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/pkg/cards"
+	"github.com/readr-media/readr-restful/pkg/sanitizer"
+)
+
+// PostImportBatchSize is the number of records imported in one transaction
+const PostImportBatchSize = 100
+
+var errPostImportDryRun = errors.New("Dry Run")
+
+// PostImportResult reports what happened to a single imported record
+type PostImportResult struct {
+	Line   int    `json:"line"`
+	ID     uint32 `json:"id,omitempty"`
+	Slug   string `json:"slug,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+	// Stripped lists content of record not allowed by the sanitizer, which is not imported
+	Stripped []sanitizer.Stripped `json:"stripped,omitempty"`
+}
+
+// NewPostDescription converts post fetched with ShowAuthor, ShowTag and ShowCard
+// into PostDescription, which could be inserted or updated directly
+func NewPostDescription(post TaggedPostMember) PostDescription {
+
+	description := PostDescription{Post: post.Post}
+	if post.Tags != nil {
+		description.Tags = rrsql.NullIntSlice{Slice: make([]int, 0, len(post.Tags)), Valid: true}
+		for _, tag := range post.Tags {
+			description.Tags.Slice = append(description.Tags.Slice, tag.ID)
+		}
+	}
+	for _, author := range post.Authors {
+		description.Authors = append(description.Authors, AuthorInput{
+			Type:     author.Type,
+			MemberID: rrsql.NullInt{Int: author.ID, Valid: true},
+			Position: author.Position,
+		})
+	}
+	for _, card := range post.Cards {
+		description.NewsCards = append(description.NewsCards, cards.NewsCard{
+			ID:              card.ID,
+			PostID:          card.PostID,
+			Title:           card.Title,
+			Description:     card.Description,
+			BackgroundImage: card.BackgroundImage,
+			BackgroundColor: card.BackgroundColor,
+			Image:           card.Image,
+			Video:           card.Video,
+			Order:           card.Order,
+		})
+	}
+	return description
+}
+
+// matchPost finds the existing post id of record, by slug first and then by id
+func (a *postAPI) matchPost(tx *sqlx.Tx, record PostDescription) (id uint32, err error) {
+
+	if record.Slug.Valid && record.Slug.String != "" {
+		err = tx.Get(&id, `SELECT post_id FROM posts WHERE slug = ? LIMIT 1`, record.Slug.String)
+	} else if record.ID != 0 {
+		err = tx.Get(&id, `SELECT post_id FROM posts WHERE post_id = ?`, record.ID)
+	} else {
+		return 0, nil
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// importPost upserts a single record within tx, and returns the resulting id and action
+func (a *postAPI) importPost(tx *sqlx.Tx, record PostDescription) (id uint32, action string, err error) {
+
+	matched, err := a.matchPost(tx, record)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// Cards are always replaced, because card ids are not portable between databases
+	for i := range record.NewsCards {
+		record.NewsCards[i].ID = 0
+		record.NewsCards[i].PostID = matched
+	}
+	// Versions are local to each database as well, so import never conflicts with edits
+	record.Version = rrsql.NullInt{}
+	if !record.UpdatedAt.Valid {
+		record.UpdatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	if matched != 0 {
+		record.ID = matched
+		stmts, err := a.updatePostPipeline(record)
+		if err != nil {
+			return 0, "", err
+		}
+		if _, _, err = rrsql.RunPipeline(tx, stmts...); err != nil {
+			return 0, "", err
+		}
+		if err = a.updateStats(tx, matched); err != nil {
+			return 0, "", err
+		}
+		return matched, "update", nil
+	}
+
+	record.ID = 0
+	if !record.CreatedAt.Valid {
+		record.CreatedAt = record.UpdatedAt
+	}
+	stmts, err := a.insertPostPipeline(record)
+	if err != nil {
+		return 0, "", err
+	}
+	lastID, _, err := rrsql.RunPipeline(tx, stmts...)
+	if err != nil {
+		return 0, "", err
+	}
+	if err = a.updateStats(tx, uint32(lastID)); err != nil {
+		return 0, "", err
+	}
+	return uint32(lastID), "insert", nil
+}
+
+// ImportPosts upserts records by slug or id in batches of PostImportBatchSize.
+// Each record runs within its own savepoint, so a failed record does not roll back the rest of batch.
+// With dryRun, every batch is rolled back after all records are tried.
+func (a *postAPI) ImportPosts(records []PostDescription, dryRun bool) (results []PostImportResult, err error) {
+
+	results = make([]PostImportResult, len(records))
+	for start := 0; start < len(records); start += PostImportBatchSize {
+		end := start + PostImportBatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+			for i := start; i < end; i++ {
+				results[i] = PostImportResult{ID: records[i].ID, Slug: records[i].Slug.String}
+
+				savepoint := fmt.Sprintf("post_import_%d", i)
+				if _, err := tx.Exec(fmt.Sprintf("SAVEPOINT %s", savepoint)); err != nil {
+					return err
+				}
+				id, action, err := a.importPost(tx, records[i])
+				if err != nil {
+					if _, rollbackErr := tx.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", savepoint)); rollbackErr != nil {
+						return rollbackErr
+					}
+					results[i].Action = "error"
+					results[i].Error = err.Error()
+					continue
+				}
+				results[i].ID, results[i].Action = id, action
+			}
+			if dryRun {
+				return errPostImportDryRun
+			}
+			return nil
+		})
+		if err != nil && err != errPostImportDryRun {
+			return results, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostArgsCredited(t *testing.T) {
+
+	args := NewPostArgs(func(args *PostArgs) {
+		args.ProjectID = -1
+		args.Credited = 12
+	})
+	assert.True(t, args.anyFilter())
+
+	restricts, values := args.parseRestricts()
+	assert.Equal(t, "WHERE posts.post_id IN (SELECT resource_id FROM authors WHERE author_id = ? AND resource_type = posts.type)", restricts)
+	assert.Equal(t, []interface{}{int64(12)}, values)
+}
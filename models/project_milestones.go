@@ -0,0 +1,209 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Progress of projects is typed in by editors by default.
+// It could instead be computed from weights of milestones done, or memos published against planned_memos.
+const (
+	ProgressManual     = "manual"
+	ProgressMilestones = "milestones"
+	ProgressMemos      = "memos"
+)
+
+func ValidateProgressSource(source string) error {
+	switch source {
+	case ProgressManual, ProgressMilestones, ProgressMemos:
+		return nil
+	}
+	return errors.New("Invalid Progress Source")
+}
+
+// Milestone is a step of project planned at target_at, which is reached when done_at is set
+type Milestone struct {
+	ID          int              `json:"id" db:"id"`
+	ProjectID   int              `json:"project_id" db:"project_id"`
+	Title       rrsql.NullString `json:"title" db:"title"`
+	Description rrsql.NullString `json:"description" db:"description"`
+	Weight      rrsql.NullInt    `json:"weight" db:"weight"`
+	TargetAt    rrsql.NullTime   `json:"target_at" db:"target_at"`
+	DoneAt      rrsql.NullTime   `json:"done_at" db:"done_at"`
+	CreatedAt   rrsql.NullTime   `json:"created_at" db:"created_at"`
+	UpdatedAt   rrsql.NullTime   `json:"updated_at" db:"updated_at"`
+	UpdatedBy   rrsql.NullInt    `json:"updated_by" db:"updated_by"`
+}
+
+// ProjectMilestone is a milestone reached with its project, for notifications and mails
+type ProjectMilestone struct {
+	Milestone
+	Project Project `json:"project"`
+}
+
+type MilestoneInterface interface {
+	GetMilestone(id int) (Milestone, error)
+	GetMilestones(projectID int) ([]Milestone, error)
+	InsertMilestone(m Milestone) (int, error)
+	// UpdateMilestone tells if milestone is reached by the update, in which case followers are notified
+	UpdateMilestone(m Milestone) (reached bool, err error)
+	DeleteMilestone(id int) error
+	UpdateProgress(projectID int) error
+	// UpdateMemosProgress updates progress of projects of memos published, unpublished or deleted
+	UpdateMemosProgress(memoIDs []int) error
+}
+
+type milestoneAPI struct{}
+
+func (a *milestoneAPI) GetMilestone(id int) (milestone Milestone, err error) {
+
+	err = rrsql.DB.Get(&milestone, `SELECT * FROM project_milestones WHERE id = ?;`, id)
+	if err == sql.ErrNoRows {
+		err = rrsql.ItemNotFoundError
+	}
+	return milestone, err
+}
+
+func (a *milestoneAPI) GetMilestones(projectID int) (milestones []Milestone, err error) {
+
+	// Projects are deleted by deactivating, so milestones of them are left out here
+	milestones = make([]Milestone, 0)
+	err = rrsql.DB.Select(&milestones, `SELECT m.* FROM project_milestones AS m INNER JOIN projects AS p ON p.project_id = m.project_id
+		WHERE m.project_id = ? AND p.active = ? ORDER BY m.target_at IS NULL, m.target_at, m.id;`, projectID, config.Config.Models.ProjectsActive["active"])
+	return milestones, err
+}
+
+func (a *milestoneAPI) InsertMilestone(m Milestone) (id int, err error) {
+
+	m.ID = 0
+	if !m.Weight.Valid {
+		m.Weight = rrsql.NullInt{Int: 1, Valid: true}
+	}
+	query, _ := rrsql.GenerateSQLStmt("insert", "project_milestones", m)
+	result, err := rrsql.DB.NamedExec(query, m)
+	if err != nil {
+		// Foreign key of project fails
+		if strings.Contains(err.Error(), "foreign key constraint") {
+			return 0, errors.New("Project Not Found")
+		}
+		return 0, err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(lastID), a.UpdateProgress(m.ProjectID)
+}
+
+func (a *milestoneAPI) UpdateMilestone(m Milestone) (reached bool, err error) {
+
+	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		var current Milestone
+		if err := tx.Get(&current, `SELECT * FROM project_milestones WHERE id = ? FOR UPDATE;`, m.ID); err == sql.ErrNoRows {
+			return rrsql.ItemNotFoundError
+		} else if err != nil {
+			return err
+		}
+		// Project of milestone could not be changed
+		m.ProjectID = current.ProjectID
+		query, _ := rrsql.GenerateSQLStmt("partial_update", "project_milestones", m)
+		if _, err := tx.NamedExec(query, m); err != nil {
+			return err
+		}
+		reached = !current.DoneAt.Valid && m.DoneAt.Valid
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return reached, a.UpdateProgress(m.ProjectID)
+}
+
+func (a *milestoneAPI) DeleteMilestone(id int) (err error) {
+
+	var projectID int
+	if err = rrsql.DB.Get(&projectID, `SELECT project_id FROM project_milestones WHERE id = ?;`, id); err == sql.ErrNoRows {
+		return rrsql.ItemNotFoundError
+	} else if err != nil {
+		return err
+	}
+	if _, err = rrsql.DB.Exec(`DELETE FROM project_milestones WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return a.UpdateProgress(projectID)
+}
+
+// UpdateProgress computes progress of project if it's not typed in by editors.
+// Progress is updated without bumping version, as SchedulePublish does.
+func (a *milestoneAPI) UpdateProgress(projectID int) (err error) {
+
+	var project Project
+	if err = rrsql.DB.Get(&project, `SELECT * FROM projects WHERE project_id = ?;`, projectID); err == sql.ErrNoRows {
+		return errors.New("Project Not Found")
+	} else if err != nil {
+		return err
+	}
+
+	var done, total int
+	switch project.ProgressSource.String {
+	case ProgressMilestones:
+		if err = rrsql.DB.QueryRow(`SELECT IFNULL(SUM(IF(done_at IS NOT NULL, weight, 0)), 0), IFNULL(SUM(weight), 0)
+			FROM project_milestones WHERE project_id = ?;`, projectID).Scan(&done, &total); err != nil {
+			return err
+		}
+	case ProgressMemos:
+		if err = rrsql.DB.Get(&done, fmt.Sprintf(`SELECT COUNT(*) FROM posts WHERE project_id = ? AND type = %d AND active = %d AND publish_status = %d;`,
+			config.Config.Models.PostType["memo"], config.Config.Models.Memos["active"], config.Config.Models.MemosPublishStatus["publish"]), projectID); err != nil {
+			return err
+		}
+		total = int(project.PlannedMemos.Int)
+	default:
+		return nil
+	}
+
+	_, err = rrsql.DB.Exec(`UPDATE projects SET progress = ? WHERE project_id = ?;`, computeProgress(done, total), projectID)
+	return err
+}
+
+func (a *milestoneAPI) UpdateMemosProgress(memoIDs []int) (err error) {
+
+	if len(memoIDs) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(`SELECT DISTINCT project_id FROM posts WHERE post_id IN (?) AND type = ? AND project_id > 0;`,
+		memoIDs, config.Config.Models.PostType["memo"])
+	if err != nil {
+		return err
+	}
+	var projectIDs []int
+	if err = rrsql.DB.Select(&projectIDs, rrsql.DB.Rebind(query), args...); err != nil {
+		return err
+	}
+	for _, id := range projectIDs {
+		if err = a.UpdateProgress(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// computeProgress returns percentage of done in total, rounded to 2 decimal places
+func computeProgress(done, total int) float64 {
+
+	if total <= 0 || done <= 0 {
+		return 0
+	}
+	if done >= total {
+		return 100
+	}
+	return math.Round(float64(done)*10000/float64(total)) / 100
+}
+
+var MilestoneAPI MilestoneInterface = new(milestoneAPI)
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeProgress(t *testing.T) {
+
+	for _, tc := range []struct {
+		done, total int
+		expected    float64
+	}{
+		{0, 0, 0},
+		{3, 0, 0},
+		{1, 3, 33.33},
+		{2, 3, 66.67},
+		{5, 4, 100},
+	} {
+		assert.Equal(t, tc.expected, computeProgress(tc.done, tc.total))
+	}
+}
+
+func TestValidateProgressSource(t *testing.T) {
+
+	for _, source := range []string{ProgressManual, ProgressMilestones, ProgressMemos} {
+		assert.Nil(t, ValidateProgressSource(source))
+	}
+	assert.EqualError(t, ValidateProgressSource("posts"), "Invalid Progress Source")
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// validateOrder checks ordered ids against members of the ordered list.
+// Ids should cover every member exactly once, and notMember is returned for those not in members.
+func validateOrder(ids []int, members []int, notMember string) error {
+
+	memberSet := make(map[int]bool, len(members))
+	for _, id := range members {
+		memberSet[id] = true
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return errors.New("Duplicate ID")
+		}
+		seen[id] = true
+		if !memberSet[id] {
+			return errors.New(notMember)
+		}
+	}
+	if len(ids) != len(members) {
+		return errors.New("Incomplete Order")
+	}
+	return nil
+}
+
+// orderStmt rewrites order of ids in a single statement.
+// Lists are sorted by order descending, so the first id gets the largest order.
+func orderStmt(table, idField, orderField string, ids []int) *rrsql.PipelineStmt {
+
+	cases := ""
+	args := make([]interface{}, 0, len(ids)*2)
+	for i, id := range ids {
+		cases = fmt.Sprintf("%s WHEN ? THEN ?", cases)
+		args = append(args, id, len(ids)-i)
+	}
+	// Placeholders of cases are expanded by sqlx.In along with ids
+	query, inArgs, _ := sqlx.In(fmt.Sprintf(`UPDATE %s SET %s = CASE %s%s END WHERE %s IN (?);`, table, orderField, idField, cases, idField), append(args, ids)...)
+	return &rrsql.PipelineStmt{Query: query, Args: inArgs}
+}
+
+// ReorderPosts rewrites post_order of all posts in project id following postIDs.
+// Post cache holding any of them is synced once afterwards.
+func (a *projectAPI) ReorderPosts(id int, postIDs []int) error {
+
+	if len(postIDs) == 0 {
+		return errors.New("Incomplete Order")
+	}
+
+	err := rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		var exist int
+		if err := tx.Get(&exist, `SELECT COUNT(*) FROM projects WHERE project_id = ?`, id); err != nil {
+			return err
+		} else if exist == 0 {
+			return errors.New("Project Not Found")
+		}
+
+		// Lock posts of the project, so membership could not change before orders are written
+		var members []int
+		if err := tx.Select(&members, `SELECT post_id FROM posts WHERE project_id = ? AND active != ? FOR UPDATE;`,
+			id, config.Config.Models.Posts["deactive"]); err != nil {
+			return err
+		}
+		if err := validateOrder(postIDs, members, "Post Not In Project"); err != nil {
+			return err
+		}
+
+		_, _, err := rrsql.RunPipeline(tx, orderStmt("posts", "post_id", "post_order", postIDs))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint32, 0, len(postIDs))
+	for _, id := range postIDs {
+		ids = append(ids, uint32(id))
+	}
+	go PostCache.Invalidate(ids)
+	return nil
+}
+
+// Reorder rewrites project_order of all projects on the homepage following ids.
+// Only active and published projects are listed there, so drafts are left out of the order.
+func (a *projectAPI) Reorder(ids []int) error {
+
+	if len(ids) == 0 {
+		return errors.New("Incomplete Order")
+	}
+
+	// Posts in cache carry no project order, so there is nothing in post cache to invalidate
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		var members []int
+		if err := tx.Select(&members, `SELECT project_id FROM projects WHERE active = ? AND publish_status = ? FOR UPDATE;`,
+			config.Config.Models.ProjectsActive["active"], config.Config.Models.ProjectsPublishStatus["publish"]); err != nil {
+			return err
+		}
+		if err := validateOrder(ids, members, "Project Not Found"); err != nil {
+			return err
+		}
+
+		_, _, err := rrsql.RunPipeline(tx, orderStmt("projects", "project_id", "project_order", ids))
+		return err
+	})
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOrder(t *testing.T) {
+
+	members := []int{3, 1, 2}
+	for _, tc := range []struct {
+		name string
+		ids  []int
+		err  string
+	}{
+		{"Complete", []int{1, 2, 3}, ""},
+		{"Duplicate", []int{1, 2, 1}, "Duplicate ID"},
+		{"NotMember", []int{1, 2, 4}, "Post Not In Project"},
+		{"Incomplete", []int{2, 1}, "Incomplete Order"},
+		{"Empty", []int{}, "Incomplete Order"},
+	} {
+		err := validateOrder(tc.ids, members, "Post Not In Project")
+		if tc.err == "" {
+			assert.Nil(t, err, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.err, tc.name)
+		}
+	}
+}
+
+func TestOrderStmt(t *testing.T) {
+
+	stmt := orderStmt("projects", "project_id", "project_order", []int{7, 5, 9})
+	assert.Equal(t, 3, strings.Count(stmt.Query, "WHEN ? THEN ?"))
+	assert.True(t, strings.HasSuffix(stmt.Query, "WHERE project_id IN (?, ?, ?);"))
+	// The first id gets the largest order
+	assert.Equal(t, []interface{}{7, 3, 5, 2, 9, 1, 7, 5, 9}, stmt.Args)
+}
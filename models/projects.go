@@ -6,6 +6,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"database/sql"
 
@@ -17,29 +18,33 @@ import (
 )
 
 type Project struct {
-	ID            int              `json:"id" db:"project_id"`
-	CreatedAt     rrsql.NullTime   `json:"created_at" db:"created_at"`
-	UpdatedAt     rrsql.NullTime   `json:"updated_at" db:"updated_at"`
-	UpdatedBy     rrsql.NullInt    `json:"updated_by" db:"updated_by"`
-	PublishedAt   rrsql.NullTime   `json:"published_at" db:"published_at"`
-	PostID        int              `json:"post_id" db:"post_id"`
-	LikeAmount    rrsql.NullInt    `json:"like_amount" db:"like_amount"`
-	CommentAmount rrsql.NullInt    `json:"comment_amount" db:"comment_amount"`
-	Active        rrsql.NullInt    `json:"active" db:"active"`
-	HeroImage     rrsql.NullString `json:"hero_image" db:"hero_image"`
-	Title         rrsql.NullString `json:"title" db:"title"`
-	Description   rrsql.NullString `json:"description" db:"description"`
-	Author        rrsql.NullString `json:"author" db:"author"`
-	OgTitle       rrsql.NullString `json:"og_title" db:"og_title"`
-	OgDescription rrsql.NullString `json:"og_description" db:"og_description"`
-	OgImage       rrsql.NullString `json:"og_image" db:"og_image"`
-	Order         rrsql.NullInt    `json:"project_order" db:"project_order" redis:"project_order"`
-	Status        rrsql.NullInt    `json:"status" db:"status" redis:"status"`
-	Slug          rrsql.NullString `json:"slug" db:"slug" redis:"slug"`
-	Views         rrsql.NullInt    `json:"views" db:"views" redis:"views"`
-	PublishStatus rrsql.NullInt    `json:"publish_status" db:"publish_status" redis:"publish_status"`
-	Progress      rrsql.NullFloat  `json:"progress" db:"progress" redis:"progress"`
-	MemoPoints    rrsql.NullInt    `json:"memo_points" db:"memo_points" redis:"memo_points"`
+	ID             int              `json:"id" db:"project_id"`
+	CreatedAt      rrsql.NullTime   `json:"created_at" db:"created_at"`
+	UpdatedAt      rrsql.NullTime   `json:"updated_at" db:"updated_at"`
+	UpdatedBy      rrsql.NullInt    `json:"updated_by" db:"updated_by"`
+	PublishedAt    rrsql.NullTime   `json:"published_at" db:"published_at"`
+	PostID         int              `json:"post_id" db:"post_id"`
+	LikeAmount     rrsql.NullInt    `json:"like_amount" db:"like_amount"`
+	CommentAmount  rrsql.NullInt    `json:"comment_amount" db:"comment_amount"`
+	Active         rrsql.NullInt    `json:"active" db:"active"`
+	HeroImage      rrsql.NullString `json:"hero_image" db:"hero_image"`
+	Title          rrsql.NullString `json:"title" db:"title"`
+	Description    rrsql.NullString `json:"description" db:"description"`
+	Author         rrsql.NullString `json:"author" db:"author"`
+	OgTitle        rrsql.NullString `json:"og_title" db:"og_title"`
+	OgDescription  rrsql.NullString `json:"og_description" db:"og_description"`
+	OgImage        rrsql.NullString `json:"og_image" db:"og_image"`
+	Order          rrsql.NullInt    `json:"project_order" db:"project_order" redis:"project_order"`
+	Status         rrsql.NullInt    `json:"status" db:"status" redis:"status"`
+	Slug           rrsql.NullString `json:"slug" db:"slug" redis:"slug"`
+	Views          rrsql.NullInt    `json:"views" db:"views" redis:"views"`
+	UniqueViews    rrsql.NullInt    `json:"unique_views" db:"unique_views" redis:"unique_views"`
+	PublishStatus  rrsql.NullInt    `json:"publish_status" db:"publish_status" redis:"publish_status"`
+	Progress       rrsql.NullFloat  `json:"progress" db:"progress" redis:"progress"`
+	ProgressSource rrsql.NullString `json:"progress_source" db:"progress_source" redis:"progress_source"`
+	PlannedMemos   rrsql.NullInt    `json:"planned_memos" db:"planned_memos" redis:"planned_memos"`
+	MemoPoints     rrsql.NullInt    `json:"memo_points" db:"memo_points" redis:"memo_points"`
+	Version        rrsql.NullInt    `json:"version" db:"version" redis:"version"`
 }
 
 type FilteredProject struct {
@@ -60,9 +65,13 @@ type ProjectAPIInterface interface {
 	GetProjects(args GetProjectArgs) ([]ProjectAuthors, error)
 	GetContents(id int, args GetProjectArgs) ([]interface{}, error)
 	FilterProjects(args *FilterProjectArgs) ([]interface{}, error)
-	InsertProject(p Project) error
+	InsertProject(p Project) (int, error)
 	UpdateProjects(p Project) error
+	InsertAuthors(projectID int, authors []AuthorInput) error
+	UpdateAuthors(projectID int, authors []AuthorInput) error
 	SchedulePublish() error
+	ReorderPosts(id int, postIDs []int) error
+	Reorder(ids []int) error
 }
 
 type GetProjectArgs struct {
@@ -266,7 +275,8 @@ func (p *FilterProjectArgs) parseRestricts() (restrictString string, values []in
 	if len(p.Tag) != 0 {
 		subRestricts := make([]string, 0)
 		for _, v := range p.Tag {
-			subRestricts = append(subRestricts, `tags.tag_content LIKE ?`)
+			// Projects tagged with descendants of tags matched are included
+			subRestricts = append(subRestricts, `tagging.tag_id IN (SELECT c.descendant_id FROM tag_closure AS c INNER JOIN tags AS ancestor ON ancestor.tag_id = c.ancestor_id WHERE ancestor.tag_content LIKE ?)`)
 			values = append(values, fmt.Sprintf("%s%s%s", "%", v, "%"))
 		}
 		restricts = append(restricts, fmt.Sprintf("(%s)", strings.Join(subRestricts, " OR ")))
@@ -335,7 +345,14 @@ type ProjectAuthor struct {
 	Project
 	ContentUpdateTime rrsql.NullTime   `json:"content_updated_at" db:"content_updated_at"`
 	Tags              rrsql.NullString `json:"-" db:"tags"`
-	Author            Stunt            `json:"author" db:"author"`
+	Author            ProjectCredit    `json:"author" db:"author"`
+}
+
+// ProjectCredit is a member credited in project as author_type, ordered by position within the type
+type ProjectCredit struct {
+	Stunt
+	Type     rrsql.NullInt `json:"author_type" db:"author_type"`
+	Position rrsql.NullInt `json:"position" db:"position"`
 }
 
 type SimpleTag struct {
@@ -347,10 +364,42 @@ type ProjectAuthors struct {
 	Project
 	ContentUpdateTime rrsql.NullTime   `json:"content_updated_at"`
 	Tags              rrsql.NullString `json:"-"`
-	Authors           []Stunt          `json:"authors"`
+	Authors           []ProjectCredit  `json:"authors"`
 	TagList           []SimpleTag      `json:"tags"`
 }
 
+// ------------ ↓↓↓ Requirement to satisfy LastPNRInterface  ↓↓↓ ------------
+
+// ReturnPublishedAt is created to return published_at and used in pnr API
+func (pa ProjectAuthors) ReturnPublishedAt() time.Time {
+	if pa.PublishedAt.Valid {
+		return pa.PublishedAt.Time
+	}
+	return time.Time{}
+}
+
+// ReturnCreatedAt is created to return created_at and used in pnr API
+func (pa ProjectAuthors) ReturnCreatedAt() time.Time {
+	if pa.CreatedAt.Valid {
+		return pa.CreatedAt.Time
+	}
+	return time.Time{}
+}
+
+// ReturnUpdatedAt is created to return updated_at and used in pnr API
+func (pa ProjectAuthors) ReturnUpdatedAt() time.Time {
+	if pa.UpdatedAt.Valid {
+		return pa.UpdatedAt.Time
+	}
+	return time.Time{}
+}
+
+func (pa ProjectAuthors) ReturnID() int { return pa.ID }
+
+func (pa ProjectAuthors) ReturnResourceType() string { return "project" }
+
+// ------------ ↑↑↑ End of requirement to satisfy LastPNRInterface  ↑↑↑ ------------
+
 func (p *ProjectAuthors) formatTags() {
 	if p.Tags.Valid != false {
 		tas := strings.Split(p.Tags.String, ",")
@@ -416,8 +465,14 @@ func (a *projectAPI) GetProjects(args GetProjectArgs) (result []ProjectAuthors,
 	// select *, a.nickname "a.nickname", a.member_id "a.member_id", a.points "a.points" from projects left join project_authors pa on projects.project_id = pa.project_id left join members a on pa.author_id = a.id where projects.project_id in (1000010, 1000013);
 	values = append(values, largs...)
 
+	// Authors of each project are listed by credit type, then position
+	authorOrder := "ORDER BY pa.author_type, pa.position"
+	if limit["order"] != "" {
+		authorOrder = fmt.Sprintf("%s, pa.author_type, pa.position", limit["order"])
+	}
+
 	query := fmt.Sprintf(`
-		SELECT projects.*, t.tags, po.published_at as content_updated_at , %s FROM (SELECT * FROM projects %s %s) AS projects
+		SELECT projects.*, t.tags, po.published_at as content_updated_at , %s, pa.author_type "author.author_type", pa.position "author.position" FROM (SELECT * FROM projects %s %s) AS projects
 		LEFT JOIN (
 			SELECT project_id, MAX(published_at) as published_at FROM posts WHERE project_id != 0 AND publish_status=%d GROUP BY project_id
 			) as po ON projects.project_id = po.project_id
@@ -426,20 +481,14 @@ func (a *projectAPI) GetProjects(args GetProjectArgs) (result []ProjectAuthors,
 			FROM tagging as pt LEFT JOIN tags as t ON t.tag_id = pt.tag_id WHERE pt.type=%d 
 			GROUP BY pt.target_id
 			) AS t ON t.project_id = projects.project_id
-		LEFT JOIN (
-			SELECT DISTINCT authors.author_id, posts.project_id FROM authors 
-			LEFT JOIN posts ON authors.resource_id = posts.post_id AND authors.resource_type = posts.type 
-			WHERE posts.type = %d OR posts.type = %d 
-			) AS pa ON projects.project_id = pa.project_id 
+		LEFT JOIN project_authors AS pa ON projects.project_id = pa.project_id
 		LEFT JOIN members author ON pa.author_id = author.id %s;`,
 		args.Fields.GetFields(`author.%s "author.%s"`),
 		restricts,
 		limit["full"],
 		config.Config.Models.PostPublishStatus["publish"],
 		config.Config.Models.TaggingType["project"],
-		config.Config.Models.PostType["memo"],
-		config.Config.Models.PostType["report"],
-		limit["order"])
+		authorOrder)
 
 	query, values, err = sqlx.In(query, values...)
 	if err != nil {
@@ -460,7 +509,7 @@ func (a *projectAPI) GetProjects(args GetProjectArgs) (result []ProjectAuthors,
 	for _, project := range pa {
 		var notNullAuthor = func(in ProjectAuthor) ProjectAuthors {
 			pas := ProjectAuthors{Project: in.Project, Tags: in.Tags, ContentUpdateTime: in.ContentUpdateTime}
-			if project.Author != (Stunt{}) {
+			if project.Author.Stunt != (Stunt{}) {
 				pas.Authors = append(pas.Authors, in.Author)
 			}
 			return pas
@@ -623,37 +672,39 @@ func (a *projectAPI) FilterProjects(args *FilterProjectArgs) (result []interface
 	return result, nil
 }
 
-func (a *projectAPI) InsertProject(p Project) error {
+// InsertProject returns id of project inserted, which is given or auto incremented
+func (a *projectAPI) InsertProject(p Project) (id int, err error) {
 
+	p.Version = rrsql.NullInt{Int: 1, Valid: true}
 	query, _ := rrsql.GenerateSQLStmt("insert", "projects", p)
 	result, err := rrsql.DB.NamedExec(query, p)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Duplicate entry") {
-			return errors.New("Duplicate entry")
+			return 0, errors.New("Duplicate entry")
 		}
-		return err
+		return 0, err
 	}
 	rowCnt, err := result.RowsAffected()
 	if err != nil {
 		log.Fatal(err)
 	}
 	if rowCnt > 1 {
-		return errors.New("More Than One Rows Affected") //Transaction rollback?
+		return 0, errors.New("More Than One Rows Affected") //Transaction rollback?
 	} else if rowCnt == 0 {
-		return errors.New("No Row Inserted")
+		return 0, errors.New("No Row Inserted")
 	}
 	lastID, err := result.LastInsertId()
 	if err != nil {
 		log.Printf("Fail to get last insert ID when insert a project: %v", err)
-		return err
+		return 0, err
+	}
+	if p.ID == 0 {
+		p.ID = int(lastID)
 	}
 
 	// Only insert a project when it's active
 	if p.Active.Valid == true && p.Active.Int == 1 {
-		if p.ID == 0 {
-			p.ID = int(lastID)
-		}
 		arg := GetProjectArgs{}
 		arg.Default()
 		arg.IDs = []int{p.ID}
@@ -663,18 +714,21 @@ func (a *projectAPI) InsertProject(p Project) error {
 		projects, err := ProjectAPI.GetProjects(arg)
 		if err != nil {
 			log.Printf("Error When Getting Project to Insert to SearchFeed: %v", err.Error())
-			return nil
+			return p.ID, nil
 		}
 		go SearchFeed.InsertProject(projects)
 	}
 
-	return nil
+	return p.ID, nil
 }
 
 func (a *projectAPI) UpdateProjects(p Project) error {
 
+	// Updates without version, such as counters from memos and reports, still bump it
+	version := p.Version
+	p.Version = rrsql.NullInt{}
 	query, _ := rrsql.GenerateSQLStmt("partial_update", "projects", p)
-	result, err := rrsql.DB.NamedExec(query, p)
+	result, err := rrsql.DB.NamedExec(rrsql.Versioned(query, version), p)
 
 	if err != nil {
 		return err
@@ -682,6 +736,8 @@ func (a *projectAPI) UpdateProjects(p Project) error {
 	rowCnt, err := result.RowsAffected()
 	if rowCnt > 1 {
 		return errors.New("More Than One Rows Affected") //Transaction rollback?
+	} else if rowCnt == 0 && version.Valid {
+		return rrsql.VersionConflictError
 	} else if rowCnt == 0 {
 		return errors.New("Project Not Found")
 	}
@@ -779,18 +835,35 @@ func (a *projectAPI) SchedulePublish() error {
 // 	return result, nil
 // }
 
-func (a *projectAPI) InsertAuthors(projectID int, authorIDs []int) (err error) {
+// creditValues lists credits of project for insertion.
+// Repeated credits of a member as the same author type are dropped, and positions are filled in by input order.
+func creditValues(projectID int, authors []AuthorInput) (valueStr []string, values []interface{}) {
+
+	credited := make(map[[2]int64]bool)
+	unique := make([]AuthorInput, 0, len(authors))
+	for _, author := range authors {
+		key := [2]int64{author.MemberID.Int, author.Type.Int}
+		if credited[key] {
+			continue
+		}
+		credited[key] = true
+		unique = append(unique, author)
+	}
+	for _, author := range positionAuthors(unique) {
+		valueStr = append(valueStr, `(?, ?, ?, ?)`)
+		values = append(values, projectID, author.MemberID.Int, author.Type.Int, author.Position.Int)
+	}
+	return valueStr, values
+}
+
+func (a *projectAPI) InsertAuthors(projectID int, authors []AuthorInput) (err error) {
 
-	var (
-		valueStr     []string
-		insertValues []interface{}
-	)
-	for _, author := range authorIDs {
-		valueStr = append(valueStr, `(?, ?)`)
-		insertValues = append(insertValues, projectID, author)
+	if len(authors) == 0 {
+		return nil
 	}
-	//INSERT IGNORE INTO project_authorIDs (project_id, author_id) VALUES ( ?, ? ), ( ?, ? );
-	query := fmt.Sprintf(`INSERT IGNORE INTO project_authors (project_id, author_id) VALUES %s;`, strings.Join(valueStr, ", "))
+	valueStr, insertValues := creditValues(projectID, authors)
+	//INSERT IGNORE INTO project_authors (project_id, author_id, author_type, position) VALUES ( ?, ?, ?, ? ), ( ?, ?, ?, ? );
+	query := fmt.Sprintf(`INSERT IGNORE INTO project_authors (project_id, author_id, author_type, position) VALUES %s;`, strings.Join(valueStr, ", "))
 	_, err = rrsql.DB.Exec(query, insertValues...)
 	if err != nil {
 		sqlerr, ok := err.(*mysql.MySQLError)
@@ -802,55 +875,27 @@ func (a *projectAPI) InsertAuthors(projectID int, authorIDs []int) (err error) {
 	return err
 }
 
-func (a *projectAPI) UpdateAuthors(projectID int, authorIDs []int) (err error) {
+// UpdateAuthors replaces credits of project with authors, in the order given
+func (a *projectAPI) UpdateAuthors(projectID int, authors []AuthorInput) (err error) {
 
-	// Delete all author record if authorIDs is null
-	if authorIDs == nil || len(authorIDs) == 0 {
+	// Delete all author record if authors is null
+	if authors == nil || len(authors) == 0 {
 		_, err = rrsql.DB.Exec(`DELETE FROM project_authors WHERE project_id = ?`, projectID)
 		if err != nil {
 			return err
 		}
 		return nil
 	}
-	tx, err := rrsql.DB.Beginx()
-	if err != nil {
-		log.Printf("Fail to get sql connection: %v", err)
-		return err
-	}
-	// Either rollback or commit transaction
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			return
-		}
-		err = tx.Commit()
-	}()
-
-	del, args, err := sqlx.In(`DELETE FROM project_authors WHERE project_id = ? AND author_id NOT IN (?)`, projectID, authorIDs)
-	if err != nil {
-		log.Printf("Fail to generate query: %v", err)
-		return err
-	}
-	del = rrsql.DB.Rebind(del)
-	_, err = tx.Exec(del, args...)
-	if err != nil {
 
-	}
-	var (
-		valueStr     []string
-		insertValues []interface{}
-	)
-	for _, author := range authorIDs {
-		valueStr = append(valueStr, `(?, ?)`)
-		insertValues = append(insertValues, projectID, author)
-	}
-	//INSERT IGNORE INTO project_authorIDs (project_id, author_id) VALUES ( ?, ? ), ( ?, ? );
-	ins := fmt.Sprintf(`INSERT IGNORE INTO project_authors (project_id, author_id) VALUES %s;`, strings.Join(valueStr, ", "))
-	_, err = tx.Exec(ins, insertValues...)
-	if err != nil {
+	valueStr, insertValues := creditValues(projectID, authors)
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM project_authors WHERE project_id = ?`, projectID); err != nil {
+			return err
+		}
+		ins := fmt.Sprintf(`INSERT IGNORE INTO project_authors (project_id, author_id, author_type, position) VALUES %s;`, strings.Join(valueStr, ", "))
+		_, err := tx.Exec(ins, insertValues...)
 		return err
-	}
-	return err
+	})
 }
 
 var ProjectAPI ProjectAPIInterface = new(projectAPI)
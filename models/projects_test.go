@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreditValues(t *testing.T) {
+
+	authors := []AuthorInput{
+		{MemberID: rrsql.NullInt{Int: 1, Valid: true}, Type: rrsql.NullInt{Int: 0, Valid: true}},
+		{MemberID: rrsql.NullInt{Int: 2, Valid: true}, Type: rrsql.NullInt{Int: 0, Valid: true}},
+		{MemberID: rrsql.NullInt{Int: 1, Valid: true}, Type: rrsql.NullInt{Int: 0, Valid: true}},
+		{MemberID: rrsql.NullInt{Int: 1, Valid: true}, Type: rrsql.NullInt{Int: 1, Valid: true}},
+	}
+	valueStr, values := creditValues(7, authors)
+
+	// Repeated credit of member 1 as type 0 is dropped, without leaving a gap in positions
+	assert.Equal(t, 3, len(valueStr))
+	assert.Equal(t, []interface{}{
+		7, int64(1), int64(0), int64(0),
+		7, int64(2), int64(0), int64(1),
+		7, int64(1), int64(1), int64(0),
+	}, values)
+}
@@ -87,30 +87,6 @@ func (r *redisHelper) getOrderedHashes(keysTemplate string, quantity int) (resul
 	return result, err
 }
 
-/*
-func (r *redisHelper) GetHotPosts(keysTemplate string, quantity int) (result []HotPost, err error) {
-	res, err := r.getOrderedHashes(keysTemplate, quantity)
-	if err != nil {
-		log.Printf("Error getting redis key: %v", err)
-		return result, err
-	}
-
-	for _, v := range res {
-		var p HotPost
-		if err = redis.ScanStruct(v, &p); err != nil {
-			log.Printf("Error scan redis key: %v", err)
-			return result, err
-		}
-		if p.ID == 0 {
-			break
-		}
-		result = append(result, p)
-	}
-
-	return result, err
-}
-*/
-
 func (r *redisHelper) GetHotTags(keysTemplate string, quantity int) (result []TagRelatedResources, err error) {
 	conn := r.ReadConn()
 	defer conn.Close()
@@ -0,0 +1,267 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// RelatedPost is a post recommended to read next, with the score it ranked by
+type RelatedPost struct {
+	ID    uint32  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+type relatedPostStats struct {
+	SharedTags    int
+	SameProject   bool
+	SharedAuthors int
+	CoEngagement  int
+	PublishedAt   time.Time
+}
+
+// CalcScore sums up weighted signals, and decays the sum by the age of post
+func (s relatedPostStats) CalcScore(now time.Time) float64 {
+	weight := config.Config.Models.RelatedPostsWeight
+
+	score := float64(s.SharedTags*weight["tag"] + s.SharedAuthors*weight["author"])
+	if s.SameProject {
+		score += float64(weight["project"])
+	}
+	// Co-engagement grows fast on popular posts, normalize it like tagResStats does
+	score += math.Log2(float64(1+s.CoEngagement)) * float64(weight["engagement"])
+
+	if halfLife := config.Config.RelatedPosts.HalfLifeDays; halfLife > 0 && !s.PublishedAt.IsZero() {
+		age := now.Sub(s.PublishedAt).Hours() / 24
+		if age > 0 {
+			score *= math.Pow(0.5, age/float64(halfLife))
+		}
+	}
+	return score
+}
+
+type RelatedPostInterface interface {
+	Get(postID uint32) ([]RelatedPost, error)
+	Precompute() error
+}
+
+type relatedPostAPI struct{}
+
+var RelatedPostAPI RelatedPostInterface = new(relatedPostAPI)
+
+func (a *relatedPostAPI) key(postID uint32) string {
+	return fmt.Sprintf("postcache_related_%d", postID)
+}
+
+func (a *relatedPostAPI) candidateLimit() int {
+	if limit := config.Config.RelatedPosts.CandidateLimit; limit > 0 {
+		return limit
+	}
+	return 200
+}
+
+// collect runs query which selects (post_id, count) and passes each row to fn
+func (a *relatedPostAPI) collect(query string, args []interface{}, fn func(id uint32, count int)) error {
+
+	rows, err := rrsql.DB.Queryx(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint32
+		var count int
+		if err = rows.Scan(&id, &count); err != nil {
+			return err
+		}
+		fn(id, count)
+	}
+	return rows.Err()
+}
+
+// Compute ranks related posts of postID directly from database
+func (a *relatedPostAPI) Compute(postID uint32) (result []RelatedPost, err error) {
+
+	var projectID rrsql.NullInt
+	if err = rrsql.DB.Get(&projectID, `SELECT project_id FROM posts WHERE post_id = ?`, postID); err == sql.ErrNoRows {
+		return nil, errors.New("Post Not Found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[uint32]*relatedPostStats)
+	candidate := func(id uint32) *relatedPostStats {
+		if _, ok := candidates[id]; !ok {
+			candidates[id] = &relatedPostStats{}
+		}
+		return candidates[id]
+	}
+	limit := a.candidateLimit()
+
+	// Shared tags
+	if err = a.collect(`
+		SELECT t2.target_id, COUNT(*) FROM tagging AS t1
+		INNER JOIN tagging AS t2 ON t1.tag_id = t2.tag_id AND t2.type = t1.type
+		WHERE t1.type = ? AND t1.target_id = ? AND t2.target_id != t1.target_id
+		GROUP BY t2.target_id ORDER BY COUNT(*) DESC LIMIT ?;`,
+		[]interface{}{config.Config.Models.TaggingType["post"], postID, limit},
+		func(id uint32, count int) { candidate(id).SharedTags = count }); err != nil {
+		return nil, err
+	}
+
+	// Overlapping authors
+	if err = a.collect(`
+		SELECT a2.resource_id, COUNT(DISTINCT a2.author_id) FROM authors AS a1
+		INNER JOIN authors AS a2 ON a1.author_id = a2.author_id
+		WHERE a1.resource_id = ? AND a2.resource_id != a1.resource_id
+		GROUP BY a2.resource_id ORDER BY COUNT(DISTINCT a2.author_id) DESC LIMIT ?;`,
+		[]interface{}{postID, limit},
+		func(id uint32, count int) { candidate(id).SharedAuthors = count }); err != nil {
+		return nil, err
+	}
+
+	// Members who followed or emoted on both posts
+	if err = a.collect(`
+		SELECT f2.target_id, COUNT(DISTINCT f2.member_id) FROM following AS f1
+		INNER JOIN following AS f2 ON f1.member_id = f2.member_id AND f2.type = f1.type
+		WHERE f1.type = ? AND f1.target_id = ? AND f2.target_id != f1.target_id
+		GROUP BY f2.target_id ORDER BY COUNT(DISTINCT f2.member_id) DESC LIMIT ?;`,
+		[]interface{}{config.Config.Models.FollowingType["post"], postID, limit},
+		func(id uint32, count int) { candidate(id).CoEngagement = count }); err != nil {
+		return nil, err
+	}
+
+	// Same project
+	if projectID.Valid && projectID.Int > 0 {
+		if err = a.collect(`
+			SELECT post_id, 1 FROM posts WHERE project_id = ? AND post_id != ?
+			ORDER BY published_at DESC LIMIT ?;`,
+			[]interface{}{projectID.Int, postID, limit},
+			func(id uint32, count int) { candidate(id).SameProject = true }); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(candidates) == 0 {
+		return []RelatedPost{}, nil
+	}
+
+	// Only public posts are recommended
+	ids := make([]uint32, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	query, args, err := sqlx.In(`SELECT post_id, published_at FROM posts WHERE post_id IN (?) AND active = ? AND publish_status = ?;`,
+		ids, config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"])
+	if err != nil {
+		return nil, err
+	}
+	rows, err := rrsql.DB.Queryx(rrsql.DB.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	result = make([]RelatedPost, 0)
+	for rows.Next() {
+		var id uint32
+		var publishedAt rrsql.NullTime
+		if err = rows.Scan(&id, &publishedAt); err != nil {
+			return nil, err
+		}
+		stats := candidates[id]
+		stats.PublishedAt = publishedAt.Time
+		if score := stats.CalcScore(now); score > 0 {
+			result = append(result, RelatedPost{ID: id, Score: score})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score == result[j].Score {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].Score > result[j].Score
+	})
+	if max := config.Config.RelatedPosts.MaxResult; max > 0 && len(result) > max {
+		result = result[:max]
+	}
+	return result, nil
+}
+
+func (a *relatedPostAPI) save(postID uint32, related []RelatedPost) error {
+
+	body, err := json.Marshal(related)
+	if err != nil {
+		return err
+	}
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	if ttl := config.Config.RelatedPosts.CacheTTL; ttl > 0 {
+		_, err = conn.Do("SET", a.key(postID), body, "EX", ttl)
+	} else {
+		_, err = conn.Do("SET", a.key(postID), body)
+	}
+	return err
+}
+
+// Get returns precomputed related posts in Redis, or computes them on demand
+func (a *relatedPostAPI) Get(postID uint32) (result []RelatedPost, err error) {
+
+	conn := RedisHelper.ReadConn()
+	body, err := redis.Bytes(conn.Do("GET", a.key(postID)))
+	conn.Close()
+	if err == nil {
+		if err = json.Unmarshal(body, &result); err == nil {
+			return result, nil
+		}
+	}
+
+	return a.Compute(postID)
+}
+
+// Precompute ranks related posts for hot posts, which are followed or emoted most in recent days
+func (a *relatedPostAPI) Precompute() error {
+
+	hotPosts, hotDays := config.Config.RelatedPosts.HotPosts, config.Config.RelatedPosts.HotDays
+	if hotPosts <= 0 {
+		hotPosts = 100
+	}
+	if hotDays <= 0 {
+		hotDays = 7
+	}
+
+	var ids []uint32
+	if err := rrsql.DB.Select(&ids, `
+		SELECT f.target_id FROM following AS f
+		INNER JOIN posts AS p ON f.target_id = p.post_id
+		WHERE f.type = ? AND f.created_at > ? AND p.active = ? AND p.publish_status = ?
+		GROUP BY f.target_id ORDER BY COUNT(*) DESC LIMIT ?;`,
+		config.Config.Models.FollowingType["post"], time.Now().AddDate(0, 0, -hotDays),
+		config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"], hotPosts); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		related, err := a.Compute(id)
+		if err != nil {
+			log.Printf("Error computing related posts of post %d: %v\n", id, err)
+			continue
+		}
+		if err = a.save(id, related); err != nil {
+			log.Printf("Error saving related posts of post %d: %v\n", id, err)
+		}
+	}
+	return nil
+}
@@ -39,6 +39,8 @@ type Report struct {
 	LinkName        rrsql.NullString `json:"link_name" db:"link_name" redis:"link_name"`
 	VideoID         rrsql.NullString `json:"video_id" db:"video_id" redis:"video_id"`
 	VideoViews      rrsql.NullInt    `json:"video_views" db:"video_views" redis:"video_views"`
+	Views           rrsql.NullInt    `json:"views" db:"views" redis:"views"`
+	UniqueViews     rrsql.NullInt    `json:"unique_views" db:"unique_views" redis:"unique_views"`
 	PublishStatus   rrsql.NullInt    `json:"publish_status" db:"publish_status" redis:"publish_status"`
 	ProjectID       rrsql.NullInt    `json:"project_id" db:"project_id" redis:"project_id"`
 	Order           rrsql.NullInt    `json:"post_order" db:"post_order" redis:"post_order"`
@@ -46,6 +48,12 @@ type Report struct {
 	Slug            rrsql.NullString `json:"slug" db:"slug" redis:"slug"`
 	CSS             rrsql.NullString `json:"css" db:"css" redis:"css"`
 	JS              rrsql.NullString `json:"javascript" db:"javascript" redis:"javascript"`
+	CharCount       rrsql.NullInt    `json:"char_count" db:"char_count" redis:"char_count"`
+	WordCount       rrsql.NullInt    `json:"word_count" db:"word_count" redis:"word_count"`
+	ReadingTime     rrsql.NullInt    `json:"reading_time" db:"reading_time" redis:"reading_time"`
+	ImageCount      rrsql.NullInt    `json:"image_count" db:"image_count" redis:"image_count"`
+	EmbedCount      rrsql.NullInt    `json:"embed_count" db:"embed_count" redis:"embed_count"`
+	Version         rrsql.NullInt    `json:"version" db:"version" redis:"version"`
 }
 
 type reportAPI struct{}
@@ -215,6 +223,10 @@ func (ra ReportAuthors) ReturnUpdatedAt() time.Time {
 	return time.Time{}
 }
 
+func (ra ReportAuthors) ReturnID() int { return int(ra.ID) }
+
+func (ra ReportAuthors) ReturnResourceType() string { return "report" }
+
 // ------------ ↑↑↑ End of requirement to satisfy LastPNRInterface  ↑↑↑ ------------
 
 type ReportAuthor struct {
@@ -329,6 +341,7 @@ func (a *reportAPI) GetReports(args GetReportArgs) (result []ReportAuthors, err
 func (a *reportAPI) InsertReport(p Report) (lastID int, err error) {
 
 	p.Type = rrsql.NullInt{int64(config.Config.Models.PostType["report"]), true}
+	p.Version = rrsql.NullInt{Int: 1, Valid: true}
 
 	query, _ := rrsql.GenerateSQLStmt("insert", "posts", p)
 	result, err := rrsql.DB.NamedExec(query, p)
@@ -362,9 +375,10 @@ func (a *reportAPI) InsertReport(p Report) (lastID int, err error) {
 }
 
 func (a *reportAPI) UpdateReport(p Report) error {
+	p.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", p)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, tags)
-	query := fmt.Sprintf(`UPDATE posts SET %s WHERE post_id = :post_id`, strings.Join(fields, ", "))
+	query := rrsql.Versioned(fmt.Sprintf(`UPDATE posts SET %s WHERE post_id = :post_id`, strings.Join(fields, ", ")), rrsql.NullInt{})
 	result, err := rrsql.DB.NamedExec(query, p)
 
 	if err != nil {
@@ -59,7 +59,15 @@ func (s *searchEngine) Init(enable bool) {
 
 }
 
-func (s *searchEngine) insert(id int, payload []byte, objectType string) (err error) {
+// docID is the id of document in index. Translations of resource are indexed with their language.
+func (s *searchEngine) docID(objectType string, id int, lang string) string {
+	if lang == "" || IsSourceLanguage(lang) {
+		return fmt.Sprintf("%s_%d", objectType, id)
+	}
+	return fmt.Sprintf("%s_%d_%s", objectType, id, lang)
+}
+
+func (s *searchEngine) insert(docID string, payload []byte) (err error) {
 	if s.searchEnabled {
 		retry := 0
 		for retry < config.Config.SearchFeed.MaxRetry {
@@ -67,7 +75,7 @@ func (s *searchEngine) insert(id int, payload []byte, objectType string) (err er
 			_, err := s.client.Index().
 				Index(config.Config.SearchFeed.IndexName).
 				Type("_doc").
-				Id(fmt.Sprintf("%s_%d", objectType, id)).
+				Id(docID).
 				BodyString(string(payload)).
 				Do(ctx)
 			if err != nil {
@@ -83,7 +91,7 @@ func (s *searchEngine) insert(id int, payload []byte, objectType string) (err er
 	return nil
 }
 
-func (s *searchEngine) delete(id int, objectType string) (err error) {
+func (s *searchEngine) delete(docID string) (err error) {
 	if s.searchEnabled {
 		retry := 0
 		for retry < config.Config.SearchFeed.MaxRetry {
@@ -91,7 +99,7 @@ func (s *searchEngine) delete(id int, objectType string) (err error) {
 			_, err := s.client.Delete().
 				Index(config.Config.SearchFeed.IndexName).
 				Type("_doc").
-				Id(fmt.Sprintf("%s_%d", objectType, id)).
+				Id(docID).
 				Do(ctx)
 			if err != nil {
 				retry += 1
@@ -116,19 +124,52 @@ func (s *searchEngine) InsertPost(input []TaggedPostMember) error {
 		TaggedPostMember
 		ObjectType string `json:"objectType"`
 	}
+	if !s.searchEnabled {
+		return nil
+	}
+	ids := make([]uint32, 0, len(input))
 	for _, tpm := range input {
-		tpm.UpdatedBy = nil
-		typedObject := searchObject{tpm, "post"}
-		typedString, err := json.Marshal(typedObject)
-		if err != nil {
-			log.Println("Marshal post error:", err.Error())
-			return err
+		ids = append(ids, tpm.ID)
+	}
+
+	// Each language is indexed as a separate document
+	for _, lang := range Languages() {
+		translations := map[uint32]PostTranslation{}
+		if !IsSourceLanguage(lang) {
+			var err error
+			if translations, err = PostTranslationAPI.Get(ids, lang); err != nil {
+				log.Println("Get post translations error:", err.Error())
+				return err
+			}
+		}
+		for _, tpm := range input {
+			tpm.UpdatedBy = nil
+			tpm.Language = config.Config.Translation.SourceLanguage
+			if !IsSourceLanguage(lang) {
+				t, ok := translations[tpm.ID]
+				if !ok {
+					continue
+				}
+				tpm.Cards = append([]postCard{}, tpm.Cards...)
+				t.Localize(&tpm)
+			}
+			typedObject := searchObject{tpm, "post"}
+			typedString, err := json.Marshal(typedObject)
+			if err != nil {
+				log.Println("Marshal post error:", err.Error())
+				return err
+			}
+			s.insert(s.docID("post", int(tpm.ID), tpm.Language), typedString)
 		}
-		s.insert(int(tpm.ID), typedString, "post")
 	}
 	return nil
 }
 
+// DeletePostTranslation removes the document of post translated in lang
+func (s *searchEngine) DeletePostTranslation(id int, lang string) error {
+	return s.delete(s.docID("post", id, lang))
+}
+
 func (s *searchEngine) InsertProject(input []ProjectAuthors) error {
 	type searchObject struct {
 		ProjectAuthors
@@ -141,21 +182,23 @@ func (s *searchEngine) InsertProject(input []ProjectAuthors) error {
 			log.Println("Marshal post error:", err.Error())
 			return err
 		}
-		s.insert(int(tpm.Project.ID), typedString, "project")
+		s.insert(s.docID("project", int(tpm.Project.ID), ""), typedString)
 	}
 	return nil
 }
 
 func (s *searchEngine) DeletePost(ids []int) error {
 	for _, id := range ids {
-		s.delete(id, "post")
+		for _, lang := range Languages() {
+			s.delete(s.docID("post", id, lang))
+		}
 	}
 	return nil
 }
 
 func (s *searchEngine) DeleteProject(ids []int) error {
 	for _, id := range ids {
-		s.delete(id, "project")
+		s.delete(s.docID("project", id, ""))
 	}
 	return nil
 }
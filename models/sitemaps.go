@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// SitemapEntry is the least information to list a resource in sitemap
+type SitemapEntry struct {
+	ID          int              `db:"id"`
+	Slug        rrsql.NullString `db:"slug"`
+	Title       rrsql.NullString `db:"title"`
+	UpdatedAt   rrsql.NullTime   `db:"updated_at"`
+	PublishedAt rrsql.NullTime   `db:"published_at"`
+	// Type and ProjectSlug tell memos, which are pages of their series, from other posts
+	Type        rrsql.NullInt    `db:"type"`
+	ProjectSlug rrsql.NullString `db:"project_slug"`
+}
+
+// LastMod returns updated_at, or published_at if the entry is never updated
+func (e SitemapEntry) LastMod() time.Time {
+	if e.UpdatedAt.Valid {
+		return e.UpdatedAt.Time
+	}
+	if e.PublishedAt.Valid {
+		return e.PublishedAt.Time
+	}
+	return time.Time{}
+}
+
+type SitemapInterface interface {
+	Count(resource string) (int, error)
+	GetEntries(resource string, offset int, limit int) ([]SitemapEntry, error)
+	GetRecentPosts(since time.Time) ([]SitemapEntry, error)
+}
+
+type sitemapAPI struct{}
+
+var SitemapAPI SitemapInterface = new(sitemapAPI)
+
+// SitemapCache keeps rendered sitemaps in Redis until the next publishing
+var SitemapCache = feedCache{prefix: "sitemapcache", ttl: &config.Config.Sitemap.CacheTTL}
+
+// SitemapResources lists the resources included in sitemap, in the order of sitemap index
+var SitemapResources = []string{"posts", "projects", "tags", "members"}
+
+// sitemapSource returns the select fields, table and restricts for only public resource
+func (s *sitemapAPI) sitemapSource(resource string) (query string, values []interface{}, err error) {
+
+	switch resource {
+	case "posts":
+		return `SELECT posts.post_id AS id, posts.slug, posts.title, posts.updated_at, posts.published_at, posts.type, project.slug AS project_slug
+			FROM posts LEFT JOIN projects AS project ON posts.project_id = project.project_id WHERE posts.active = ? AND posts.publish_status = ?`,
+			[]interface{}{config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"]}, nil
+	case "projects":
+		return `SELECT project_id AS id, slug, title, updated_at, published_at FROM projects WHERE active = ? AND publish_status = ? AND slug IS NOT NULL`,
+			[]interface{}{config.Config.Models.ProjectsActive["active"], config.Config.Models.ProjectsPublishStatus["publish"]}, nil
+	case "tags":
+		return `SELECT tag_id AS id, NULL AS slug, tag_content AS title, updated_at, created_at AS published_at FROM tags WHERE active = ? AND alias_of IS NULL`,
+			[]interface{}{config.Config.Models.Tags["active"]}, nil
+	case "members":
+		return `SELECT id, NULL AS slug, nickname AS title, updated_at, created_at AS published_at FROM members WHERE active = ? AND (hide_profile IS NULL OR hide_profile = 0)`,
+			[]interface{}{config.Config.Models.Members["active"]}, nil
+	default:
+		return "", nil, errors.New("Invalid Sitemap Resource")
+	}
+}
+
+func (s *sitemapAPI) Count(resource string) (count int, err error) {
+
+	query, values, err := s.sitemapSource(resource)
+	if err != nil {
+		return 0, err
+	}
+	err = rrsql.DB.Get(&count, fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS subquery`, query), values...)
+	return count, err
+}
+
+func (s *sitemapAPI) GetEntries(resource string, offset int, limit int) (result []SitemapEntry, err error) {
+
+	query, values, err := s.sitemapSource(resource)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf(`%s ORDER BY id LIMIT ? OFFSET ?`, query)
+	values = append(values, limit, offset)
+
+	if err = rrsql.DB.Select(&result, query, values...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRecentPosts returns posts published after since, which are listed in news sitemap
+func (s *sitemapAPI) GetRecentPosts(since time.Time) (result []SitemapEntry, err error) {
+
+	query, values, err := s.sitemapSource("posts")
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf(`%s AND posts.published_at >= ? ORDER BY posts.published_at DESC LIMIT 1000`, query)
+	values = append(values, since)
+
+	if err = rrsql.DB.Select(&result, query, values...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
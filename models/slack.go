@@ -2,6 +2,8 @@ package models
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"log"
 
 	"net/http"
@@ -66,3 +68,28 @@ func (s *slackHelper) SendCECommentNotify(tpm TaggedPostMember) error {
 
 	return nil
 }
+
+// SendBrokenLinksNotify tells member responsible for links that they are broken.
+// Message is marshaled instead of templated, since urls could break JSON.
+func (s *slackHelper) SendBrokenLinksNotify(member string, notices []LinkCheckNotice) error {
+
+	type attachment struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	}
+	attachments := make([]attachment, 0, len(notices))
+	for _, n := range notices {
+		attachments = append(attachments, attachment{
+			Title: fmt.Sprintf("%s %d: %s", n.ResourceType, n.ResourceID, n.Status),
+			Text:  n.URL,
+		})
+	}
+	msg, err := json.Marshal(map[string]interface{}{
+		"text":        fmt.Sprintf("%s 負責的 %d 個連結已失效", member, len(notices)),
+		"attachments": attachments,
+	})
+	if err != nil {
+		return err
+	}
+	return s.SendSlackMsg(msg, config.Config.Slack.NotifyWebhook)
+}
@@ -0,0 +1,450 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/pkg/cards"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+// Content tagged with a tag or its descendants is listed in /tags/pnr as one feed.
+// The feed is sorted by time then id, and paged by cursor of the last item listed,
+// so that items published in between are neither skipped nor repeated.
+
+// PNRTypes are resource types listed in /tags/pnr.
+// Reports are listed when either reports or their projects are tagged, and cards are listed when their posts are tagged.
+var PNRTypes = []string{"post", "report", "project", "card", "poll"}
+
+// pollActive is the active value of polls, as listed by /v2/polls
+const pollActive = 1
+
+type GetPostReportArgs struct {
+	MaxResult int    `form:"max_result"`
+	Page      int    `form:"page"`
+	Sorting   string `form:"sort"`
+	Cursor    string `form:"cursor"`
+	TagID     int
+	Types     []string
+	Filter    Filter
+
+	cursor *pnrCursor
+}
+
+func NewGetPostReportArgs(options ...func(*GetPostReportArgs)) *GetPostReportArgs {
+
+	arg := GetPostReportArgs{MaxResult: 20, Page: 1, Sorting: "-published_at", Types: PNRTypes}
+
+	for _, option := range options {
+		option(&arg)
+	}
+	return &arg
+}
+
+func (a *GetPostReportArgs) ValidateGet() (err error) {
+
+	if !utils.ValidateStringArgs(a.Sorting, "-?(created_at|updated_at|published_at)") {
+		return errors.New("Invalid Sort Option")
+	}
+	if a.MaxResult < 1 || a.MaxResult > 100 {
+		return errors.New("Invalid Max Result")
+	}
+	if len(a.Types) == 0 {
+		return errors.New("Invalid Types")
+	}
+	for _, t := range a.Types {
+		if !validPNRType(t) {
+			return errors.New("Invalid Types")
+		}
+	}
+	if a.Cursor != "" {
+		if a.cursor, err = parsePNRCursor(a.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *GetPostReportArgs) ValidateFilter() (err error) {
+
+	if !utils.ValidateStringArgs(a.Filter.Field, "(created_at|updated_at|published_at)") {
+		return errors.New("Invalid Filter Field")
+	}
+	// This will be blocked by Error: No Valid PNR Filter
+	// if !utils.ValidateStringArgs(a.Filter.Operator, "(<|<=|>|>=|==|!=)") {
+	// 	return errors.New("Invalid Filter Operator")
+	// }
+	if _, err := time.Parse(time.RFC3339, a.Filter.Condition); err != nil {
+		return errors.New("Invalid Filter Time Condition")
+	}
+	// If fields match a.Sorting
+	var sortPattern string
+	if strings.HasPrefix(a.Sorting, "-") {
+		sortPattern = strings.TrimPrefix(a.Sorting, "-")
+	} else {
+		sortPattern = a.Sorting
+	}
+	if !utils.ValidateStringArgs(a.Filter.Field, fmt.Sprintf("-?(%s)", sortPattern)) {
+		return errors.New("Inconsistent Filter Field")
+	}
+	return nil
+}
+
+func validPNRType(resourceType string) bool {
+	for _, t := range PNRTypes {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// LastPNRInterface is used in /tags/pnr
+// TaggedPostMember, ReportAuthors, ProjectAuthors, TaggedCard and TaggedPoll satisfy this interface
+type LastPNRInterface interface {
+	ReturnPublishedAt() time.Time
+	ReturnCreatedAt() time.Time
+	ReturnUpdatedAt() time.Time
+	ReturnID() int
+	ReturnResourceType() string
+}
+
+// PNRItem is marshalled as the item itself with its resource type
+type PNRItem struct {
+	LastPNRInterface
+}
+
+func (i PNRItem) MarshalJSON() ([]byte, error) {
+
+	body, err := json.Marshal(i.LastPNRInterface)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+	values["resource_type"], _ = json.Marshal(i.ReturnResourceType())
+	return json.Marshal(values)
+}
+
+// pnrCursor is the position of the last item listed
+type pnrCursor struct {
+	At   time.Time `json:"at"`
+	Type string    `json:"type"`
+	ID   int       `json:"id"`
+}
+
+func pnrTime(sorting string, item LastPNRInterface) time.Time {
+
+	switch strings.TrimPrefix(sorting, "-") {
+	case "published_at":
+		return item.ReturnPublishedAt()
+	case "created_at":
+		return item.ReturnCreatedAt()
+	case "updated_at":
+		return item.ReturnUpdatedAt()
+	}
+	return time.Time{}
+}
+
+// EncodePNRCursor returns cursor for items after item in sorting
+func EncodePNRCursor(sorting string, item LastPNRInterface) string {
+
+	body, _ := json.Marshal(pnrCursor{At: pnrTime(sorting, item), Type: item.ReturnResourceType(), ID: item.ReturnID()})
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func parsePNRCursor(cursor string) (*pnrCursor, error) {
+
+	body, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("Invalid Cursor")
+	}
+	var c pnrCursor
+	if err = json.Unmarshal(body, &c); err != nil || !validPNRType(c.Type) {
+		return nil, errors.New("Invalid Cursor")
+	}
+	return &c, nil
+}
+
+// pnrSource is how resources of a type tagged with a tag are selected.
+// from is FROM and WHERE clause, with args bound to its placeholders.
+type pnrSource struct {
+	id   string
+	at   string
+	from string
+	args []interface{}
+}
+
+func newPNRSource(resourceType string, field string, tagID int) (s pnrSource) {
+
+	tagged := `SELECT target_id FROM tagging WHERE type = ? AND tag_id IN (` + tagDescendants + `)`
+	taggingType := config.Config.Models.TaggingType
+
+	switch resourceType {
+	case "post":
+		s.id, s.at = "posts.post_id", "posts."+field
+		s.from = fmt.Sprintf(`FROM posts WHERE posts.post_id IN (%s) AND posts.type != ? AND posts.active != ?`, tagged)
+		s.args = []interface{}{taggingType["post"], tagID, config.Config.Models.PostType["report"], config.Config.Models.Posts["deactive"]}
+	case "report":
+		s.id, s.at = "posts.post_id", "posts."+field
+		s.from = fmt.Sprintf(`FROM posts WHERE posts.type = ? AND posts.active != ? AND (posts.project_id IN (%s) OR posts.post_id IN (%s))`, tagged, tagged)
+		s.args = []interface{}{config.Config.Models.PostType["report"], config.Config.Models.Reports["deactive"], taggingType["project"], tagID, taggingType["post"], tagID}
+	case "project":
+		s.id, s.at = "projects.project_id", "projects."+field
+		s.from = fmt.Sprintf(`FROM projects WHERE projects.project_id IN (%s) AND projects.active != ?`, tagged)
+		s.args = []interface{}{taggingType["project"], tagID, config.Config.Models.ProjectsActive["deactive"]}
+	case "card":
+		// Cards are published along with their posts
+		s.id, s.at = "newscards.id", "newscards."+field
+		if field == "published_at" {
+			s.at = "posts.published_at"
+		}
+		s.from = fmt.Sprintf(`FROM newscards INNER JOIN posts ON posts.post_id = newscards.post_id WHERE posts.post_id IN (%s) AND posts.active != ? AND newscards.active = ?`, tagged)
+		s.args = []interface{}{taggingType["post"], tagID, config.Config.Models.Posts["deactive"], config.Config.Models.Cards["active"]}
+	case "poll":
+		s.id, s.at = "polls.id", "polls."+field
+		s.from = fmt.Sprintf(`FROM polls WHERE polls.id IN (%s) AND polls.active = ?`, tagged)
+		s.args = []interface{}{taggingType["poll"], tagID, pollActive}
+	}
+	return s
+}
+
+// pnrQuery selects resource type, id and time of items in the page.
+// Each type is sorted and limited before union, so that only the first items of each type are read.
+func (a *GetPostReportArgs) pnrQuery() (query string, values []interface{}) {
+
+	field, order, cmp := strings.TrimPrefix(a.Sorting, "-"), "ASC", ">"
+	if strings.HasPrefix(a.Sorting, "-") {
+		order, cmp = "DESC", "<"
+	}
+	// Page is still supported without cursor, though deep pages are slow
+	var offset int
+	if a.cursor == nil && a.Page > 1 {
+		offset = (a.Page - 1) * a.MaxResult
+	}
+	limit := offset + a.MaxResult + 1
+
+	selects := make([]string, 0, len(a.Types))
+	for _, t := range a.Types {
+		s := newPNRSource(t, field, a.TagID)
+		where := []string{s.at + " IS NOT NULL"}
+		values = append(values, s.args...)
+		if a.Filter != (Filter{}) {
+			operator := a.Filter.Operator
+			if operator == "==" {
+				operator = "="
+			}
+			where = append(where, fmt.Sprintf("%s %s ?", s.at, operator))
+			values = append(values, a.Filter.Condition)
+		}
+		if a.cursor != nil {
+			// Items of the same time and id are ordered by resource type,
+			// so the item of cursor id is included for types after the cursor type.
+			idCmp := cmp
+			if (order == "DESC" && t < a.cursor.Type) || (order == "ASC" && t > a.cursor.Type) {
+				idCmp += "="
+			}
+			where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", s.at, cmp, s.at, s.id, idCmp))
+			values = append(values, a.cursor.At, a.cursor.At, a.cursor.ID)
+		}
+		selects = append(selects, fmt.Sprintf("(SELECT '%s' AS resource_type, %s AS id, %s AS pnr_at %s AND %s ORDER BY pnr_at %s, id %s LIMIT %d)",
+			t, s.id, s.at, s.from, strings.Join(where, " AND "), order, order, limit))
+	}
+	query = fmt.Sprintf("%s ORDER BY pnr_at %s, id %s, resource_type %s LIMIT %d OFFSET %d;",
+		strings.Join(selects, " UNION ALL "), order, order, order, a.MaxResult+1, offset)
+	return query, values
+}
+
+// GetPostReport returns at most MaxResult + 1 items, the last of which tells there are items left
+func (t *tagApi) GetPostReport(args *GetPostReportArgs) (results []LastPNRInterface, err error) {
+
+	query, values := args.pnrQuery()
+	keys := []struct {
+		ResourceType string         `db:"resource_type"`
+		ID           int            `db:"id"`
+		PNRAt        rrsql.NullTime `db:"pnr_at"`
+	}{}
+	if err = rrsql.DB.Select(&keys, query, values...); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string][]int)
+	for _, k := range keys {
+		ids[k.ResourceType] = append(ids[k.ResourceType], k.ID)
+	}
+	items := make(map[string]map[int]LastPNRInterface)
+	for resourceType, list := range ids {
+		if items[resourceType], err = getPNRItems(resourceType, list); err != nil {
+			return nil, err
+		}
+	}
+
+	// Items removed in between are left out
+	results = make([]LastPNRInterface, 0, len(keys))
+	for _, k := range keys {
+		if item, ok := items[k.ResourceType][k.ID]; ok {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+func getPNRItems(resourceType string, ids []int) (items map[int]LastPNRInterface, err error) {
+
+	items = make(map[int]LastPNRInterface, len(ids))
+	switch resourceType {
+	case "post":
+		postIDs := make([]uint32, len(ids))
+		for i, id := range ids {
+			postIDs[i] = uint32(id)
+		}
+		var posts []TaggedPostMember
+		posts, err = PostAPI.GetPosts(NewPostArgs(func(args *PostArgs) {
+			args.MaxResult = uint8(len(ids))
+			args.IDs = postIDs
+			args.ProjectID = -1
+			args.Active = map[string][]int{"$nin": []int{config.Config.Models.Posts["deactive"]}}
+			args.ShowAuthor = true
+			args.ShowCard = true
+			args.ShowCommment = true
+			args.ShowTag = true
+			args.ShowUpdater = true
+		}))
+		if err != nil {
+			return nil, errors.New("Unable to get tagged posts")
+		}
+		for _, post := range posts {
+			items[int(post.ID)] = post
+		}
+	case "report":
+		var reports []ReportAuthors
+		reports, err = ReportAPI.GetReports(*NewGetReportArgs(func(args *GetReportArgs) {
+			args.IDs = ids
+			args.MaxResult = len(ids)
+			args.Fields = []string{"nickname"}
+		}))
+		if err != nil {
+			return nil, errors.New("Unable to get tagged reports")
+		}
+		for _, report := range reports {
+			items[int(report.ID)] = report
+		}
+	case "project":
+		arg := GetProjectArgs{}
+		arg.Default()
+		arg.IDs = ids
+		arg.Fields = arg.FullAuthorTags()
+		arg.MaxResult = len(ids)
+		var projects []ProjectAuthors
+		if projects, err = ProjectAPI.GetProjects(arg); err != nil {
+			return nil, errors.New("Unable to get tagged projects")
+		}
+		for _, project := range projects {
+			items[project.ID] = project
+		}
+	case "card":
+		fields := rrsql.MakeFieldString("get", `newscards.%s "%s"`, rrsql.GetStructDBTags("full", cards.NewsCard{}))
+		var list []TaggedCard
+		if err = selectIn(&list, fmt.Sprintf(`SELECT %s, posts.published_at "published_at" FROM newscards
+			INNER JOIN posts ON posts.post_id = newscards.post_id WHERE newscards.id IN (?);`, strings.Join(fields, ",")), ids); err != nil {
+			return nil, errors.New("Unable to get tagged cards")
+		}
+		for _, card := range list {
+			items[int(card.ID)] = card
+		}
+	case "poll":
+		var list []TaggedPoll
+		if err = selectIn(&list, fmt.Sprintf(`SELECT %s FROM polls WHERE id IN (?);`,
+			strings.Join(rrsql.GetStructDBTags("full", TaggedPoll{}), ",")), ids); err != nil {
+			return nil, errors.New("Unable to get tagged polls")
+		}
+		for _, poll := range list {
+			items[int(poll.ID)] = poll
+		}
+	}
+	return items, nil
+}
+
+func selectIn(dest interface{}, query string, ids []int) error {
+
+	query, args, err := sqlx.In(query, ids)
+	if err != nil {
+		return err
+	}
+	return rrsql.DB.Select(dest, rrsql.DB.Rebind(query), args...)
+}
+
+// TaggedCard is a card listed in /tags/pnr, which is published along with its post
+type TaggedCard struct {
+	cards.NewsCard
+	PublishedAt rrsql.NullTime `json:"published_at" db:"published_at"`
+}
+
+func (tc TaggedCard) ReturnPublishedAt() time.Time {
+	return nullTimeValue(tc.PublishedAt)
+}
+
+func (tc TaggedCard) ReturnCreatedAt() time.Time {
+	return nullTimeValue(tc.CreatedAt)
+}
+
+func (tc TaggedCard) ReturnUpdatedAt() time.Time {
+	return nullTimeValue(tc.UpdatedAt)
+}
+
+func (tc TaggedCard) ReturnID() int { return int(tc.ID) }
+
+func (tc TaggedCard) ReturnResourceType() string { return "card" }
+
+// TaggedPoll is a poll listed in /tags/pnr.
+// Polls are defined in pkg/poll, which depends on this package.
+type TaggedPoll struct {
+	ID          int64            `json:"id" db:"id"`
+	Status      int64            `json:"status" db:"status"`
+	Active      int64            `json:"active" db:"active"`
+	Title       rrsql.NullString `json:"title" db:"title"`
+	Description rrsql.NullString `json:"description" db:"description"`
+	TotalVote   int64            `json:"total_vote" db:"total_vote"`
+	Frequency   rrsql.NullInt    `json:"frequency" db:"frequency"`
+	StartAt     rrsql.NullTime   `json:"start_at" db:"start_at"`
+	EndAt       rrsql.NullTime   `json:"end_at" db:"end_at"`
+	MaxChoice   int64            `json:"max_choice" db:"max_choice"`
+	Changeable  int64            `json:"changeable" db:"changeable"`
+	PublishedAt rrsql.NullTime   `json:"published_at" db:"published_at"`
+	CreatedAt   rrsql.NullTime   `json:"created_at" db:"created_at"`
+	CreatedBy   rrsql.NullInt    `json:"created_by" db:"created_by"`
+	UpdatedAt   rrsql.NullTime   `json:"updated_at" db:"updated_at"`
+	UpdatedBy   rrsql.NullInt    `json:"updated_by" db:"updated_by"`
+}
+
+func (tp TaggedPoll) ReturnPublishedAt() time.Time {
+	return nullTimeValue(tp.PublishedAt)
+}
+
+func (tp TaggedPoll) ReturnCreatedAt() time.Time {
+	return nullTimeValue(tp.CreatedAt)
+}
+
+func (tp TaggedPoll) ReturnUpdatedAt() time.Time {
+	return nullTimeValue(tp.UpdatedAt)
+}
+
+func (tp TaggedPoll) ReturnID() int { return int(tp.ID) }
+
+func (tp TaggedPoll) ReturnResourceType() string { return "poll" }
+
+func nullTimeValue(t rrsql.NullTime) time.Time {
+	if t.Valid {
+		return t.Time
+	}
+	return time.Time{}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPNRCursor(t *testing.T) {
+
+	at := time.Date(2018, 5, 17, 2, 54, 25, 0, time.UTC)
+	poll := TaggedPoll{ID: 2, PublishedAt: rrsql.NullTime{Time: at, Valid: true}}
+
+	args := NewGetPostReportArgs(func(args *GetPostReportArgs) {
+		args.Cursor = EncodePNRCursor(args.Sorting, poll)
+	})
+	assert.Nil(t, args.ValidateGet())
+	assert.Equal(t, &pnrCursor{At: at, Type: "poll", ID: 2}, args.cursor)
+
+	for _, cursor := range []string{"abc", "eyJ0eXBlIjoibWVtbyJ9"} {
+		args.Cursor = cursor
+		assert.EqualError(t, args.ValidateGet(), "Invalid Cursor")
+	}
+	args.Cursor, args.Types = "", []string{"post", "memo"}
+	assert.EqualError(t, args.ValidateGet(), "Invalid Types")
+}
+
+func TestPNRQuery(t *testing.T) {
+
+	at := time.Date(2018, 5, 17, 2, 54, 25, 0, time.UTC)
+	args := NewGetPostReportArgs(func(args *GetPostReportArgs) {
+		args.TagID = 1
+		args.MaxResult = 10
+		args.Page = 3
+		args.Types = []string{"card", "poll", "post"}
+	})
+
+	// Page is used without cursor
+	query, values := args.pnrQuery()
+	assert.True(t, strings.HasSuffix(query, "ORDER BY pnr_at DESC, id DESC, resource_type DESC LIMIT 11 OFFSET 20;"))
+	assert.Equal(t, 3, strings.Count(query, "LIMIT 31)"))
+	assert.Contains(t, query, "posts.published_at AS pnr_at FROM newscards")
+	assert.Equal(t, strings.Count(query, "?"), len(values))
+
+	// Items of cursor time and id are left only for types after cursor type
+	args.cursor = &pnrCursor{At: at, Type: "poll", ID: 5}
+	query, values = args.pnrQuery()
+	assert.True(t, strings.HasSuffix(query, "LIMIT 11 OFFSET 0;"))
+	assert.Contains(t, query, "(posts.published_at < ? OR (posts.published_at = ? AND newscards.id <= ?))")
+	assert.Contains(t, query, "(polls.published_at < ? OR (polls.published_at = ? AND polls.id < ?))")
+	assert.Contains(t, query, "(posts.published_at < ? OR (posts.published_at = ? AND posts.post_id < ?))")
+	assert.Equal(t, strings.Count(query, "?"), len(values))
+	assert.Equal(t, []interface{}{at, at, 5}, values[len(values)-3:])
+
+	args.Sorting = "updated_at"
+	query, _ = args.pnrQuery()
+	assert.Contains(t, query, "(newscards.updated_at > ? OR (newscards.updated_at = ? AND newscards.id > ?))")
+	assert.Contains(t, query, "(posts.updated_at > ? OR (posts.updated_at = ? AND posts.post_id >= ?))")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY pnr_at ASC, id ASC, resource_type ASC LIMIT 11 OFFSET 0;"))
+}
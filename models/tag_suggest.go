@@ -0,0 +1,459 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Tags are suggested by a model built from published posts and tagging, which is held in memory until rebuilt.
+// Texts are segmented by forward maximum matching against tags and words of dictionary, and the rest of Chinese
+// characters are cut into bigrams and trigrams. Tags found in text are ranked by TF-IDF, and those often tagged
+// together with them are suggested as well. Other segments frequent in posts are suggested as new keywords.
+
+type SuggestTagsArgs struct {
+	PostID    int64  `json:"post_id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	MaxResult int    `json:"max_result"`
+}
+
+type TagSuggestion struct {
+	ID    int     `json:"id"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+type KeywordSuggestion struct {
+	Keyword string  `json:"keyword"`
+	Score   float64 `json:"score"`
+}
+
+type TagSuggestions struct {
+	Tags     []TagSuggestion     `json:"tags"`
+	Keywords []KeywordSuggestion `json:"keywords"`
+}
+
+type TagSuggestInterface interface {
+	Build() error
+	Suggest(args SuggestTagsArgs) (TagSuggestions, error)
+}
+
+const (
+	// titleWeight is how many times segments in title are counted
+	titleWeight = 2
+	// cooccurWeight scales scores passed to tags often tagged together
+	cooccurWeight = 0.5
+)
+
+// suggestStopChars are characters which segments are unlikely to begin or end with
+var suggestStopChars = map[rune]bool{
+	'的': true, '了': true, '是': true, '在': true, '和': true, '與': true, '及': true, '也': true, '就': true,
+	'都': true, '而': true, '之': true, '或': true, '這': true, '那': true, '有': true, '為': true, '我': true,
+	'你': true, '他': true, '她': true, '們': true, '不': true, '但': true, '並': true, '被': true, '將': true,
+	'於': true, '以': true, '其': true, '中': true, '對': true, '到': true, '從': true, '等': true, '個': true,
+}
+
+var suggestStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true, "from": true,
+	"are": true, "was": true, "were": true, "has": true, "have": true, "not": true, "but": true,
+	"http": true, "https": true, "www": true, "com": true,
+}
+
+type tagSuggestModel struct {
+	docs int
+	df   map[string]int
+	// dict maps lower-cased text of tags and aliases to canonical tags
+	dict map[string]int
+	// words are lower-cased words of dictionary other than tags
+	words    map[string]bool
+	maxLen   int
+	tags     map[int]string
+	tagCount map[int]int
+	cooccur  map[int]map[int]int
+}
+
+func newTagSuggestModel(tags []Tag, words []string) *tagSuggestModel {
+
+	m := &tagSuggestModel{
+		df:       make(map[string]int),
+		dict:     make(map[string]int),
+		words:    make(map[string]bool),
+		tags:     make(map[int]string),
+		tagCount: make(map[int]int),
+		cooccur:  make(map[int]map[int]int),
+	}
+	for _, tag := range tags {
+		id := tag.ID
+		if tag.AliasOf.Valid {
+			id = int(tag.AliasOf.Int)
+		} else {
+			m.tags[id] = tag.Text
+		}
+		text := strings.ToLower(strings.TrimSpace(tag.Text))
+		if n := len([]rune(text)); n > 0 {
+			m.dict[text] = id
+			if n > m.maxLen {
+				m.maxLen = n
+			}
+		}
+	}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if _, ok := m.dict[word]; ok {
+			continue
+		}
+		if n := len([]rune(word)); n > 0 {
+			m.words[word] = true
+			if n > m.maxLen {
+				m.maxLen = n
+			}
+		}
+	}
+	return m
+}
+
+// loadSuggestDictionary reads words from file at path, one word in the first field of each line,
+// so that dictionaries of jieba in "word freq tag" are read as well
+func loadSuggestDictionary(path string) ([]string, error) {
+
+	words := make([]string, 0)
+	if path == "" {
+		return words, nil
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			words = append(words, fields[0])
+		}
+	}
+	return words, nil
+}
+
+// addDoc counts document frequency of segments in text
+func (m *tagSuggestModel) addDoc(text string) {
+	m.docs++
+	seen := make(map[string]bool)
+	for _, token := range m.segment(text) {
+		if !seen[token] {
+			seen[token] = true
+			m.df[token]++
+		}
+	}
+}
+
+// addTagging counts tags tagged together on a resource
+func (m *tagSuggestModel) addTagging(tagIDs []int) {
+	for _, a := range tagIDs {
+		m.tagCount[a]++
+		for _, b := range tagIDs {
+			if a == b {
+				continue
+			}
+			if m.cooccur[a] == nil {
+				m.cooccur[a] = make(map[int]int)
+			}
+			m.cooccur[a][b]++
+		}
+	}
+}
+
+// prune drops segments in less than minDF documents, except tags
+func (m *tagSuggestModel) prune(minDF int) {
+	for token, df := range m.df {
+		if _, ok := m.dict[token]; !ok && df < minDF {
+			delete(m.df, token)
+		}
+	}
+}
+
+func isWordRune(r rune) bool {
+	return !unicode.Is(unicode.Han, r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// match returns length of the longest tag or word of dictionary at i of runes.
+// They are not matched within words of letters.
+func (m *tagSuggestModel) match(runes []rune, i int) int {
+	for n := m.maxLen; n > 0; n-- {
+		if i+n > len(runes) {
+			continue
+		}
+		token := string(runes[i : i+n])
+		if _, ok := m.dict[token]; !ok && !m.words[token] {
+			continue
+		}
+		if isWordRune(runes[i]) && i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+		if isWordRune(runes[i+n-1]) && i+n < len(runes) && isWordRune(runes[i+n]) {
+			continue
+		}
+		return n
+	}
+	return 0
+}
+
+// segment cuts lower-cased text into tags, words of dictionary, words of letters,
+// and bigrams and trigrams of Chinese characters matching none of tags and words.
+// Without a dictionary, new Chinese keywords could only be found in those n-grams.
+func (m *tagSuggestModel) segment(text string) (tokens []string) {
+
+	runes := []rune(strings.ToLower(text))
+	var span, word []rune
+	flush := func() {
+		for n := 2; n <= 3; n++ {
+			for i := 0; i+n <= len(span); i++ {
+				tokens = append(tokens, string(span[i:i+n]))
+			}
+		}
+		if len(word) >= 2 {
+			tokens = append(tokens, string(word))
+		}
+		span, word = span[:0], word[:0]
+	}
+
+	for i := 0; i < len(runes); {
+		if n := m.match(runes, i); n > 0 {
+			flush()
+			tokens = append(tokens, string(runes[i:i+n]))
+			i += n
+			continue
+		}
+		switch r := runes[i]; {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			span = append(span, r)
+		case isWordRune(r):
+			if len(span) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+		i++
+	}
+	flush()
+	return tokens
+}
+
+func (m *tagSuggestModel) idf(token string) float64 {
+	return math.Log(float64(m.docs+1)/float64(m.df[token]+1)) + 1
+}
+
+// isKeyword tells whether token not being a tag is worth suggesting
+func (m *tagSuggestModel) isKeyword(token string) bool {
+	if _, ok := m.dict[token]; ok || m.df[token] == 0 || suggestStopWords[token] {
+		return false
+	}
+	runes := []rune(token)
+	if suggestStopChars[runes[0]] || suggestStopChars[runes[len(runes)-1]] {
+		return false
+	}
+	return strings.IndexFunc(token, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*10000) / 10000
+}
+
+func (m *tagSuggestModel) suggest(title string, content string, max int) (result TagSuggestions) {
+
+	tf := make(map[string]int)
+	total := 0
+	for _, token := range m.segment(title) {
+		tf[token] += titleWeight
+		total += titleWeight
+	}
+	for _, token := range m.segment(content) {
+		tf[token]++
+		total++
+	}
+
+	result = TagSuggestions{Tags: []TagSuggestion{}, Keywords: []KeywordSuggestion{}}
+	if total == 0 {
+		return result
+	}
+
+	direct := make(map[int]float64)
+	keywords := make(map[string]float64)
+	for token, count := range tf {
+		score := float64(count) / float64(total) * m.idf(token)
+		if id, ok := m.dict[token]; ok {
+			direct[id] += score
+		} else if m.isKeyword(token) {
+			keywords[token] = score
+		}
+	}
+
+	scores := make(map[int]float64)
+	for id, score := range direct {
+		scores[id] += score
+		for other, count := range m.cooccur[id] {
+			scores[other] += score * cooccurWeight * float64(count) / float64(m.tagCount[id])
+		}
+	}
+	for id, score := range scores {
+		if text, ok := m.tags[id]; ok {
+			result.Tags = append(result.Tags, TagSuggestion{ID: id, Text: text, Score: roundScore(score)})
+		}
+	}
+	sort.Slice(result.Tags, func(i, j int) bool {
+		a, b := result.Tags[i], result.Tags[j]
+		return a.Score > b.Score || a.Score == b.Score && a.ID < b.ID
+	})
+	if len(result.Tags) > max {
+		result.Tags = result.Tags[:max]
+	}
+
+	// Of keywords overlapped, the shorter one is kept if it is more frequent in posts,
+	// otherwise the longer one is, unless the shorter one is also found elsewhere in text
+	dropped := make(map[string]bool)
+	for token := range keywords {
+		for other := range keywords {
+			if len(other) <= len(token) || !strings.Contains(other, token) {
+				continue
+			}
+			if m.df[token] > m.df[other] {
+				dropped[other] = true
+			} else if tf[other] >= tf[token] {
+				dropped[token] = true
+			}
+		}
+	}
+	for token := range dropped {
+		delete(keywords, token)
+	}
+	for keyword, score := range keywords {
+		result.Keywords = append(result.Keywords, KeywordSuggestion{Keyword: keyword, Score: roundScore(score)})
+	}
+	sort.Slice(result.Keywords, func(i, j int) bool {
+		a, b := result.Keywords[i], result.Keywords[j]
+		return a.Score > b.Score || a.Score == b.Score && a.Keyword < b.Keyword
+	})
+	if len(result.Keywords) > max {
+		result.Keywords = result.Keywords[:max]
+	}
+	return result
+}
+
+// plainText strips html of content
+func plainText(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	return doc.Text()
+}
+
+type tagSuggestAPI struct {
+	mu    sync.RWMutex
+	model *tagSuggestModel
+}
+
+// Build rebuilds the model from active tags, tagging and the latest max_docs published posts
+func (a *tagSuggestAPI) Build() error {
+
+	var tags []Tag
+	if err := rrsql.DB.Select(&tags, `SELECT * FROM tags WHERE active = ?;`, config.Config.Models.Tags["active"]); err != nil {
+		return err
+	}
+	words, err := loadSuggestDictionary(config.Config.TagSuggest.Dictionary)
+	if err != nil {
+		return err
+	}
+	m := newTagSuggestModel(tags, words)
+
+	rows, err := rrsql.DB.Queryx(`SELECT GROUP_CONCAT(IFNULL(t.alias_of, t.tag_id)) FROM tagging AS tg
+		INNER JOIN tags AS t ON t.tag_id = tg.tag_id WHERE t.active = ? GROUP BY tg.type, tg.target_id;`, config.Config.Models.Tags["active"])
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var ids string
+		if err = rows.Scan(&ids); err != nil {
+			rows.Close()
+			return err
+		}
+		m.addTagging(parseIntSlice(ids))
+	}
+	rows.Close()
+
+	maxDocs := config.Config.TagSuggest.MaxDocs
+	if maxDocs <= 0 {
+		maxDocs = 20000
+	}
+	rows, err = rrsql.DB.Queryx(`SELECT IFNULL(title, ''), IFNULL(content, '') FROM posts WHERE active = ? AND publish_status = ? ORDER BY published_at DESC LIMIT ?;`,
+		config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"], maxDocs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var title, content string
+		if err = rows.Scan(&title, &content); err != nil {
+			return err
+		}
+		m.addDoc(title + "\n" + plainText(content))
+	}
+	m.prune(config.Config.TagSuggest.MinDF)
+
+	a.mu.Lock()
+	a.model = m
+	a.mu.Unlock()
+	log.Printf("Tag suggestion model built with %d posts and %d segments\n", m.docs, len(m.df))
+	return nil
+}
+
+// Suggest ranks existing tags and new keywords for title and content, or those of published post if post_id is given
+func (a *tagSuggestAPI) Suggest(args SuggestTagsArgs) (TagSuggestions, error) {
+
+	a.mu.RLock()
+	m := a.model
+	a.mu.RUnlock()
+	if m == nil {
+		return TagSuggestions{}, errors.New("Suggestion Model Not Ready")
+	}
+
+	if args.PostID > 0 {
+		var post struct {
+			Title   string `db:"title"`
+			Content string `db:"content"`
+		}
+		err := rrsql.DB.Get(&post, `SELECT IFNULL(title, '') AS title, IFNULL(content, '') AS content FROM posts WHERE post_id = ? AND active = ? AND publish_status = ?;`,
+			args.PostID, config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"])
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return TagSuggestions{}, rrsql.ItemNotFoundError
+			}
+			return TagSuggestions{}, err
+		}
+		args.Title, args.Content = post.Title, post.Content
+	}
+
+	max := args.MaxResult
+	if max <= 0 {
+		max = config.Config.TagSuggest.MaxResult
+	}
+	if max <= 0 {
+		max = 10
+	}
+	return m.suggest(args.Title, plainText(args.Content), max), nil
+}
+
+var TagSuggestAPI TagSuggestInterface = new(tagSuggestAPI)
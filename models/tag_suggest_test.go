@@ -0,0 +1,97 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTagSuggestModel() *tagSuggestModel {
+
+	m := newTagSuggestModel([]Tag{
+		{ID: 1, Text: "健保"},
+		{ID: 2, Text: "全民健保", AliasOf: rrsql.NullInt{Int: 1, Valid: true}},
+		{ID: 3, Text: "長照"},
+		{ID: 4, Text: "AI"},
+		{ID: 5, Text: "衛福部"},
+	}, []string{"費率", "調整", "宣布", "總額", "協商", "結果", "公布", "政策", "財務", "藥價", "醫療", "應用", "選舉", "出爐", "名單", "健保"})
+	for _, tagging := range [][]int{{1, 5}, {1, 5}, {1, 3}, {3}} {
+		m.addTagging(tagging)
+	}
+	for _, doc := range []string{
+		"衛福部宣布健保費率調整",
+		"健保總額協商結果公布",
+		"長照政策與健保財務",
+		"健保藥價調整結果公布",
+		"AI 醫療應用",
+		"選舉結果出爐",
+		"名單公布",
+	} {
+		m.addDoc(doc)
+	}
+	m.prune(2)
+	return m
+}
+
+func TestTagSuggestSegment(t *testing.T) {
+
+	m := newTestTagSuggestModel()
+	assert.Equal(t, []string{"全民健保", "費率", "調整"}, m.segment("全民健保費率調整"))
+	// Characters out of dictionary are cut into bigrams and trigrams
+	assert.Equal(t, []string{"健保", "今天", "天再", "再度", "今天再", "天再度", "調整"}, m.segment("健保今天再度調整"))
+	// Tags are not matched within words
+	assert.Equal(t, []string{"ai", "paid", "醫療"}, m.segment("AI, paid 醫療"))
+}
+
+func TestTagSuggestWithoutDictionary(t *testing.T) {
+
+	m := newTagSuggestModel([]Tag{{ID: 1, Text: "健保"}}, nil)
+	for _, doc := range []string{"健保費率", "調降費率", "費率公告"} {
+		m.addDoc(doc)
+	}
+	m.prune(2)
+
+	// New keywords are still found in n-grams of characters out of tags
+	result := m.suggest("", "健保費率", 3)
+	assert.Equal(t, []TagSuggestion{{1, "健保", 0.8466}}, result.Tags)
+	assert.Equal(t, []KeywordSuggestion{{"費率", 0.5}}, result.Keywords)
+}
+
+func TestLoadSuggestDictionary(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "dictionary")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dict.txt")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("費率 3 n\n調整\n\n  結果  \n"), 0644))
+
+	words, err := loadSuggestDictionary(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"費率", "調整", "結果"}, words)
+
+	words, err = loadSuggestDictionary("")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, words)
+
+	_, err = loadSuggestDictionary(filepath.Join(dir, "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestTagSuggest(t *testing.T) {
+
+	m := newTestTagSuggestModel()
+	result := m.suggest("全民健保費率調整", "健保費率調整結果公布", 10)
+
+	// Alias is suggested as its canonical tag, followed by tags tagged together with it
+	assert.Equal(t, []TagSuggestion{{1, "健保", 0.6935}, {5, "衛福部", 0.2312}, {3, "長照", 0.1156}}, result.Tags)
+	// Words in less than min_df posts such as 費率 are left out
+	assert.Equal(t, []KeywordSuggestion{{"調整", 0.5402}, {"公布", 0.1539}, {"結果", 0.1539}}, result.Keywords)
+
+	result = m.suggest("", "長照", 1)
+	assert.Equal(t, []TagSuggestion{{3, "長照", 2.3863}}, result.Tags)
+	assert.Equal(t, []KeywordSuggestion{}, result.Keywords)
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// Tags form a tree of topic, subtopic and entity by parent_id.
+// tag_closure keeps every ancestor of tags with depth, including tags themselves at depth 0,
+// so that content of a tag and its descendants could be queried without recursion.
+
+// TagTree is a tag with its descendants. Ancestors are listed from the root, and only set for the tag requested.
+type TagTree struct {
+	Tag
+	Ancestors []Tag      `json:"ancestors,omitempty"`
+	Children  []*TagTree `json:"children"`
+}
+
+// tagDescendants is the subquery of tags under a tag, including itself
+const tagDescendants = `SELECT descendant_id FROM tag_closure WHERE ancestor_id = ?`
+
+// tagParentStmts validates parent of tag, and returns statements moving tag along with its descendants under parent.
+// Tag becomes a root if parent is 0. Tag is inserted into tag_closure first if isNew,
+// in which case tagID is expected to be the transaction ID placeholder.
+func tagParentStmts(tx *sqlx.Tx, tagID string, parentID int64, isNew bool) (stmts []*rrsql.PipelineStmt, err error) {
+
+	if parentID > 0 {
+		var parent Tag
+		if err = tx.Get(&parent, fmt.Sprintf(`SELECT * FROM tags WHERE tag_id = ? AND active = %d;`, config.Config.Models.Tags["active"]), parentID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.New("Invalid Parent")
+			}
+			return nil, err
+		}
+		if parent.AliasOf.Valid {
+			return nil, errors.New("Invalid Parent")
+		}
+		if !isNew {
+			// Tag could not be moved under itself or its descendants
+			var count int
+			if err = tx.Get(&count, fmt.Sprintf(`SELECT COUNT(*) FROM tag_closure WHERE ancestor_id = %s AND descendant_id = ?;`, tagID), parentID); err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, errors.New("Invalid Parent")
+			}
+		}
+	}
+
+	if isNew {
+		stmts = append(stmts, &rrsql.PipelineStmt{
+			Query: fmt.Sprintf(`INSERT INTO tag_closure (ancestor_id, descendant_id, depth) VALUES (%s, %s, 0);`, tagID, tagID),
+		})
+	} else {
+		// Paths from ancestors of tag to its descendants are cut
+		stmts = append(stmts, &rrsql.PipelineStmt{
+			Query: fmt.Sprintf(`DELETE c FROM tag_closure AS c
+				INNER JOIN tag_closure AS a ON a.ancestor_id = c.ancestor_id AND a.descendant_id = %s AND a.depth > 0
+				INNER JOIN tag_closure AS d ON d.descendant_id = c.descendant_id AND d.ancestor_id = %s;`, tagID, tagID),
+		})
+	}
+	if parentID > 0 {
+		stmts = append(stmts, &rrsql.PipelineStmt{
+			Query: fmt.Sprintf(`INSERT INTO tag_closure (ancestor_id, descendant_id, depth)
+				SELECT a.ancestor_id, d.descendant_id, a.depth + d.depth + 1 FROM tag_closure AS a
+				CROSS JOIN tag_closure AS d WHERE a.descendant_id = ? AND d.ancestor_id = %s;`, tagID),
+			Args: []interface{}{parentID},
+		})
+	}
+	return stmts, nil
+}
+
+// GetTagTree returns active tag of id with its ancestors and active descendants
+func (t *tagApi) GetTagTree(id int) (tree TagTree, err error) {
+
+	active := config.Config.Models.Tags["active"]
+	err = rrsql.DB.Get(&tree.Tag, `SELECT * FROM tags WHERE tag_id = ? AND active = ? AND alias_of IS NULL;`, id, active)
+	if err == sql.ErrNoRows {
+		return tree, rrsql.ItemNotFoundError
+	} else if err != nil {
+		return tree, err
+	}
+
+	if err = rrsql.DB.Select(&tree.Ancestors, `SELECT t.* FROM tag_closure AS c INNER JOIN tags AS t ON t.tag_id = c.ancestor_id
+		WHERE c.descendant_id = ? AND c.depth > 0 ORDER BY c.depth DESC;`, id); err != nil {
+		return tree, err
+	}
+
+	var descendants []Tag
+	if err = rrsql.DB.Select(&descendants, `SELECT t.* FROM tag_closure AS c INNER JOIN tags AS t ON t.tag_id = c.descendant_id
+		WHERE c.ancestor_id = ? AND c.depth > 0 AND t.active = ? AND t.alias_of IS NULL ORDER BY c.depth, t.tag_content;`, id, active); err != nil {
+		return tree, err
+	}
+
+	// Parents come before children in order of depth. Children of inactive tags are left out.
+	tree.Children = []*TagTree{}
+	nodes := map[int]*TagTree{id: &tree}
+	for _, tag := range descendants {
+		parent, ok := nodes[int(tag.ParentID.Int)]
+		if !ok {
+			continue
+		}
+		node := &TagTree{Tag: tag, Children: []*TagTree{}}
+		parent.Children = append(parent.Children, node)
+		nodes[tag.ID] = node
+	}
+	return tree, nil
+}
@@ -2,7 +2,6 @@ package models
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -10,15 +9,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"database/sql"
 	"encoding/json"
 
 	"github.com/garyburd/redigo/redis"
-	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
-	"github.com/olivere/elastic"
 	"github.com/readr-media/readr-restful/config"
 	"github.com/readr-media/readr-restful/internal/rrsql"
 	"github.com/readr-media/readr-restful/utils"
@@ -34,6 +30,9 @@ type Tag struct {
 	RelatedReviews  rrsql.NullInt  `json:"related_reviews" db:"related_reviews"`
 	RelatedNews     rrsql.NullInt  `json:"related_news" db:"related_news"`
 	RelatedProjects rrsql.NullInt  `json:"related_projects" db:"related_projects"`
+	// AliasOf is the canonical tag which this tag is merged into
+	AliasOf  rrsql.NullInt `json:"alias_of" db:"alias_of"`
+	ParentID rrsql.NullInt `json:"parent_id" db:"parent_id"`
 }
 
 type TagInterface interface {
@@ -46,6 +45,8 @@ type TagInterface interface {
 	GetHotTags() ([]TagRelatedResources, error)
 	UpdateHotTags() error
 	GetPostReport(args *GetPostReportArgs) ([]LastPNRInterface, error)
+	MergeTags(args MergeTagsArgs) error
+	GetTagTree(id int) (TagTree, error)
 }
 
 type tagApi struct{}
@@ -74,6 +75,9 @@ func (t *tagApi) ToggleTags(args UpdateMultipleTagsArgs) error {
 		return err
 	}
 
+	if active, err := strconv.ParseFloat(args.Active, 64); err == nil && int(active) == config.Config.Models.Tags["deactive"] {
+		recordTrash("tag", args.IDs)
+	}
 	return nil
 }
 
@@ -206,21 +210,22 @@ func (t *tagApi) GetTags(args GetTagsArgs) (tags []TagRelatedResources, err erro
 	}
 
 	if args.TaggingType == 0 {
-		query.WriteString(fmt.Sprintf(` WHERE ta.active=%d `, config.Config.Models.Tags["active"]))
+		query.WriteString(fmt.Sprintf(` WHERE ta.active=%d AND ta.alias_of IS NULL `, config.Config.Models.Tags["active"]))
 	} else {
-		query.WriteString(fmt.Sprintf(` LEFT JOIN tagging AS tg ON ta.tag_id = tg.tag_id WHERE ta.active=%d AND tg.type = ?`, config.Config.Models.Tags["active"]))
+		query.WriteString(fmt.Sprintf(` LEFT JOIN tagging AS tg ON ta.tag_id = tg.tag_id WHERE ta.active=%d AND ta.alias_of IS NULL AND tg.type = ?`, config.Config.Models.Tags["active"]))
 		queryArgs = append(queryArgs, args.TaggingType)
 	}
 
+	// Aliases matched are resolved to their canonical tags
 	if args.Keyword != "" {
-		query.WriteString(` AND ta.tag_content LIKE ?`)
+		query.WriteString(` AND (ta.tag_content LIKE ? OR ta.tag_id IN (SELECT alias_of FROM tags WHERE alias_of IS NOT NULL AND tag_content LIKE ?))`)
 		args.Keyword = "%" + args.Keyword + "%"
-		queryArgs = append(queryArgs, args.Keyword)
+		queryArgs = append(queryArgs, args.Keyword, args.Keyword)
 	}
 
 	if len(args.IDs) > 0 {
-		query.WriteString(` AND ta.tag_id IN (?)`)
-		queryArgs = append(queryArgs, args.IDs)
+		query.WriteString(` AND (ta.tag_id IN (?) OR ta.tag_id IN (SELECT alias_of FROM tags WHERE tag_id IN (?)))`)
+		queryArgs = append(queryArgs, args.IDs, args.IDs)
 	}
 
 	if args.Sorting != "" {
@@ -390,6 +395,7 @@ func (t *tagApi) GetTags(args GetTagsArgs) (tags []TagRelatedResources, err erro
 	return tags, nil
 }
 
+// InsertTag returns ID of the canonical tag instead if text is an alias, so new content uses the canonical tag
 func (t *tagApi) InsertTag(tag Tag) (int, error) {
 	var existTag Tag
 	query := fmt.Sprint("SELECT * FROM tags WHERE active=", config.Config.Models.Tags["active"], " AND BINARY tag_content=?;")
@@ -397,25 +403,29 @@ func (t *tagApi) InsertTag(tag Tag) (int, error) {
 	if err != nil && err != sql.ErrNoRows {
 		return 0, err
 	}
+	if existTag.AliasOf.Valid {
+		return int(existTag.AliasOf.Int), nil
+	}
 	if existTag.ID > 0 {
 		return 0, rrsql.DuplicateError
 	}
 
-	query = fmt.Sprintf(`INSERT INTO tags (tag_content, updated_by) VALUES (?, ?);`)
-
-	result, err := rrsql.DB.Exec(query, tag.Text, tag.UpdatedBy)
-	if err != nil {
-		sqlerr, ok := err.(*mysql.MySQLError)
-		if ok && sqlerr.Number == 1062 {
-			return 0, rrsql.DuplicateError
-		} else {
-			return 0, err
+	var lastID int64
+	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		stmts, err := tagParentStmts(tx, config.Config.SQL.TrasactionIDPlaceholder, tag.ParentID.Int, true)
+		if err != nil {
+			return err
 		}
-	}
-
-	lastID, err := result.LastInsertId()
+		stmts = append([]*rrsql.PipelineStmt{&rrsql.PipelineStmt{
+			Query:        `INSERT INTO tags (tag_content, updated_by, parent_id) VALUES (?, ?, NULLIF(?, 0));`,
+			Args:         []interface{}{tag.Text, tag.UpdatedBy, tag.ParentID},
+			LastInsertId: true,
+		}}, stmts...)
+		lastID, _, err = rrsql.RunPipeline(tx, stmts...)
+		return err
+	})
 	if err != nil {
-		log.Printf("Fail to get last insert ID when insert a tag: %v", err)
+		return 0, err
 	}
 
 	return int(lastID), nil
@@ -442,27 +452,27 @@ func (t *tagApi) UpdateTag(tag Tag) error {
 
 	dbTags := rrsql.GetStructDBTags("partial", tag)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, dbTags)
+	for i, field := range dbTags {
+		// Tag with parent_id 0 is moved to root
+		if field == "parent_id" {
+			fields[i] = "parent_id = NULLIF(:parent_id, 0)"
+		}
+	}
 	query = fmt.Sprintf(`UPDATE tags SET %s WHERE tag_id = :tag_id`,
 		strings.Join(fields, ", "))
 
-	result, err := rrsql.DB.NamedExec(query, tag)
-	if err != nil {
-		sqlerr, ok := err.(*mysql.MySQLError)
-		if ok && sqlerr.Number == 1062 {
-			return rrsql.DuplicateError
-		} else {
-			return err
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+		stmts := []*rrsql.PipelineStmt{&rrsql.PipelineStmt{Query: query, NamedArgs: tag, NamedExec: true, RowsAffected: true}}
+		if tag.ParentID.Valid {
+			parentStmts, err := tagParentStmts(tx, strconv.Itoa(tag.ID), tag.ParentID.Int, false)
+			if err != nil {
+				return err
+			}
+			stmts = append(stmts, parentStmts...)
 		}
-	}
-
-	rowCnt, err := result.RowsAffected()
-	if rowCnt > 1 {
-		return rrsql.MultipleRowAffectedError
-	} else if rowCnt == 0 {
-		return rrsql.ItemNotFoundError
-	}
-
-	return nil
+		_, _, err := rrsql.RunPipeline(tx, stmts...)
+		return err
+	})
 }
 
 func updateTaggingStmts(resourceType int, targetID int, tagIDs []int) (stmts []*rrsql.PipelineStmt) {
@@ -482,8 +492,9 @@ func updateTaggingStmts(resourceType int, targetID int, tagIDs []int) (stmts []*
 		var insargs []interface{}
 		insqueryBuffer.WriteString("INSERT IGNORE INTO tagging (type, tag_id, target_id) VALUES ")
 		for index, tagID := range tagIDs {
-			insqueryBuffer.WriteString(fmt.Sprintf("( ?, ?, %s )", targetIDString))
-			insargs = append(insargs, resourceType, tagID)
+			// Aliases are tagged as their canonical tags
+			insqueryBuffer.WriteString(fmt.Sprintf("( ?, IFNULL((SELECT alias_of FROM tags WHERE tag_id = ?), ?), %s )", targetIDString))
+			insargs = append(insargs, resourceType, tagID, tagID)
 			if index < len(tagIDs)-1 {
 				insqueryBuffer.WriteString(",")
 			} else {
@@ -533,14 +544,127 @@ func (t *tagApi) UpdateTagging(resourceType int, targetID int, tagIDs []int) (er
 	return nil
 }
 
+type MergeTagsArgs struct {
+	Target    int           `json:"target"`
+	Sources   []int         `json:"sources"`
+	UpdatedBy rrsql.NullInt `json:"updated_by"`
+}
+
+func (a *MergeTagsArgs) Validate() error {
+	if a.Target <= 0 {
+		return errors.New("Invalid Target")
+	}
+	if len(a.Sources) == 0 {
+		return errors.New("Invalid Sources")
+	}
+	for _, id := range a.Sources {
+		if id <= 0 || id == a.Target {
+			return errors.New("Invalid Sources")
+		}
+	}
+	if !a.UpdatedBy.Valid {
+		return errors.New("Updater Not Sepcified")
+	}
+	return nil
+}
+
+// MergeTags moves tagging, follows and children of sources to target, and leaves sources as aliases of target.
+// Aliases of sources are pointed to target as well.
+func (t *tagApi) MergeTags(args MergeTagsArgs) error {
+
+	return rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		var target Tag
+		if err := tx.Get(&target, fmt.Sprintf(`SELECT * FROM tags WHERE tag_id = ? AND active = %d FOR UPDATE;`, config.Config.Models.Tags["active"]), args.Target); err != nil {
+			if err == sql.ErrNoRows {
+				return rrsql.ItemNotFoundError
+			}
+			return err
+		}
+		if target.AliasOf.Valid {
+			return errors.New("Invalid Target")
+		}
+
+		var count int
+		query, queryArgs, err := sqlx.In(`SELECT COUNT(*) FROM tags WHERE tag_id IN (?) FOR UPDATE;`, args.Sources)
+		if err != nil {
+			return err
+		}
+		if err = tx.Get(&count, tx.Rebind(query), queryArgs...); err != nil {
+			return err
+		}
+		if count != len(args.Sources) {
+			return rrsql.ItemNotFoundError
+		}
+
+		// Target could not be merged with its ancestors, whose children it would be moved under
+		query, queryArgs, err = sqlx.In(`SELECT COUNT(*) FROM tag_closure WHERE ancestor_id IN (?) AND descendant_id = ?;`, args.Sources, args.Target)
+		if err != nil {
+			return err
+		}
+		if err = tx.Get(&count, tx.Rebind(query), queryArgs...); err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("Invalid Target")
+		}
+
+		// Children of sources are moved under target along with their descendants
+		var children []int
+		query, queryArgs, err = sqlx.In(`SELECT tag_id FROM tags WHERE parent_id IN (?) AND tag_id NOT IN (?);`, args.Sources, args.Sources)
+		if err != nil {
+			return err
+		}
+		if err = tx.Select(&children, tx.Rebind(query), queryArgs...); err != nil {
+			return err
+		}
+		for _, child := range children {
+			stmts, err := tagParentStmts(tx, strconv.Itoa(child), int64(args.Target), false)
+			if err != nil {
+				return err
+			}
+			stmts = append([]*rrsql.PipelineStmt{&rrsql.PipelineStmt{
+				Query: `UPDATE tags SET parent_id = ? WHERE tag_id = ?;`,
+				Args:  []interface{}{args.Target, child},
+			}}, stmts...)
+			if _, _, err = rrsql.RunPipeline(tx, stmts...); err != nil {
+				return err
+			}
+		}
+
+		followType := config.Config.Models.FollowingType["tag"]
+		for _, stmt := range []struct {
+			query string
+			args  []interface{}
+		}{
+			// Rows already with target are dropped by INSERT IGNORE, and deleted with sources
+			{`INSERT IGNORE INTO tagging (type, tag_id, target_id, created_at) SELECT type, ?, target_id, created_at FROM tagging WHERE tag_id IN (?);`, []interface{}{args.Target, args.Sources}},
+			{`DELETE FROM tagging WHERE tag_id IN (?);`, []interface{}{args.Sources}},
+			{`INSERT IGNORE INTO following (type, member_id, target_id, emotion, created_at) SELECT type, member_id, ?, emotion, created_at FROM following WHERE type = ? AND target_id IN (?);`, []interface{}{args.Target, followType, args.Sources}},
+			{`DELETE FROM following WHERE type = ? AND target_id IN (?);`, []interface{}{followType, args.Sources}},
+			{`UPDATE tags SET alias_of = ? WHERE alias_of IN (?);`, []interface{}{args.Target, args.Sources}},
+			{`UPDATE tags SET alias_of = ?, updated_by = ?, updated_at = NOW() WHERE tag_id IN (?);`, []interface{}{args.Target, args.UpdatedBy, args.Sources}},
+		} {
+			query, queryArgs, err := sqlx.In(stmt.query, stmt.args...)
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Exec(tx.Rebind(query), queryArgs...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (a *tagApi) CountTags(args GetTagsArgs) (result int, err error) {
 	var query bytes.Buffer
-	query.WriteString(fmt.Sprintf(`SELECT COUNT(*) FROM tags WHERE active=%d `, config.Config.Models.Tags["active"]))
+	query.WriteString(fmt.Sprintf(`SELECT COUNT(*) FROM tags AS ta WHERE ta.active=%d AND ta.alias_of IS NULL `, config.Config.Models.Tags["active"]))
 
 	if args.Keyword != "" {
-		query.WriteString(` AND tag_content LIKE ?`)
+		query.WriteString(` AND (ta.tag_content LIKE ? OR ta.tag_id IN (SELECT alias_of FROM tags WHERE alias_of IS NOT NULL AND tag_content LIKE ?))`)
 		args.Keyword = "%" + args.Keyword + "%"
-		err = rrsql.DB.Get(&result, query.String(), args.Keyword)
+		err = rrsql.DB.Get(&result, query.String(), args.Keyword, args.Keyword)
 	} else {
 		err = rrsql.DB.Get(&result, query.String())
 	}
@@ -582,19 +706,23 @@ type sortableItem struct {
 
 type sortableList []sortableItem
 
-func (s sortableList) Len() int           { return len(s) }
-func (s sortableList) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-func (s sortableList) Less(i, j int) bool { return s[i].Key > s[j].Key }
+func (s sortableList) Len() int      { return len(s) }
+func (s sortableList) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortableList) Less(i, j int) bool {
+	return s[i].Key > s[j].Key || s[i].Key == s[j].Key && s[i].ID < s[j].ID
+}
 
 type tagResStats struct {
 	Clicks    int
 	PageView  int
+	Visitors  int
 	Follows   int
 	Emotions  int
 	Comments  int
 	Score     int
 	NClicks   int
 	NPageView int
+	NVisitors int
 }
 
 func (t *tagResStats) normalize() {
@@ -608,6 +736,11 @@ func (t *tagResStats) normalize() {
 	} else {
 		t.NPageView = int(math.Ceil(math.Log10(float64(t.PageView))))
 	}
+	if t.Visitors == 0 {
+		t.NVisitors = 0
+	} else {
+		t.NVisitors = int(math.Ceil(math.Log10(float64(t.Visitors))))
+	}
 }
 
 func (t *tagResStats) CalcScore() {
@@ -615,11 +748,58 @@ func (t *tagResStats) CalcScore() {
 	t.normalize()
 	t.Score = t.NClicks*weight["click"] +
 		t.NPageView*weight["pv"] +
+		t.NVisitors*weight["uv"] +
 		t.Follows*weight["follow"] +
 		t.Emotions*weight["emotion"] +
 		t.Comments*weight["comment"]
 }
 
+// fillEngagements sets clicks, pageviews and visitors of resources from source
+func fillEngagements(source EngagementSource, resources map[string]map[int]tagResStats) error {
+
+	for resType, stats := range resources {
+		engagements, err := source.Engagements(resType, getMapKeySlice(stats))
+		if err != nil {
+			return err
+		}
+		for resourceID, e := range engagements {
+			trs := stats[resourceID]
+			trs.Clicks = e.Clicks
+			trs.PageView = e.PageViews
+			trs.Visitors = e.Visitors
+			stats[resourceID] = trs
+		}
+	}
+	return nil
+}
+
+// scoreHotTags scores tags by their own stats plus scores of resources tagged,
+// and returns tags by score. Tags of the same score are ordered by ID.
+func scoreHotTags(tags map[int]tagStats, resources map[string]map[int]tagResStats) sortableList {
+
+	for _, stats := range resources {
+		for k, v := range stats {
+			v.CalcScore()
+			stats[k] = v
+		}
+	}
+
+	sl := make(sortableList, 0, len(tags))
+	for k, v := range tags {
+		v.CalcScore()
+		for _, postID := range v.PostIDs {
+			v.TagScore += resources["post"][postID].Score
+		}
+		for _, projectID := range v.ProjectIDs {
+			v.TagScore += resources["project"][projectID].Score
+		}
+		tags[k] = v
+		sl = append(sl, sortableItem{k, v.TagScore})
+	}
+	sort.Sort(sl)
+	return sl
+}
+
 func (a *tagApi) UpdateHotTags() error {
 	var tagResources = make(map[int]tagStats, 0)
 	var tagResourcesStats = map[string]map[int]tagResStats{
@@ -627,8 +807,8 @@ func (a *tagApi) UpdateHotTags() error {
 		"project": map[int]tagResStats{},
 	}
 
-	// Get Tag and Reources
-	query := "SELECT tag_id, type, GROUP_CONCAT(target_id) FROM tagging GROUP BY tag_id, type;"
+	// Get Tag and Reources, in which resources of descendants are rolled up to ancestors
+	query := "SELECT c.ancestor_id, tg.type, GROUP_CONCAT(DISTINCT tg.target_id) FROM tagging AS tg INNER JOIN tag_closure AS c ON c.descendant_id = tg.tag_id GROUP BY c.ancestor_id, tg.type;"
 	rows, err := rrsql.DB.Queryx(query)
 	if err != nil {
 		return err
@@ -679,79 +859,12 @@ func (a *tagApi) UpdateHotTags() error {
 	postResourceIDs := getMapKeySlice(tagResourcesStats["post"])
 	projectResourceIDs := getMapKeySlice(tagResourcesStats["project"])
 
-	_, postResourceStrings := mapResourceString("post", postResourceIDs)
-	orderedProjectIDs, projectResourceStrings := mapResourceString("project", projectResourceIDs)
-
-	projectSlugIDMap := map[string]int{}
-	for i := 0; i < len(projectResourceStrings); i++ {
-		projectSlugIDMap[projectResourceStrings[i]] = orderedProjectIDs[i]
-	}
-
-	// ES
-	esClient, err := ESConn(map[string]string{
-		"url": config.Config.ES.Url,
-	})
-	if err != nil {
+	// Clicks, pageviews and visitors
+	if err = fillEngagements(EngagementAPI, tagResourcesStats); err != nil {
+		log.Println("Fail getting engagements when updating hot tags:", err)
 		return err
 	}
 
-	esFilterQuery := elastic.NewBoolQuery()
-	var queryParams []interface{}
-	for _, v := range postResourceStrings {
-		queryParams = append(queryParams, v)
-	}
-	for _, v := range projectResourceStrings {
-		queryParams = append(queryParams, v)
-	}
-
-	esFilterQuery = esFilterQuery.Must([]elastic.Query{
-		elastic.NewTermsQuery("jsonPayload.curr-url.keyword", queryParams...),
-		elastic.NewTermsQuery("jsonPayload.event-type.keyword", []interface{}{"click", "pageview"}...)}...)
-	esCnstScoreQuery := elastic.NewConstantScoreQuery(esFilterQuery)
-
-	esEventTypeAggsQuery := elastic.NewTermsAggregation().
-		CollectionMode("breadth_first").
-		Field("jsonPayload.event-type.keyword")
-
-	esTermAggsQuery := elastic.NewTermsAggregation().
-		CollectionMode("breadth_first").
-		Field("jsonPayload.curr-url.keyword").
-		Size(10000).
-		SubAggregation("evt", esEventTypeAggsQuery)
-
-	searchResult, err := esClient.Search(config.Config.ES.LogIndices).Query(esCnstScoreQuery).Aggregation("counts", esTermAggsQuery).Do(context.Background())
-	if err != nil {
-		panic(err)
-	}
-
-	agg, found := searchResult.Aggregations.Terms("counts")
-	if !found {
-		log.Fatalf("we should have a terms aggregation called %q", "counts")
-	}
-	for _, bucket := range agg.Buckets {
-		t, ID := utils.ParseResourceInfo(bucket.Key.(string))
-		var iID int
-		if t == "post" {
-			iID, _ = strconv.Atoi(ID)
-		} else if t == "project" {
-			iID = projectSlugIDMap[bucket.Key.(string)]
-		}
-		subAgg, found := bucket.Terms("evt")
-		if !found {
-			log.Fatalf("we should have a terms aggregation called %q", "evt")
-		}
-
-		trs := tagResourcesStats[t][iID]
-		for _, subBucket := range subAgg.Buckets {
-			if subBucket.Key == "click" {
-				trs.Clicks = int(subBucket.DocCount)
-			} else if subBucket.Key == "pageview" {
-				trs.PageView = int(subBucket.DocCount)
-			}
-		}
-		tagResourcesStats[t][iID] = trs
-	}
-
 	// Resource Following and Like/Dislike(post only)
 	postEmotionQuery := fmt.Sprintf(`
 		SELECT target_id, COUNT(member_id)
@@ -848,10 +961,39 @@ func (a *tagApi) UpdateHotTags() error {
 	for _, v := range tagFollowResult.([]FollowedCount) {
 		ResourceID := int(v.ResourceID)
 		res := tagResources[ResourceID]
-		res.TagFollows = v.Count
+		res.TagFollows += v.Count
 		tagResources[ResourceID] = res
 	}
 
+	// Follows of descendants are rolled up to ancestors as well
+	closureQuery, closureArgs, err := sqlx.In("SELECT ancestor_id, descendant_id FROM tag_closure WHERE descendant_id IN (?) AND depth > 0;", tagIDs64)
+	if err != nil {
+		log.Println("Error parsing IN query when get tag ancestors when updating hottags:", err)
+		return err
+	}
+	ancestors := make(map[int][]int)
+	rows, err = rrsql.DB.Queryx(rrsql.DB.Rebind(closureQuery), closureArgs...)
+	if err != nil {
+		log.Println("Error get tag ancestors when updating hottags:", err)
+		return err
+	}
+	for rows.Next() {
+		var ancestorID, descendantID int
+		if err = rows.Scan(&ancestorID, &descendantID); err != nil {
+			log.Println("Error scaning query result when updating hottags:", err)
+			return err
+		}
+		ancestors[descendantID] = append(ancestors[descendantID], ancestorID)
+	}
+	for _, v := range tagFollowResult.([]FollowedCount) {
+		for _, ancestorID := range ancestors[int(v.ResourceID)] {
+			if res, ok := tagResources[ancestorID]; ok {
+				res.TagFollows += v.Count
+				tagResources[ancestorID] = res
+			}
+		}
+	}
+
 	// Comment Count
 	postCCQuery := "SELECT post_id, IFNULL(comment_amount, 0) AS comment_amount FROM posts WHERE post_id IN (?);"
 	postCCQuery, args, err := sqlx.In(postCCQuery, postResourceIDs)
@@ -902,7 +1044,7 @@ func (a *tagApi) UpdateHotTags() error {
 	}
 
 	// Tagged Post Count
-	taggedPostQuery := fmt.Sprintf("SELECT tag_id, COUNT(id) FROM tagging WHERE type = %d GROUP by tag_id;", config.Config.Models.TaggingType["post"])
+	taggedPostQuery := fmt.Sprintf("SELECT c.ancestor_id, COUNT(DISTINCT tg.target_id) FROM tagging AS tg INNER JOIN tag_closure AS c ON c.descendant_id = tg.tag_id WHERE tg.type = %d GROUP by c.ancestor_id;", config.Config.Models.TaggingType["post"])
 	rows, err = rrsql.DB.Queryx(taggedPostQuery)
 	if err != nil {
 		log.Println("Error get tagged post count when updating hottags:", err)
@@ -920,33 +1062,7 @@ func (a *tagApi) UpdateHotTags() error {
 		tagResources[tagID] = res
 	}
 
-	// Calculate tag score
-	for k, v := range tagResourcesStats["post"] {
-		v.CalcScore()
-		tagResourcesStats["post"][k] = v
-	}
-	for k, v := range tagResourcesStats["project"] {
-		v.CalcScore()
-		tagResourcesStats["project"][k] = v
-	}
-
-	for k, v := range tagResources {
-		v.CalcScore()
-		for _, postID := range v.PostIDs {
-			v.TagScore += tagResourcesStats["post"][postID].Score
-		}
-		for _, projectID := range v.ProjectIDs {
-			v.TagScore += tagResourcesStats["project"][projectID].Score
-		}
-		tagResources[k] = v
-	}
-
-	// Sort Score
-	var sl sortableList
-	for k, v := range tagResources {
-		sl = append(sl, sortableItem{k, v.TagScore})
-	}
-	sort.Sort(sl)
+	sl := scoreHotTags(tagResources, tagResourcesStats)
 	limit := func(a, b int) int {
 		if a < b {
 			return a
@@ -1022,39 +1138,6 @@ func getMapKeySlice(m map[int]tagResStats) []int {
 	return ks
 }
 
-func mapResourceString(resType string, resIDs []int) (orderedResIDs []int, resStrings []string) {
-	if resType == "post" {
-		for _, resID := range resIDs {
-			resStrings = append(resStrings, utils.GenerateResourceInfo(resType, resID, ""))
-		}
-	} else if resType == "project" {
-		query := fmt.Sprintf("SELECT project_id, slug FROM projects WHERE project_id IN (?) AND active = %d", config.Config.Models.Tags["active"])
-		query, args, err := sqlx.In(query, resIDs)
-		if err != nil {
-			log.Println("Error parsing IN query when mapResourceString:", err)
-			return orderedResIDs, resStrings
-		}
-		query = rrsql.DB.Rebind(query)
-		rows, err := rrsql.DB.Queryx(query, args...)
-		if err != nil {
-			log.Println("Error querying project slugs when mapResourceString:", err)
-			return orderedResIDs, resStrings
-		}
-
-		for rows.Next() {
-			var id int
-			var slug string
-			if err = rows.Scan(&id, &slug); err != nil {
-				log.Println("Error scaning query result when mapResourceString:", err)
-				return []int{}, []string{}
-			}
-			resStrings = append(resStrings, utils.GenerateResourceInfo(resType, id, slug))
-			orderedResIDs = append(orderedResIDs, id)
-		}
-	}
-	return orderedResIDs, resStrings
-}
-
 func mapResourceIDint64(res []int) (res64 []int64) {
 	res64 = make([]int64, len(res))
 	for i := 0; i < len(res); i++ {
@@ -1063,187 +1146,4 @@ func mapResourceIDint64(res []int) (res64 []int64) {
 	return res64
 }
 
-type GetPostReportArgs struct {
-	MaxResult int    `form:"max_result"`
-	Page      int    `form:"page"`
-	Sorting   string `form:"sort"`
-	TagID     int
-	Filter    Filter
-}
-
-func NewGetPostReportArgs(options ...func(*GetPostReportArgs)) *GetPostReportArgs {
-
-	arg := GetPostReportArgs{MaxResult: 20, Page: 1, Sorting: "-published_at"}
-
-	for _, option := range options {
-		option(&arg)
-	}
-	return &arg
-}
-
-func (a *GetPostReportArgs) ValidateGet() (err error) {
-
-	if !utils.ValidateStringArgs(a.Sorting, "-?(created_at|updated_at|published_at)") {
-		return errors.New("Invalid Sort Option")
-	}
-	return nil
-}
-
-func (a *GetPostReportArgs) ValidateFilter() (err error) {
-
-	if !utils.ValidateStringArgs(a.Filter.Field, "(created_at|updated_at|published_at)") {
-		return errors.New("Invalid Filter Field")
-	}
-	// This will be blocked by Error: No Valid PNR Filter
-	// if !utils.ValidateStringArgs(a.Filter.Operator, "(<|<=|>|>=|==|!=)") {
-	// 	return errors.New("Invalid Filter Operator")
-	// }
-	if _, err := time.Parse(time.RFC3339, a.Filter.Condition); err != nil {
-		return errors.New("Invalid Filter Time Condition")
-	}
-	// If fields match a.Sorting
-	var sortPattern string
-	if strings.HasPrefix(a.Sorting, "-") {
-		sortPattern = strings.TrimPrefix(a.Sorting, "-")
-	} else {
-		sortPattern = a.Sorting
-	}
-	if !utils.ValidateStringArgs(a.Filter.Field, fmt.Sprintf("-?(%s)", sortPattern)) {
-		return errors.New("Inconsistent Filter Field")
-	}
-	return nil
-}
-
-// LastPNRInterface is used in /tags/pnr
-// TaggedPostMember and ReportAuthors satisfy this interface
-type LastPNRInterface interface {
-	ReturnPublishedAt() time.Time
-	ReturnCreatedAt() time.Time
-	ReturnUpdatedAt() time.Time
-}
-
-func (t *tagApi) GetPostReport(args *GetPostReportArgs) (results []LastPNRInterface, err error) {
-
-	tagging := []struct {
-		Type     int    `db:"type"`
-		TagID    int    `db:"tag_id"`
-		TargetID uint32 `db:"target_id"`
-	}{}
-	err = rrsql.DB.Select(&tagging, `SELECT type, tag_id, target_id FROM tagging WHERE tag_id = ?`, args.TagID)
-	if err != nil {
-		return results, err
-	}
-
-	var (
-		postsList    []uint32
-		projectsList []int64
-	)
-	for _, v := range tagging {
-		if v.Type == config.Config.Models.TaggingType["post"] {
-			postsList = append(postsList, v.TargetID)
-		} else if v.Type == config.Config.Models.TaggingType["project"] {
-			projectsList = append(projectsList, int64(v.TargetID))
-		}
-	}
-
-	// set necessary PostArgs to get posts with specific tag
-	setPostArgs := func(in *GetPostReportArgs, pl []uint32) func(*PostArgs) {
-		return func(args *PostArgs) {
-			args.MaxResult = uint8(in.MaxResult + 1)
-			args.Sorting = in.Sorting
-			args.IDs = pl
-			args.ProjectID = -1
-			args.Filter = in.Filter
-			args.Active = map[string][]int{"$nin": []int{config.Config.Models.Reports["deactive"]}}
-			args.ShowAuthor = true
-			args.ShowCard = true
-			args.ShowCommment = true
-			args.ShowTag = true
-			args.ShowUpdater = true
-		}
-	}
-
-	var (
-		posts   []TaggedPostMember
-		reports []ReportAuthors
-	)
-	if len(postsList) > 0 {
-		postargs := NewPostArgs(setPostArgs(args, postsList))
-		posts, err = PostAPI.GetPosts(postargs)
-		if err != nil {
-			return results, errors.New("Unable to get tagged posts")
-		}
-	}
-
-	setReportArgs := func(in *GetPostReportArgs, pl []int64) func(*GetReportArgs) {
-		return func(args *GetReportArgs) {
-			args.MaxResult = in.MaxResult + 1
-			args.Sorting = in.Sorting
-			args.Fields = []string{"nickname"}
-			args.Project = pl
-			args.Filter = in.Filter
-		}
-	}
-
-	if len(projectsList) > 0 {
-		reportargs := NewGetReportArgs(setReportArgs(args, projectsList))
-		reports, err = ReportAPI.GetReports(*reportargs)
-		if err != nil {
-			return results, errors.New("Unable to get tagged reports")
-		}
-	}
-
-	// Construct an sorted array out of two sorted array
-	results = make([]LastPNRInterface, len(posts)+len(reports))
-
-	// sorter is the function compare two arrays,
-	// which support multiple sorting
-	sorter := func(p TaggedPostMember, r ReportAuthors) bool {
-		switch args.Sorting {
-		case "-published_at":
-			return p.PublishedAt.After(r.PublishedAt)
-		case "published_at":
-			return p.PublishedAt.Before(r.PublishedAt)
-		case "-updated_at":
-			return p.UpdatedAt.After(r.UpdatedAt)
-		case "updated_at":
-			return p.UpdatedAt.Before(r.UpdatedAt)
-		case "-created_at":
-			return p.CreatedAt.After(r.CreatedAt)
-		case "created_at":
-			return p.CreatedAt.Before(r.CreatedAt)
-		default:
-			return false
-		}
-	}
-	var i, j, k int
-	for i < len(posts) && j < len(reports) {
-		if sorter(posts[i], reports[j]) {
-			results[k] = posts[i]
-			i++
-			k++
-		} else {
-			results[k] = reports[j]
-			j++
-			k++
-		}
-	}
-	// Finish the remaining elements
-	// Only one of these loop functions at a time
-	for i < len(posts) {
-		results[k] = posts[i]
-		i++
-		k++
-	}
-	for j < len(reports) {
-		results[k] = reports[j]
-		j++
-		k++
-	}
-	if len(results) > args.MaxResult {
-		results = results[:args.MaxResult+1]
-	}
-	return results, err
-}
-
 var TagAPI TagInterface = new(tagApi)
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// trashResource describes how a resource is deactivated, restored and purged
+type trashResource struct {
+	table     string
+	idField   string
+	nameField string
+	// status returns active values of resource before and after it is deleted
+	status func() (active int, deleted int)
+	// relations are rows referring to resource, which are purged together with it.
+	// Each query takes ids of purged resource as its last argument.
+	relations func() []rrsql.PipelineStmt
+}
+
+var trashResources = map[string]trashResource{
+	"post": {
+		table: "posts", idField: "post_id", nameField: "title",
+		status: func() (int, int) {
+			return config.Config.Models.Posts["active"], config.Config.Models.Posts["deactive"]
+		},
+		relations: func() []rrsql.PipelineStmt {
+			return []rrsql.PipelineStmt{
+				{Query: `DELETE FROM tagging WHERE type = ? AND target_id IN (?);`, Args: []interface{}{config.Config.Models.TaggingType["post"]}},
+				{Query: `DELETE FROM authors WHERE resource_id IN (?);`},
+				{Query: `DELETE FROM newscards WHERE post_id IN (?);`},
+			}
+		},
+	},
+	"member": {
+		table: "members", idField: "id", nameField: "nickname",
+		status: func() (int, int) {
+			return config.Config.Models.Members["active"], config.Config.Models.Members["delete"]
+		},
+		relations: func() []rrsql.PipelineStmt {
+			return []rrsql.PipelineStmt{
+				{Query: `DELETE FROM authors WHERE author_id IN (?);`},
+				{Query: `DELETE FROM project_authors WHERE author_id IN (?);`},
+			}
+		},
+	},
+	"tag": {
+		table: "tags", idField: "tag_id", nameField: "tag_content",
+		status: func() (int, int) {
+			return config.Config.Models.Tags["active"], config.Config.Models.Tags["deactive"]
+		},
+		relations: func() []rrsql.PipelineStmt {
+			return []rrsql.PipelineStmt{
+				{Query: `DELETE FROM tagging WHERE tag_id IN (?);`},
+				// Children are moved to the parent of tags purged
+				{Query: `UPDATE tags AS t INNER JOIN tags AS p ON p.tag_id = t.parent_id SET t.parent_id = p.parent_id WHERE p.tag_id IN (?);`},
+				{Query: `DELETE FROM tag_closure WHERE ancestor_id IN (?);`},
+				{Query: `DELETE FROM tag_closure WHERE descendant_id IN (?);`},
+			}
+		},
+	},
+	"asset": {
+		table: "assets", idField: "id", nameField: "title",
+		status: func() (int, int) {
+			return config.Config.Models.Assets["active"], config.Config.Models.Assets["deactive"]
+		},
+		relations: func() []rrsql.PipelineStmt {
+			return []rrsql.PipelineStmt{
+				{Query: `DELETE FROM tagging WHERE type = ? AND target_id IN (?);`, Args: []interface{}{config.Config.Models.TaggingType["asset"]}},
+			}
+		},
+	},
+}
+
+// ValidateTrashType checks resource type of trash
+func ValidateTrashType(resource string) error {
+	if _, ok := trashResources[resource]; !ok {
+		return errors.New("Invalid Type")
+	}
+	return nil
+}
+
+// TrashItem is a deleted resource waiting to be restored or purged
+type TrashItem struct {
+	ID        int64            `json:"id" db:"id"`
+	Type      string           `json:"type" db:"type"`
+	Name      rrsql.NullString `json:"name" db:"name"`
+	DeletedAt rrsql.NullTime   `json:"deleted_at" db:"deleted_at"`
+}
+
+type GetTrashArgs struct {
+	Type      string `form:"type"`
+	MaxResult int    `form:"max_result"`
+	Page      int    `form:"page"`
+}
+
+type TrashInterface interface {
+	Get(args *GetTrashArgs) ([]TrashItem, error)
+	Insert(resource string, ids []int) error
+	Restore(resource string, ids []int) ([]int, error)
+	Purge() (int, error)
+}
+
+type trashAPI struct{}
+
+var TrashAPI TrashInterface = new(trashAPI)
+
+func trashRetention() time.Duration {
+	days := config.Config.Trash.RetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Get lists resources of args.Type still deleted, the latest deleted first
+func (a *trashAPI) Get(args *GetTrashArgs) (items []TrashItem, err error) {
+
+	res, ok := trashResources[args.Type]
+	if !ok {
+		return nil, errors.New("Invalid Type")
+	}
+	_, deleted := res.status()
+
+	query := fmt.Sprintf(`
+		SELECT trash.resource_id AS id, trash.resource_type AS type, r.%s AS name, trash.deleted_at FROM trash
+		INNER JOIN %s AS r ON r.%s = trash.resource_id AND r.active = ?
+		WHERE trash.resource_type = ? ORDER BY trash.deleted_at DESC LIMIT ? OFFSET ?;`,
+		res.nameField, res.table, res.idField)
+
+	items = make([]TrashItem, 0)
+	if err = rrsql.DB.Select(&items, query, deleted, args.Type, args.MaxResult, (args.Page-1)*args.MaxResult); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Insert records ids of resource as deleted now. Deleting again restarts the retention period,
+// since resource could be reactivated without Restore in between.
+func (a *trashAPI) Insert(resource string, ids []int) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, len(ids)*2)
+	placeholders := ""
+	for i, id := range ids {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += "(?, ?)"
+		values = append(values, resource, id)
+	}
+	_, err := rrsql.DB.Exec(fmt.Sprintf(`INSERT INTO trash (resource_type, resource_id) VALUES %s ON DUPLICATE KEY UPDATE deleted_at = CURRENT_TIMESTAMP;`, placeholders), values...)
+	return err
+}
+
+// recordTrash records deleted resources, and only logs error since resources are already deactivated
+func recordTrash(resource string, ids []int) {
+	if err := TrashAPI.Insert(resource, ids); err != nil {
+		log.Printf("Error recording %s %v to trash: %v\n", resource, ids, err)
+	}
+}
+
+// Restore reactivates ids of resource which are still deleted, and returns ids restored
+func (a *trashAPI) Restore(resource string, ids []int) (restored []int, err error) {
+
+	res, ok := trashResources[resource]
+	if !ok {
+		return nil, errors.New("Invalid Type")
+	}
+	active, deleted := res.status()
+
+	err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+		query, args, err := sqlx.In(fmt.Sprintf(`SELECT %s FROM %s WHERE %s IN (?) AND active = ? FOR UPDATE;`, res.idField, res.table, res.idField), ids, deleted)
+		if err != nil {
+			return err
+		}
+		if err = tx.Select(&restored, tx.Rebind(query), args...); err != nil {
+			return err
+		}
+		if len(restored) == 0 {
+			return errors.New("Trash Not Found")
+		}
+
+		update, updateArgs, err := sqlx.In(fmt.Sprintf(`UPDATE %s SET active = ? WHERE %s IN (?);`, res.table, res.idField), active, restored)
+		if err != nil {
+			return err
+		}
+		remove, removeArgs, err := sqlx.In(`DELETE FROM trash WHERE resource_type = ? AND resource_id IN (?);`, resource, restored)
+		if err != nil {
+			return err
+		}
+		_, _, err = rrsql.RunPipeline(tx,
+			&rrsql.PipelineStmt{Query: tx.Rebind(update), Args: updateArgs},
+			&rrsql.PipelineStmt{Query: tx.Rebind(remove), Args: removeArgs},
+		)
+		return err
+	})
+	return restored, err
+}
+
+// Purge hard-deletes resources deleted longer than the retention period, with their relations.
+// Resources reactivated without Restore are only dropped from trash.
+func (a *trashAPI) Purge() (purged int, err error) {
+
+	before := time.Now().Add(-trashRetention())
+	limit := config.Config.Trash.MaxPurge
+	if limit <= 0 {
+		limit = 500
+	}
+
+	for resource, res := range trashResources {
+		_, deleted := res.status()
+
+		var expired []int
+		if err = rrsql.DB.Select(&expired, `SELECT resource_id FROM trash WHERE resource_type = ? AND deleted_at < ? LIMIT ?;`, resource, before, limit); err != nil {
+			return purged, err
+		}
+		if len(expired) == 0 {
+			continue
+		}
+
+		count := 0
+		err = rrsql.WithTransaction(rrsql.DB.DB, func(tx *sqlx.Tx) error {
+
+			// Check again in transaction, since resources could be reactivated any time
+			query, args, err := sqlx.In(fmt.Sprintf(`SELECT %s FROM %s WHERE %s IN (?) AND active = ? FOR UPDATE;`, res.idField, res.table, res.idField), expired, deleted)
+			if err != nil {
+				return err
+			}
+			var ids []int
+			if err = tx.Select(&ids, tx.Rebind(query), args...); err != nil {
+				return err
+			}
+
+			stmts := make([]*rrsql.PipelineStmt, 0)
+			if len(ids) > 0 {
+				relations := res.relations()
+				relations = append(relations, rrsql.PipelineStmt{Query: fmt.Sprintf(`DELETE FROM %s WHERE %s IN (?);`, res.table, res.idField)})
+				for _, relation := range relations {
+					query, args, err := sqlx.In(relation.Query, append(relation.Args, ids)...)
+					if err != nil {
+						return err
+					}
+					stmts = append(stmts, &rrsql.PipelineStmt{Query: tx.Rebind(query), Args: args})
+				}
+			}
+			query, args, err = sqlx.In(`DELETE FROM trash WHERE resource_type = ? AND resource_id IN (?);`, resource, expired)
+			if err != nil {
+				return err
+			}
+			stmts = append(stmts, &rrsql.PipelineStmt{Query: tx.Rebind(query), Args: args})
+
+			count = len(ids)
+			_, _, err = rrsql.RunPipeline(tx, stmts...)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error purging %s: %v\n", resource, err)
+			return purged, err
+		}
+		purged += count
+	}
+	return purged, nil
+}
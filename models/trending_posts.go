@@ -0,0 +1,243 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+// trendingPostsKey is the sorted set of post ids scored by trending score
+const trendingPostsKey = "posts_trending"
+
+func trendingWindowHours() int {
+	if hours := config.Config.TrendingPosts.WindowHours; hours > 0 {
+		return hours
+	}
+	return 48
+}
+
+type trendingPost struct {
+	ID    uint32
+	Score float64
+}
+
+type trendingPostStats struct {
+	Views       int
+	Likes       int
+	Dislikes    int
+	Comments    int
+	PublishedAt time.Time
+}
+
+// CalcScore weights recent engagement, and divides it by the age of post powered by gravity,
+// so that new posts with less engagement could still rise above old ones
+func (s trendingPostStats) CalcScore(now time.Time) float64 {
+
+	weight := config.Config.Models.TrendingPostsWeight
+	window := float64(trendingWindowHours())
+
+	// Views are normalized like tagResStats does, and comments are counted in comments per day
+	score := math.Log2(float64(1+s.Views))*float64(weight["view"]) +
+		float64(s.Likes*weight["like"]+s.Dislikes*weight["dislike"]) +
+		float64(s.Comments)/window*24*float64(weight["comment"])
+
+	gravity := config.Config.TrendingPosts.Gravity
+	if gravity <= 0 {
+		gravity = 1.5
+	}
+	age := now.Sub(s.PublishedAt).Hours()
+	if age < 0 {
+		age = 0
+	}
+	return score / math.Pow(age+2, gravity)
+}
+
+type TrendingPostInterface interface {
+	Get() ([]uint32, error)
+	Update() error
+}
+
+type trendingPostAPI struct{}
+
+var TrendingPostAPI TrendingPostInterface = new(trendingPostAPI)
+
+// Get returns post ids ordered by trending score
+func (a *trendingPostAPI) Get() (ids []uint32, err error) {
+
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+
+	values, err := redis.Ints(conn.Do("ZREVRANGE", trendingPostsKey, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	ids = make([]uint32, 0, len(values))
+	for _, v := range values {
+		ids = append(ids, uint32(v))
+	}
+	return ids, nil
+}
+
+// recentViews sums up post views in hourly buckets within the window
+func (a *trendingPostAPI) recentViews(now time.Time, stats func(id uint32) *trendingPostStats) error {
+
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+
+	hours := trendingWindowHours()
+	conn.Send("MULTI")
+	for i := 0; i < hours; i++ {
+		conn.Send("ZRANGE", postViewsHourlyKey(now.Add(-time.Duration(i)*time.Hour)), 0, -1, "WITHSCORES")
+	}
+	buckets, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		views, err := redis.IntMap(bucket, nil)
+		if err != nil {
+			return err
+		}
+		for id, count := range views {
+			postID, err := strconv.ParseUint(id, 10, 32)
+			if err != nil {
+				continue
+			}
+			stats(uint32(postID)).Views += count
+		}
+	}
+	return nil
+}
+
+// Update recomputes trending scores of posts engaged within the window, and replaces the sorted set
+func (a *trendingPostAPI) Update() error {
+
+	now := time.Now()
+	since := now.Add(-time.Duration(trendingWindowHours()) * time.Hour)
+
+	candidates := make(map[uint32]*trendingPostStats)
+	stats := func(id uint32) *trendingPostStats {
+		if _, ok := candidates[id]; !ok {
+			candidates[id] = &trendingPostStats{}
+		}
+		return candidates[id]
+	}
+
+	// Recent views buffered by ViewAPI
+	if err := a.recentViews(now, stats); err != nil {
+		return err
+	}
+
+	// Recent like and dislike
+	rows, err := rrsql.DB.Queryx(`
+		SELECT target_id, emotion, COUNT(*) FROM following
+		WHERE type = ? AND emotion IN (?, ?) AND created_at > ?
+		GROUP BY target_id, emotion;`,
+		config.Config.Models.FollowingType["post"],
+		config.Config.Models.Emotions["like"], config.Config.Models.Emotions["dislike"], since)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id uint32
+		var emotion, count int
+		if err = rows.Scan(&id, &emotion, &count); err != nil {
+			rows.Close()
+			return err
+		}
+		if emotion == config.Config.Models.Emotions["like"] {
+			stats(id).Likes = count
+		} else {
+			stats(id).Dislikes = count
+		}
+	}
+	rows.Close()
+
+	// Recent comments, whose resource is the url of post or memo
+	rows, err = rrsql.DB.Queryx(`
+		SELECT resource, COUNT(*) FROM comments
+		WHERE active = ? AND created_at > ?
+		GROUP BY resource;`,
+		config.Config.Models.Comment["active"], since)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var resource string
+		var count int
+		if err = rows.Scan(&resource, &count); err != nil {
+			rows.Close()
+			return err
+		}
+		if resourceType, resourceID := utils.ParseResourceInfo(resource); resourceType == "post" || resourceType == "memo" {
+			if id, err := strconv.ParseUint(resourceID, 10, 32); err == nil {
+				stats(uint32(id)).Comments += count
+			}
+		}
+	}
+	rows.Close()
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	if len(candidates) == 0 {
+		_, err = conn.Do("DEL", trendingPostsKey)
+		return err
+	}
+
+	// Only public posts are trending
+	ids := make([]uint32, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	query, args, err := sqlx.In(`SELECT post_id, published_at FROM posts WHERE post_id IN (?) AND active = ? AND publish_status = ?;`,
+		ids, config.Config.Models.Posts["active"], config.Config.Models.PostPublishStatus["publish"])
+	if err != nil {
+		return err
+	}
+	rows, err = rrsql.DB.Queryx(rrsql.DB.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	scores := make([]trendingPost, 0, len(candidates))
+	for rows.Next() {
+		var id uint32
+		var publishedAt rrsql.NullTime
+		if err = rows.Scan(&id, &publishedAt); err != nil {
+			return err
+		}
+		candidates[id].PublishedAt = publishedAt.Time
+		if score := candidates[id].CalcScore(now); score > 0 {
+			scores = append(scores, trendingPost{ID: id, Score: score})
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	if max := config.Config.TrendingPosts.MaxPosts; max > 0 && len(scores) > max {
+		scores = scores[:max]
+	}
+
+	// Replace the sorted set at once, so readers never see a partial ranking
+	tmp := fmt.Sprintf("%s_tmp", trendingPostsKey)
+	conn.Send("MULTI")
+	conn.Send("DEL", tmp)
+	for _, s := range scores {
+		conn.Send("ZADD", tmp, s.Score, s.ID)
+	}
+	if len(scores) > 0 {
+		conn.Send("RENAME", tmp, trendingPostsKey)
+	} else {
+		conn.Send("DEL", trendingPostsKey)
+	}
+	_, err = conn.Do("EXEC")
+	return err
+}
@@ -0,0 +1,338 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/garyburd/redigo/redis"
+	"github.com/readr-media/readr-restful/config"
+)
+
+// TwitterCard is the Twitter card meta of a page
+type TwitterCard struct {
+	Card        string `meta:"twitter:card" json:"card"`
+	Site        string `meta:"twitter:site" json:"site"`
+	Title       string `meta:"twitter:title" json:"title"`
+	Description string `meta:"twitter:description" json:"description"`
+	Image       string `meta:"twitter:image,twitter:image:src" json:"image"`
+}
+
+// OEmbed is the oEmbed response of a page, discovered by its link of type application/json+oembed
+type OEmbed struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title,omitempty"`
+	AuthorName   string `json:"author_name,omitempty"`
+	ProviderName string `json:"provider_name,omitempty"`
+	ProviderURL  string `json:"provider_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	HTML         string `json:"html,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// unfurlEntry is what stored in cache. Failed unfurls are cached as well with Error set,
+// so that broken links are not fetched again and again.
+type unfurlEntry struct {
+	Info  *OGInfo `json:"info,omitempty"`
+	Error string  `json:"error,omitempty"`
+}
+
+type UnfurlInterface interface {
+	Unfurl(rawURL string) (*OGInfo, error)
+}
+
+type unfurlAPI struct{}
+
+// UnfurlAPI fetches previews of user-supplied links, which should never reach internal networks
+var UnfurlAPI UnfurlInterface = new(unfurlAPI)
+
+// unfurlBlockedNets are private, loopback, link-local and other non-public ranges.
+// IPv6 ranges embedding IPv4 addresses are blocked too, while IPv4-mapped ones are matched against IPv4 ranges.
+var unfurlBlockedNets = func() (nets []*net.IPNet) {
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+		"::/96", "64:ff9b::/96", "fc00::/7", "fe80::/10", "ff00::/8",
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+func unfurlBlocked(ip net.IP) bool {
+	for _, n := range unfurlBlockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardDial runs after DNS resolution for every connection, including those of redirects,
+// so hostnames resolving to internal addresses are rejected as well
+func guardDial(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || unfurlBlocked(ip) {
+		return errors.New("Blocked Address")
+	}
+	return nil
+}
+
+func (u *unfurlAPI) client() *http.Client {
+
+	timeout := time.Duration(config.Config.Unfurl.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout, Control: guardDial}
+	tr := &http.Transport{
+		// Never go through proxies from environment, which would bypass guardDial
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     &tls.Config{NextProtos: []string{"http/1.1"}},
+		TLSHandshakeTimeout: timeout,
+	}
+	return &http.Client{
+		Transport:     tr,
+		Timeout:       timeout,
+		CheckRedirect: unfurlRedirectPolicy(config.Config.Unfurl.MaxRedirects),
+	}
+}
+
+// unfurlRedirectPolicy follows at most maxRedirects redirects, and only to http or https
+func unfurlRedirectPolicy(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return errors.New("Too Many Redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return errors.New("Invalid URL")
+		}
+		return nil
+	}
+}
+
+func (u *unfurlAPI) get(client *http.Client, target string) (*http.Response, error) {
+	return u.request(client, "GET", target)
+}
+
+// request sends request with headers of crawler
+func (u *unfurlAPI) request(client *http.Client, method, target string) (*http.Response, error) {
+
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range config.Config.Crawler.Headers {
+		req.Header.Add(k, v)
+	}
+	if !regexp.MustCompile("\\.readr\\.tw\\/").MatchString(target) {
+		req.Header.Del("Cookie")
+	}
+	if regexp.MustCompile("\\.youtube\\.com\\/").MatchString(target) {
+		req.Header.Del("User-Agent")
+		req.Header.Add("User-Agent", "facebookexternalhit/1.1")
+	}
+	return client.Do(req)
+}
+
+// readBody reads at most max_body_bytes of body, which is enough for meta in head
+func readBody(body io.Reader) ([]byte, error) {
+	limit := config.Config.Unfurl.MaxBodyBytes
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	return ioutil.ReadAll(io.LimitReader(body, limit))
+}
+
+// resolveRef makes ref found in page absolute
+func resolveRef(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(r).String()
+}
+
+func (u *unfurlAPI) fetch(target *url.URL) (*OGInfo, error) {
+
+	client := u.client()
+	resp, err := u.get(client, target.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected Status %d", resp.StatusCode)
+	}
+	info := &OGInfo{}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		return info, nil
+	}
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	if err = OGParser.getPageData(doc, info); err != nil {
+		return nil, err
+	}
+
+	// Relative references are resolved against the final url after redirects
+	base := resp.Request.URL
+	info.Image = resolveRef(base, info.Image)
+	info.Twitter.Image = resolveRef(base, info.Twitter.Image)
+	if href, ok := doc.Find(`link[rel~="icon"]`).First().Attr("href"); ok {
+		info.Favicon = resolveRef(base, href)
+	} else {
+		info.Favicon = resolveRef(base, "/favicon.ico")
+	}
+
+	info.OEmbed = nil
+	if href, ok := doc.Find(`link[type="application/json+oembed"]`).First().Attr("href"); ok {
+		if oembed, err := u.fetchOEmbed(client, resolveRef(base, href)); err != nil {
+			log.Printf("Fetch oEmbed of %s fail: %v\n", target.String(), err)
+		} else {
+			info.OEmbed = oembed
+		}
+	}
+	return info, nil
+}
+
+func (u *unfurlAPI) fetchOEmbed(client *http.Client, endpoint string) (*OEmbed, error) {
+
+	resp, err := u.get(client, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected Status %d", resp.StatusCode)
+	}
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	oembed := &OEmbed{}
+	if err = json.Unmarshal(body, oembed); err != nil {
+		return nil, err
+	}
+	return oembed, nil
+}
+
+func unfurlKey(target string) string {
+	return fmt.Sprintf("unfurl_%x", sha1.Sum([]byte(target)))
+}
+
+// cached returns the cached entry of key. ok is false if there is no such cache
+func (u *unfurlAPI) cached(key string) (entry unfurlEntry, ok bool) {
+
+	conn := RedisHelper.ReadConn()
+	defer conn.Close()
+
+	res, err := redis.Bytes(conn.Do("GET", key))
+	if err != nil {
+		return entry, false
+	}
+	if err = json.Unmarshal(res, &entry); err != nil {
+		return entry, false
+	}
+	return entry, entry.Info != nil || entry.Error != ""
+}
+
+func (u *unfurlAPI) cache(key string, entry unfurlEntry, ttl int) {
+
+	if ttl <= 0 {
+		return
+	}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	if _, err = conn.Do("SETEX", key, ttl, value); err != nil {
+		log.Printf("Error set unfurl cache %s: %v\n", key, err)
+	}
+}
+
+// allow counts fetches to host in the current minute, and tells whether another one is allowed.
+// Requests are let through if counting fails, since previews are not critical.
+func (u *unfurlAPI) allow(host string) bool {
+
+	limit := config.Config.Unfurl.DomainRateLimit
+	if limit <= 0 {
+		return true
+	}
+	key := fmt.Sprintf("unfurl_rate_%s_%d", strings.ToLower(host), time.Now().Unix()/60)
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	count, err := redis.Int(conn.Do("INCR", key))
+	if err != nil {
+		log.Printf("Error count unfurl rate of %s: %v\n", host, err)
+		return true
+	}
+	if count == 1 {
+		conn.Do("EXPIRE", key, 60)
+	}
+	return count <= limit
+}
+
+// Unfurl returns preview of rawURL, from cache if possible
+func (u *unfurlAPI) Unfurl(rawURL string) (*OGInfo, error) {
+
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, errors.New("Invalid URL")
+	}
+	key := unfurlKey(target.String())
+	if entry, ok := u.cached(key); ok {
+		if entry.Error != "" {
+			return nil, errors.New(entry.Error)
+		}
+		return entry.Info, nil
+	}
+	// Rate limited requests are not cached, so they could be retried later
+	if !u.allow(target.Hostname()) {
+		return nil, errors.New("Rate Limited")
+	}
+
+	info, err := u.fetch(target)
+	if err != nil {
+		u.cache(key, unfurlEntry{Error: err.Error()}, config.Config.Unfurl.NegativeCacheTTL)
+		return nil, err
+	}
+	u.cache(key, unfurlEntry{Info: info}, config.Config.Unfurl.CacheTTL)
+	return info, nil
+}
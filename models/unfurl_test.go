@@ -0,0 +1,99 @@
+package models
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnfurlBlocked(t *testing.T) {
+
+	for _, tc := range []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"::", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::127.0.0.1", true},
+		{"64:ff9b::10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"::ffff:8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	} {
+		assert.Equal(t, tc.blocked, unfurlBlocked(net.ParseIP(tc.ip)), tc.ip)
+	}
+}
+
+func TestGuardDial(t *testing.T) {
+
+	assert.EqualError(t, guardDial("tcp", "169.254.169.254:80", nil), "Blocked Address")
+	assert.EqualError(t, guardDial("tcp6", "[::ffff:10.0.0.1]:443", nil), "Blocked Address")
+	assert.EqualError(t, guardDial("tcp", "localhost:80", nil), "Blocked Address")
+	assert.Nil(t, guardDial("tcp", "8.8.8.8:443", nil))
+
+	// Servers of tests listen on loopback, which the unfurl client never reaches
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, err := new(unfurlAPI).client().Get(server.URL)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Blocked Address")
+	}
+}
+
+func TestUnfurlRedirectPolicy(t *testing.T) {
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Redirect(w, r, "/next", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: unfurlRedirectPolicy(2)}
+	_, err := client.Get(server.URL)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Too Many Redirects")
+	}
+	// The first request and 2 redirects followed
+	assert.Equal(t, 3, hits)
+
+	req, _ := http.NewRequest("GET", "ftp://example.com/", nil)
+	assert.EqualError(t, unfurlRedirectPolicy(2)(req, nil), "Invalid URL")
+}
+
+func TestReadBody(t *testing.T) {
+
+	backup := config.Config.Unfurl.MaxBodyBytes
+	defer func() { config.Config.Unfurl.MaxBodyBytes = backup }()
+
+	config.Config.Unfurl.MaxBodyBytes = 10
+	body, err := readBody(strings.NewReader(strings.Repeat("a", 100)))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(body))
+
+	body, err = readBody(strings.NewReader("short"))
+	assert.Nil(t, err)
+	assert.Equal(t, "short", string(body))
+
+	// Body is limited to 1MB without config
+	config.Config.Unfurl.MaxBodyBytes = 0
+	body, err = readBody(strings.NewReader(strings.Repeat("a", 2<<20)))
+	assert.Nil(t, err)
+	assert.Equal(t, 1<<20, len(body))
+}
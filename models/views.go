@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+)
+
+// ViewBeacon is a single view reported by client
+type ViewBeacon struct {
+	Resource string `json:"resource"`
+	ID       int    `json:"id"`
+	MemberID int64  `json:"member_id"`
+}
+
+// viewResource describes where views of a resource are flushed to.
+// Unique visitors are not stored if uniqueField is empty.
+type viewResource struct {
+	table       string
+	idField     string
+	totalField  string
+	uniqueField string
+}
+
+var viewResources = map[string]viewResource{
+	"post":    {table: "posts", idField: "post_id", totalField: "views", uniqueField: "unique_views"},
+	"project": {table: "projects", idField: "project_id", totalField: "views", uniqueField: "unique_views"},
+	"video":   {table: "posts", idField: "post_id", totalField: "video_views"},
+}
+
+// IsBot tells whether userAgent matches any bot pattern in config
+func IsBot(userAgent string) bool {
+	if userAgent == "" {
+		return true
+	}
+	userAgent = strings.ToLower(userAgent)
+	for _, pattern := range config.Config.Views.BotPatterns {
+		if strings.Contains(userAgent, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks resource and id of beacon
+func (b ViewBeacon) Validate() error {
+	if _, ok := viewResources[b.Resource]; !ok {
+		return errors.New("Invalid Resource")
+	}
+	if b.ID <= 0 {
+		return errors.New("Invalid ID")
+	}
+	return nil
+}
+
+type ViewInterface interface {
+	Count(beacons []ViewBeacon, visitor string) error
+	Flush() (flushed int, err error)
+}
+
+type viewAPI struct{}
+
+var ViewAPI ViewInterface = new(viewAPI)
+
+func (a *viewAPI) totalKey(resource string, id int) string {
+	return fmt.Sprintf("views_%s_%d", resource, id)
+}
+
+func (a *viewAPI) uniqueKey(resource string, id int) string {
+	return fmt.Sprintf("uviews_%s_%d", resource, id)
+}
+
+func (a *viewAPI) pendingKey(resource string) string {
+	return fmt.Sprintf("views_pending_%s", resource)
+}
+
+// postViewsHourlyKey is the sorted set of post views within the hour of t, used by trending posts
+func postViewsHourlyKey(t time.Time) string {
+	return fmt.Sprintf("views_hourly_post_%s", t.UTC().Format("2006010215"))
+}
+
+// Count buffers beacons in Redis. Totals are counted with INCR,
+// and unique visitors with HyperLogLog, keyed by member id or visitor when member is absent.
+func (a *viewAPI) Count(beacons []ViewBeacon, visitor string) error {
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	hourly := postViewsHourlyKey(time.Now())
+	conn.Send("MULTI")
+	for _, b := range beacons {
+		if b.Resource == "post" {
+			conn.Send("ZINCRBY", hourly, 1, b.ID)
+		}
+		conn.Send("INCR", a.totalKey(b.Resource, b.ID))
+		if viewResources[b.Resource].uniqueField != "" {
+			unique := visitor
+			if b.MemberID > 0 {
+				unique = fmt.Sprintf("member_%d", b.MemberID)
+			}
+			conn.Send("PFADD", a.uniqueKey(b.Resource, b.ID), unique)
+		}
+		conn.Send("SADD", a.pendingKey(b.Resource), b.ID)
+	}
+	conn.Send("EXPIRE", hourly, (trendingWindowHours()+1)*3600)
+	_, err := conn.Do("EXEC")
+	return err
+}
+
+// Flush writes views buffered since last flush back to MySQL.
+// Totals are added to the stored value, while unique visitors are kept in Redis
+// and overwrite the stored value whenever they grow.
+func (a *viewAPI) Flush() (flushed int, err error) {
+
+	conn := RedisHelper.WriteConn()
+	defer conn.Close()
+
+	for resource, res := range viewResources {
+
+		// Take the pending set away, so views counted during flushing wait for the next one
+		flushing := a.pendingKey(resource) + "_flushing"
+		if _, err = conn.Do("RENAME", a.pendingKey(resource), flushing); err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				err = nil
+				continue
+			}
+			return flushed, err
+		}
+		ids, err := redis.Strings(conn.Do("SMEMBERS", flushing))
+		if err != nil {
+			return flushed, err
+		}
+
+		for _, idString := range ids {
+			id, _ := strconv.Atoi(idString)
+
+			total, err := redis.Int(conn.Do("GETSET", a.totalKey(resource, id), 0))
+			if err != nil && err != redis.ErrNil {
+				log.Printf("Error getting views of %s %d: %v\n", resource, id, err)
+				continue
+			}
+			query := fmt.Sprintf(`UPDATE %s SET %s = IFNULL(%s, 0) + ?`, res.table, res.totalField, res.totalField)
+			args := []interface{}{total}
+			if res.uniqueField != "" {
+				unique, err := redis.Int(conn.Do("PFCOUNT", a.uniqueKey(resource, id)))
+				if err != nil {
+					log.Printf("Error counting unique views of %s %d: %v\n", resource, id, err)
+				}
+				query = fmt.Sprintf(`%s, %s = GREATEST(IFNULL(%s, 0), ?)`, query, res.uniqueField, res.uniqueField)
+				args = append(args, unique)
+			}
+			query = fmt.Sprintf(`%s WHERE %s = ?`, query, res.idField)
+			args = append(args, id)
+
+			if _, err = rrsql.DB.Exec(query, args...); err != nil {
+				// Put views back, so they are not lost until the next flush
+				log.Printf("Error flushing views of %s %d: %v\n", resource, id, err)
+				conn.Send("MULTI")
+				conn.Send("INCRBY", a.totalKey(resource, id), total)
+				conn.Send("SADD", a.pendingKey(resource), id)
+				conn.Do("EXEC")
+				continue
+			}
+			flushed++
+		}
+		conn.Do("DEL", flushing)
+	}
+	return flushed, err
+}
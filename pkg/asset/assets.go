@@ -284,8 +284,8 @@ func (a *assetAPI) Count(args args.ArgsParser) (count int, err error) {
 
 func (m *assetAPI) Delete(ids []int) (err error) {
 
-	query, args, err := sqlx.In(`UPDATE assets SET active = ? AND updated_at = ? WHERE id IN (?);`,
-		rrsql.NullInt{Int: int64(config.Config.Models.Posts["deactive"]), Valid: true},
+	query, args, err := sqlx.In(`UPDATE assets SET active = ?, updated_at = ? WHERE id IN (?);`,
+		rrsql.NullInt{Int: int64(config.Config.Models.Assets["deactive"]), Valid: true},
 		rrsql.NullTime{Time: time.Now(), Valid: true},
 		ids,
 	)
@@ -306,6 +306,9 @@ func (m *assetAPI) Delete(ids []int) (err error) {
 		return errors.New("Assets Not Found")
 	}
 
+	if err = models.TrashAPI.Insert("asset", ids); err != nil {
+		log.Printf("Error recording assets %v to trash: %v\n", ids, err)
+	}
 	return nil
 }
 func (a *assetAPI) FilterAssets(args *FilterAssetArgs) (result []FilteredAsset, err error) {
@@ -145,7 +145,16 @@ func (a *mockNewsCardAPI) UpdateCard(c NewsCard) (err error) {
 	err = errors.New("Card Not Found")
 	for index, value := range a.mockCardDS {
 		if value.ID == c.ID {
+			// Version of a card starts from 1, as the column default
+			current := int64(1)
+			if value.Version.Valid {
+				current = value.Version.Int
+			}
+			if c.Version.Int != current {
+				return rrsql.VersionConflictError
+			}
 			a.mockCardDS[index].Title = c.Title
+			a.mockCardDS[index].Version = rrsql.NullInt{Int: current + 1, Valid: true}
 			err = nil
 			return err
 		}
@@ -224,8 +233,10 @@ func TestRouteCards(t *testing.T) {
 			init:     func() { cardTest.setup(cards) },
 			teardown: func() { cardTest.teardown() },
 			cases: []genericTestcase{
-				genericTestcase{"UpdateOK", "PUT", `/cards`, `{"id":1,"title": "altered card title 01"}`, http.StatusOK, ``},
-				genericTestcase{"NotExisted", "PUT", `/cards`, `{"id":12345, "title":"This should not be updated"}`, http.StatusBadRequest, `{"Error":"Card Not Found"}`},
+				genericTestcase{"UpdateOK", "PUT", `/cards`, `{"id":1,"version":1,"title": "altered card title 01"}`, http.StatusOK, ``},
+				genericTestcase{"NotExisted", "PUT", `/cards`, `{"id":12345,"version":1, "title":"This should not be updated"}`, http.StatusBadRequest, `{"Error":"Card Not Found"}`},
+				genericTestcase{"MissingVersion", "PUT", `/cards`, `{"id":1, "title":"no version"}`, http.StatusPreconditionRequired, `{"Error":"Missing Version"}`},
+				genericTestcase{"StaleVersion", "PUT", `/cards`, `{"id":1,"version":1, "title":"stale"}`, http.StatusConflict, []NewsCard{NewsCard{ID: 1, Title: rrsql.NullString{String: "altered card title 01", Valid: true}}}},
 			},
 		},
 		TestStep{
@@ -27,6 +27,7 @@ type NewsCard struct {
 	Order           rrsql.NullInt    `json:"order" db:"order" redis:"order"`
 	Active          rrsql.NullInt    `json:"active" db:"active" redis:"active"`
 	Status          rrsql.NullInt    `json:"status" db:"status" redis:"status"`
+	Version         rrsql.NullInt    `json:"version" db:"version" redis:"version"`
 }
 
 type NewsCardArgs struct {
@@ -160,6 +161,7 @@ func (a *newscardAPI) GetCards(rowargs *NewsCardArgs) (result []NewsCard, err er
 
 func (a *newscardAPI) InsertCard(n NewsCard) (int, error) {
 
+	n.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", n)
 	query := fmt.Sprintf(`INSERT INTO newscards (%s) VALUES (:%s)`,
 		strings.Join(tags, ","), strings.Join(tags, ",:"))
@@ -191,10 +193,12 @@ func (a *newscardAPI) InsertCard(n NewsCard) (int, error) {
 
 func (a *newscardAPI) UpdateCard(n NewsCard) error {
 
+	version := n.Version
+	n.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", n)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, tags)
-	query := fmt.Sprintf(`UPDATE newscards SET %s WHERE id = :id`,
-		strings.Join(fields, ", "))
+	query := rrsql.Versioned(fmt.Sprintf(`UPDATE newscards SET %s WHERE id = :id`,
+		strings.Join(fields, ", ")), version)
 
 	result, err := rrsql.DB.NamedExec(query, n)
 
@@ -204,6 +208,8 @@ func (a *newscardAPI) UpdateCard(n NewsCard) error {
 	rowCnt, err := result.RowsAffected()
 	if rowCnt > 1 {
 		return errors.New("More Than One Rows Affected")
+	} else if rowCnt == 0 && version.Valid {
+		return rrsql.VersionConflictError
 	} else if rowCnt == 0 {
 		return errors.New("Card Not Found")
 	}
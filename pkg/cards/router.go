@@ -9,7 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/readr-media/readr-restful/config"
+	rt "github.com/readr-media/readr-restful/internal/router"
 	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/pkg/sanitizer"
 )
 
 type newscardHandler struct{}
@@ -117,6 +119,10 @@ func (r *newscardHandler) Post(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Title or CardID"})
 		return
 	}
+	if stripped := r.sanitize(card); len(stripped) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "Content Not Allowed", "stripped": stripped})
+		return
+	}
 
 	// CreatedAt and UpdatedAt set default to now
 	card.CreatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
@@ -157,6 +163,15 @@ func (r *newscardHandler) Put(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Card"})
 		return
 	}
+	if !card.Version.Valid {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"Error": "Missing Version"})
+		return
+	}
+
+	if stripped := r.sanitize(card); len(stripped) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "Content Not Allowed", "stripped": stripped})
+		return
+	}
 
 	// Discard CreatedAt even if there is data
 	if card.CreatedAt.Valid {
@@ -172,6 +187,21 @@ func (r *newscardHandler) Put(c *gin.Context) {
 		case "Card Not Found":
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Card Not Found"})
 			return
+		case rrsql.VersionConflictError.Error():
+			// Respond with the current card, so that editors could merge their changes into it
+			args := DefaultNewsCardArgs()
+			args.IDs, args.Active = []uint32{card.ID}, nil
+			cards, err := NewsCardAPI.GetCards(args)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
+			if len(cards) == 0 {
+				c.JSON(http.StatusNotFound, gin.H{"Error": "Card Not Found"})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"Error": "Version Conflict", "_items": cards})
+			return
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
@@ -201,12 +231,27 @@ func (r *newscardHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// sanitize checks title and description of card with the card policy
+func (r *newscardHandler) sanitize(card NewsCard) (stripped []sanitizer.Stripped) {
+
+	policy := sanitizer.PolicyFor("card")
+	if card.Title.Valid {
+		_, s := sanitizer.Sanitize("title", card.Title.String, policy)
+		stripped = append(stripped, s...)
+	}
+	if card.Description.Valid {
+		_, s := sanitizer.Sanitize("description", card.Description.String, policy)
+		stripped = append(stripped, s...)
+	}
+	return stripped
+}
+
 func (r *newscardHandler) SetRoutes(router *gin.Engine) {
 
 	cardRouter := router.Group("/cards")
 	{
-		cardRouter.GET("/:id", r.Get)
-		cardRouter.GET("", r.GetAll)
+		cardRouter.GET("/:id", rt.Conditional("cards"), r.Get)
+		cardRouter.GET("", rt.Conditional("cards"), r.GetAll)
 		cardRouter.POST("", r.Post)
 		cardRouter.PUT("", r.Put)
 		cardRouter.DELETE("/:id", r.Delete)
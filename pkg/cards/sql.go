@@ -84,10 +84,12 @@ func buildDeleteStmt(postID uint32, cardIDs []uint32) (stmt *rrsql.PipelineStmt,
 }
 
 func buildUpdateStmt(card NewsCard) *rrsql.PipelineStmt {
+	// Cards saved along with post are guarded by version of the post
+	card.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", card)
 	fields := rrsql.MakeFieldString("update", `%s = :%s`, tags)
-	query := fmt.Sprintf(`UPDATE newscards SET %s WHERE id = :id`,
-		strings.Join(fields, ", "))
+	query := rrsql.Versioned(fmt.Sprintf(`UPDATE newscards SET %s WHERE id = :id`,
+		strings.Join(fields, ", ")), rrsql.NullInt{})
 
 	return &rrsql.PipelineStmt{
 		Query:     query,
@@ -102,6 +104,7 @@ func buildInsertStmt(postID uint32, card NewsCard) *rrsql.PipelineStmt {
 		postIDString = strconv.Itoa(int(postID))
 	}
 
+	card.Version = rrsql.NullInt{}
 	tags := rrsql.GetStructDBTags("partial", card)
 	query := fmt.Sprintf(`INSERT INTO newscards (%s) VALUES (:%s)`,
 		strings.Join(tags, ","), strings.Join(tags, ",:"))
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFeed = Feed{
+	Title:       "readr",
+	Link:        "https://www.readr.tw",
+	FeedURL:     "https://www.readr.tw/feeds/posts.rss",
+	Description: "readr posts",
+	Updated:     time.Date(2019, 5, 2, 10, 0, 0, 0, time.UTC),
+	Items: []Item{
+		{
+			ID:         "https://www.readr.tw/post/1",
+			Title:      "first & foremost",
+			Link:       "https://www.readr.tw/post/1",
+			Summary:    "summary <b>1</b>",
+			Image:      "https://www.readr.tw/1.jpg",
+			Authors:    []string{"readr"},
+			Categories: []string{"news"},
+			Published:  time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2019, 5, 2, 10, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+func TestRenderRSS(t *testing.T) {
+
+	body, err := Render(testFeed, FormatRSS)
+	assert.Nil(t, err)
+
+	var result rss
+	assert.Nil(t, xml.Unmarshal(body, &result))
+	assert.Equal(t, "2.0", result.Version)
+	assert.Equal(t, 1, len(result.Channel.Items))
+	assert.Equal(t, "first & foremost", result.Channel.Items[0].Title)
+	assert.Equal(t, "summary <b>1</b>", result.Channel.Items[0].Description)
+	assert.Equal(t, "Wed, 01 May 2019 10:00:00 +0000", result.Channel.Items[0].PubDate)
+	assert.True(t, result.Channel.Items[0].GUID.IsPermaLink)
+	assert.Contains(t, string(body), `<dc:creator>readr</dc:creator>`)
+	assert.NotContains(t, string(body), `<author>`)
+}
+
+func TestRenderAtom(t *testing.T) {
+
+	body, err := Render(testFeed, FormatAtom)
+	assert.Nil(t, err)
+
+	var result atomFeed
+	assert.Nil(t, xml.Unmarshal(body, &result))
+	assert.Equal(t, "2019-05-02T10:00:00Z", result.Updated)
+	assert.Equal(t, 1, len(result.Entries))
+	assert.Equal(t, "https://www.readr.tw/post/1", result.Entries[0].ID)
+	assert.Equal(t, []atomPerson{{Name: "readr"}}, result.Entries[0].Authors)
+	assert.Equal(t, []atomCategory{{Term: "news"}}, result.Entries[0].Categories)
+}
+
+func TestRenderJSON(t *testing.T) {
+
+	body, err := Render(testFeed, FormatJSON)
+	assert.Nil(t, err)
+
+	var result jsonFeed
+	assert.Nil(t, json.Unmarshal(body, &result))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", result.Version)
+	assert.Equal(t, 1, len(result.Items))
+	assert.Equal(t, "2019-05-01T10:00:00Z", result.Items[0].DatePublished)
+	assert.Equal(t, []string{"news"}, result.Items[0].Tags)
+
+	empty, err := Render(Feed{Title: "empty"}, FormatJSON)
+	assert.Nil(t, err)
+	assert.Contains(t, string(empty), `"items":[]`)
+}
+
+func TestRenderUnsupported(t *testing.T) {
+
+	_, err := Render(testFeed, "xml")
+	assert.EqualError(t, err, "Unsupported Feed Format")
+}
@@ -0,0 +1,115 @@
+package jsonld
+
+import (
+	"strings"
+	"time"
+
+	"github.com/readr-media/readr-restful/config"
+)
+
+// Context is the @context of every document
+const Context = "https://schema.org"
+
+// ImageObject is schema.org/ImageObject
+type ImageObject struct {
+	Type string `json:"@type"`
+	URL  string `json:"url"`
+}
+
+// Person is schema.org/Person
+type Person struct {
+	Context     string       `json:"@context,omitempty"`
+	Type        string       `json:"@type"`
+	Name        string       `json:"name"`
+	URL         string       `json:"url,omitempty"`
+	Image       *ImageObject `json:"image,omitempty"`
+	Description string       `json:"description,omitempty"`
+}
+
+// Organization is schema.org/Organization, used as publisher
+type Organization struct {
+	Type string       `json:"@type"`
+	Name string       `json:"name"`
+	URL  string       `json:"url,omitempty"`
+	Logo *ImageObject `json:"logo,omitempty"`
+}
+
+// WebPageElement marks the part of page behind paywall
+type WebPageElement struct {
+	Type                string `json:"@type"`
+	IsAccessibleForFree bool   `json:"isAccessibleForFree"`
+	CSSSelector         string `json:"cssSelector"`
+}
+
+// CreativeWork is shared by schema.org/CreativeWork and its subtypes like NewsArticle
+type CreativeWork struct {
+	Context             string          `json:"@context"`
+	Type                string          `json:"@type"`
+	Headline            string          `json:"headline,omitempty"`
+	Name                string          `json:"name,omitempty"`
+	Description         string          `json:"description,omitempty"`
+	URL                 string          `json:"url,omitempty"`
+	MainEntityOfPage    string          `json:"mainEntityOfPage,omitempty"`
+	Image               []string        `json:"image,omitempty"`
+	DatePublished       string          `json:"datePublished,omitempty"`
+	DateModified        string          `json:"dateModified,omitempty"`
+	Author              []Person        `json:"author,omitempty"`
+	Publisher           *Organization   `json:"publisher,omitempty"`
+	Keywords            string          `json:"keywords,omitempty"`
+	IsAccessibleForFree *bool           `json:"isAccessibleForFree,omitempty"`
+	HasPart             *WebPageElement `json:"hasPart,omitempty"`
+}
+
+// Publisher returns the publisher organization in config
+func Publisher() *Organization {
+
+	publisher := &Organization{
+		Type: "Organization",
+		Name: config.Config.JSONLD.PublisherName,
+		URL:  config.Config.DomainName,
+	}
+	if config.Config.JSONLD.PublisherLogo != "" {
+		publisher.Logo = &ImageObject{Type: "ImageObject", URL: config.Config.JSONLD.PublisherLogo}
+	}
+	return publisher
+}
+
+// Date formats t in ISO 8601, or returns empty string for zero time
+func Date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Images drops empty urls and duplicates in urls
+func Images(urls ...string) (images []string) {
+
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			images = append(images, url)
+		}
+	}
+	return images
+}
+
+// Keywords joins tags into comma separated keywords
+func Keywords(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// Paywall marks work as not accessible for free, with the gated part selected by paywall_selector in config
+func (w *CreativeWork) Paywall(gated bool) {
+
+	free := !gated
+	w.IsAccessibleForFree = &free
+	if gated {
+		w.HasPart = &WebPageElement{
+			Type:                "WebPageElement",
+			IsAccessibleForFree: false,
+			CSSSelector:         config.Config.JSONLD.PaywallSelector,
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaywall(t *testing.T) {
+
+	config.Config.JSONLD.PaywallSelector = ".memo-content"
+
+	free := CreativeWork{Context: Context, Type: "Article"}
+	free.Paywall(false)
+	body, _ := json.Marshal(free)
+	assert.JSONEq(t, `{"@context":"https://schema.org","@type":"Article","isAccessibleForFree":true}`, string(body))
+
+	gated := CreativeWork{Context: Context, Type: "Article"}
+	gated.Paywall(true)
+	body, _ = json.Marshal(gated)
+	assert.JSONEq(t, `{"@context":"https://schema.org","@type":"Article","isAccessibleForFree":false,
+		"hasPart":{"@type":"WebPageElement","isAccessibleForFree":false,"cssSelector":".memo-content"}}`, string(body))
+}
+
+func TestPublisher(t *testing.T) {
+
+	config.Config.DomainName = "https://www.readr.tw"
+	config.Config.JSONLD.PublisherName = "READr"
+	config.Config.JSONLD.PublisherLogo = ""
+	assert.Equal(t, &Organization{Type: "Organization", Name: "READr", URL: "https://www.readr.tw"}, Publisher())
+
+	config.Config.JSONLD.PublisherLogo = "https://www.readr.tw/logo.png"
+	assert.Equal(t, &ImageObject{Type: "ImageObject", URL: "https://www.readr.tw/logo.png"}, Publisher().Logo)
+}
+
+func TestHelpers(t *testing.T) {
+
+	assert.Equal(t, "", Date(time.Time{}))
+	assert.Equal(t, "2019-05-01T10:00:00Z", Date(time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{"a.jpg", "b.jpg"}, Images("a.jpg", "", "b.jpg", "a.jpg"))
+	assert.Nil(t, Images("", ""))
+	assert.Equal(t, "news, politics", Keywords([]string{"news", "politics"}))
+}
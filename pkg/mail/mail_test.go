@@ -77,10 +77,11 @@ func (m *mockMailAPI) SendDailyDigest(s []string) (err error)
 func (m *mockMailAPI) SendProjectUpdateMail(resource interface{}, resourceTyep string) (err error) {
 	return err
 }
-func (m *mockMailAPI) SendCECommentNotify(tmp models.TaggedPostMember) (err error)   { return nil }
-func (m *mockMailAPI) SendReportPublishMail(report models.ReportAuthors) (err error) { return nil }
-func (m *mockMailAPI) SendMemoPublishMail(memo models.MemoDetail) (err error)        { return nil }
-func (m *mockMailAPI) SendFollowProjectMail(args models.FollowArgs) (err error)      { return nil }
+func (m *mockMailAPI) SendCECommentNotify(tmp models.TaggedPostMember) (err error)     { return nil }
+func (m *mockMailAPI) SendReportPublishMail(report models.ReportAuthors) (err error)   { return nil }
+func (m *mockMailAPI) SendMemoPublishMail(memo models.MemoDetail) (err error)          { return nil }
+func (m *mockMailAPI) SendFollowProjectMail(args models.FollowArgs) (err error)        { return nil }
+func (m *mockMailAPI) SendMilestoneMail(milestone models.ProjectMilestone) (err error) { return nil }
 
 func TestRouteEmail(t *testing.T) {
 
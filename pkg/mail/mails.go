@@ -42,6 +42,7 @@ type MailInterface interface {
 	SendReportPublishMail(report models.ReportAuthors) (err error)
 	SendMemoPublishMail(memo models.MemoDetail) (err error)
 	SendFollowProjectMail(args models.FollowArgs) (err error)
+	SendMilestoneMail(milestone models.ProjectMilestone) (err error)
 }
 
 type mailApi struct{}
@@ -763,6 +764,64 @@ func (m *mailApi) SendFollowProjectMail(args models.FollowArgs) (err error) {
 	return nil
 }
 
+type milestoneData struct {
+	ProjectTitle     string
+	ProjectHeroImage string
+	ProjectSlug      string
+	Title            string
+	Description      string
+	DoneAt           string // 2018/07/02
+	Progress         string
+	SettingLink      string
+	MailID           string
+}
+
+func (m *mailApi) SendMilestoneMail(milestone models.ProjectMilestone) (err error) {
+	// newMilestone.html
+	SettingLink := m.GetSettingLink()
+	data := milestoneData{
+		ProjectTitle:     milestone.Project.Title.String,
+		ProjectHeroImage: milestone.Project.HeroImage.String,
+		ProjectSlug:      milestone.Project.Slug.String,
+		Title:            milestone.Title.String,
+		Description:      milestone.Description.String,
+		DoneAt:           milestone.DoneAt.Time.Format("2006/01/02"),
+		Progress:         strconv.FormatFloat(milestone.Project.Progress.Float, 'f', -1, 64),
+		MailID:           fmt.Sprintf("ProjectMilestone_%s", milestone.Project.Slug.String),
+	}
+
+	mailReceiverList, err := m.getProjectFollowerMailList(milestone.Project.ID)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	t := template.Must(template.ParseGlob(fmt.Sprintf("%s/newMilestone.html", config.Config.Mail.TemplatePath)))
+	for k, v := range SettingLink {
+		var mails []string
+		for _, receiver := range mailReceiverList {
+			if receiver.Role == k {
+				mails = append(mails, receiver.Mail)
+			}
+		}
+
+		if len(mails) > 0 {
+			data.SettingLink = v
+
+			buf := new(bytes.Buffer)
+			if err = t.ExecuteTemplate(buf, "newMilestone.html", data); err != nil {
+				return err
+			}
+			err = m.sendToAll(fmt.Sprintf("【%s】專題進度<%s>", data.ProjectTitle, data.Title), buf.String(), mails)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (m *mailApi) getProjectFollowerMailList(id int) (receiveres []mailReceiver, err error) {
 	query := fmt.Sprintf(`
 		SELECT mail, role, IF(p.id IS NOT NULL, true, false) as subed FROM members AS m 
@@ -68,14 +68,16 @@ type PollSerializer struct {
 }
 
 // PollDeserializer is used for polls input Unmarschalling
+// Tags are set by tagging type "poll", so that polls are listed in /tags/pnr
 type PollDeserializer struct {
 	Poll
-	Choices []Choice `json:"choices,omitempty" db:"choices"`
+	Choices []Choice           `json:"choices,omitempty" db:"choices"`
+	Tags    rrsql.NullIntSlice `json:"tags" db:"tags"`
 }
 
 type pollInterface interface {
 	Get(filters *ListPollsFilter) (polls []PollSerializer, err error)
-	Insert(p PollDeserializer) (err error)
+	Insert(p PollDeserializer) (pollID int64, err error)
 	Update(poll Poll) (err error)
 	Count(filters *ListPollsFilter) (count int, err error)
 }
@@ -394,13 +396,13 @@ func (p *pollData) Count(filter *ListPollsFilter) (count int, err error) {
 // This poll could have attached choices, which will be also inserted as well.
 // Insert does not allow empty poll with choices, you have to insert poll first.
 // If it's needed to insert new choice, use choice api instead.
-func (p *pollData) Insert(poll PollDeserializer) (err error) {
+func (p *pollData) Insert(poll PollDeserializer) (pollID int64, err error) {
 
 	pollTags := GetStructTags("full", "db", Poll{})
 	tx, err := rrsql.DB.Beginx()
 	if err != nil {
 		log.Printf("Fail to get sql connection: %v\n", err)
-		return err
+		return 0, err
 	}
 	// Either rollback or commit transaction
 	defer func() {
@@ -417,11 +419,11 @@ func (p *pollData) Insert(poll PollDeserializer) (err error) {
 		strings.Join(pollTags, ","), strings.Join(pollTags, ",:"))
 	pollInserted, err := tx.NamedExec(pollQ, poll.Poll)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	pollID, err := pollInserted.LastInsertId()
+	pollID, err = pollInserted.LastInsertId()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if len(poll.Choices) > 0 {
 		choiceTags := GetStructTags("full", "db", Choice{}, []string{"total_vote", "created_at", "updated_at"})
@@ -435,12 +437,12 @@ func (p *pollData) Insert(poll PollDeserializer) (err error) {
 		for _, choice := range poll.Choices {
 			choice.PollID.Int = pollID
 			choice.PollID.Valid = true
-			if _, err := tx.NamedExec(choiceQ, choice); err != nil {
-				return err
+			if _, err = tx.NamedExec(choiceQ, choice); err != nil {
+				return 0, err
 			}
 		}
 	}
-	return nil
+	return pollID, nil
 }
 
 // Update single row of poll
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
 	rt "github.com/readr-media/readr-restful/internal/router"
+	"github.com/readr-media/readr-restful/models"
 )
 
 type router struct{}
@@ -78,28 +80,41 @@ func (r *router) PostPolls(c *gin.Context) {
 			ValidateChoiceUpdatedAt,
 		)
 	} // --------- End of input validation
-	if err := PollData.Insert(poll); err != nil {
+	pollID, err := PollData.Insert(poll)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
 	}
+	if poll.Tags.Valid {
+		if err = models.TagAPI.UpdateTagging(config.Config.Models.TaggingType["poll"], int(pollID), poll.Tags.Slice); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+	}
 	c.Status(http.StatusCreated)
 }
 
 // PutPolls updates contents of a single poll
 func (r *router) PutPolls(c *gin.Context) {
 
-	poll := Poll{}
+	poll := PollDeserializer{}
 	if err := c.Bind(&poll); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 	// validation sections
 	poll.Validate(ValidatePollUpdatedAt)
-	err := PollData.Update(poll)
+	err := PollData.Update(poll.Poll)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
 	}
+	if poll.Tags.Valid {
+		if err = models.TagAPI.UpdateTagging(config.Config.Models.TaggingType["poll"], int(poll.ID), poll.Tags.Slice); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -19,6 +19,7 @@ type ListParams struct {
 
 	// Status string
 	Active map[string][]int
+	IDs    []uint64 `form:"ids"`
 
 	// Embedded SQLO in the struct
 	// ListParams could be pass through interface because of decoupled Parse()
@@ -103,6 +104,9 @@ func (p *ListParams) Parse() {
 	if p.Sort != "" {
 		p.o.FormatOrderBy(p.Sort)
 	}
+	if len(p.IDs) != 0 {
+		p.o.Where = append(p.o.Where, mysql.SQLsv{Statement: "id IN (?)", Variable: p.IDs})
+	}
 	if len(p.Active) != 0 {
 		// append condition to where statements
 		for operator, values := range p.Active {
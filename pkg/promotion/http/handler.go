@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	rt "github.com/readr-media/readr-restful/internal/router"
+	"github.com/readr-media/readr-restful/internal/rrsql"
 	"github.com/readr-media/readr-restful/pkg/promotion"
 	"github.com/readr-media/readr-restful/pkg/promotion/mysql"
 )
@@ -162,8 +163,16 @@ func (h *Handler) Put(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := (&promo).Validate(promotion.ValidateVersion); err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
 	// fmt.Printf("Promotion Put:%v\n", promo)
 	err := mysql.DataAPI.Update(promo)
+	if err == rrsql.VersionConflictError {
+		h.conflict(c, promo.ID)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -172,6 +181,21 @@ func (h *Handler) Put(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// conflict responds to a stale update with the current promotion, so that editors could merge their changes into it
+func (h *Handler) conflict(c *gin.Context, id uint64) {
+
+	results, err := mysql.DataAPI.Get(&ListParams{IDs: []uint64{id}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "version conflict", "_items": results})
+}
+
 // Delete handles DELETE method by setting active to 0
 func (h *Handler) Delete(c *gin.Context) {
 
@@ -148,7 +148,9 @@ func TestPromotionHandlerPut(t *testing.T) {
 	}{
 		{"empty-payload", http.StatusBadRequest, promotion.Promotion{}, `{"error":"null promotion payload"}`},
 		{"zero-id", http.StatusBadRequest, promotion.Promotion{ID: 0, Status: 1}, `{"error":"invalid promotion id"}`},
-		{"default-promo", http.StatusNoContent, promotion.Promotion{ID: 128, Status: 1, Active: 0, CreatedAt: time.Now().Local(), UpdatedAt: rrsql.NullTime{Time: time.Now().Local(), Valid: true}}, ``},
+		{"default-promo", http.StatusNoContent, promotion.Promotion{ID: 128, Status: 1, Active: 0, CreatedAt: time.Now().Local(), UpdatedAt: rrsql.NullTime{Time: time.Now().Local(), Valid: true}, Version: rrsql.NullInt{Int: 1, Valid: true}}, ``},
+		{"missing-version", http.StatusPreconditionRequired, promotion.Promotion{ID: 128, Status: 1}, `{"error":"missing version"}`},
+		{"stale-version", http.StatusConflict, promotion.Promotion{ID: 128, Status: 1, Version: rrsql.NullInt{Int: 1, Valid: true}}, ``},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
@@ -166,6 +168,12 @@ func TestPromotionHandlerPut(t *testing.T) {
 			if tc.httpcode == http.StatusNoContent {
 				mockData.EXPECT().Update(gomock.Any()).Times(1)
 			}
+			// if the version is stale, expect the current promotion to be responded
+			current := promotion.Promotion{ID: 128, Title: "current", Version: rrsql.NullInt{Int: 2, Valid: true}}
+			if tc.httpcode == http.StatusConflict {
+				mockData.EXPECT().Update(gomock.Any()).Return(rrsql.VersionConflictError).Times(1)
+				mockData.EXPECT().Get(gomock.Any()).Return([]promotion.Promotion{current}, nil).Times(1)
+			}
 
 			r.ServeHTTP(w, req)
 			// Check return http status code
@@ -174,6 +182,13 @@ func TestPromotionHandlerPut(t *testing.T) {
 			if tc.httpcode != http.StatusNoContent && tc.err != `` {
 				assert.Equal(t, w.Body.String(), tc.err)
 			}
+			if tc.httpcode == http.StatusConflict {
+				var resp struct {
+					Items []promotion.Promotion `json:"_items"`
+				}
+				assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, []promotion.Promotion{current}, resp.Items)
+			}
 		})
 	}
 }
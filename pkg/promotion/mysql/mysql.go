@@ -53,6 +53,8 @@ func (a *dataAPI) Count(params promotion.ListParams) (count int, err error) {
 
 func (a *dataAPI) Insert(p promotion.Promotion) (int, error) {
 
+	// Version starts from the column default
+	p.Version = rrsql.NullInt{}
 	// tags is the db tags in Promotion which is simple type or valid Nullable type
 	tags := p.GetTags()
 	query := fmt.Sprintf(`INSERT INTO promotions (%s) VALUES (:%s)`, strings.Join(tags, ","), strings.Join(tags, ",:"))
@@ -85,6 +87,8 @@ func (a *dataAPI) Insert(p promotion.Promotion) (int, error) {
 
 func (a *dataAPI) Update(p promotion.Promotion) error {
 
+	version := p.Version
+	p.Version = rrsql.NullInt{}
 	tags := p.GetTags()
 	// For tags like 'title', create a string 'title = :title'
 	// This is used for UPDATE fields in NamedExec for sqlx
@@ -95,12 +99,15 @@ func (a *dataAPI) Update(p promotion.Promotion) error {
 		}
 		return strings.Join(results, " ,")
 	}(`%s = :%s`, tags)
-	query := fmt.Sprintf(`UPDATE promotions SET %s WHERE id = :id`, fields)
+	query := rrsql.Versioned(fmt.Sprintf(`UPDATE promotions SET %s WHERE id = :id`, fields), version)
 
-	_, err := rrsql.DB.NamedExec(query, p)
+	result, err := rrsql.DB.NamedExec(query, p)
 	if err != nil {
 		return err
 	}
+	if rowCnt, err := result.RowsAffected(); err == nil && rowCnt == 0 && version.Valid {
+		return rrsql.VersionConflictError
+	}
 	return nil
 }
 
@@ -22,6 +22,7 @@ type Promotion struct {
 	UpdatedAt   rrsql.NullTime   `json:"updated_at" db:"updated_at"`
 	UpdatedBy   rrsql.NullString `json:"updated_by" db:"updated_by"`
 	PublishedAt rrsql.NullTime   `json:"published_at" db:"published_at"`
+	Version     rrsql.NullInt    `json:"version" db:"version"`
 }
 
 // ListParams setup the interface that could be passed to Get() in DataLayer
@@ -34,6 +34,15 @@ func ValidateID(p *Promotion) error {
 	return nil
 }
 
+// ValidateVersion checks if version of the edited promotion is given
+func ValidateVersion(p *Promotion) error {
+
+	if !p.Version.Valid {
+		return errors.New("missing version")
+	}
+	return nil
+}
+
 // ValidateTitle checks if title is given
 func ValidateTitle(p *Promotion) error {
 
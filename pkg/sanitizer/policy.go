@@ -0,0 +1,39 @@
+package sanitizer
+
+import (
+	"github.com/readr-media/readr-restful/config"
+)
+
+// DefaultPolicyName is used when there is no policy set for a resource type
+const DefaultPolicyName = "default"
+
+// PolicyFor returns the policy configured for name, which is a post type name like "news" or "card".
+// It falls back to the default policy if name is not configured.
+func PolicyFor(name string) Policy {
+
+	if p, ok := config.Config.Sanitizer.Policies[name]; ok {
+		return Policy(p)
+	}
+	return Policy(config.Config.Sanitizer.Policies[DefaultPolicyName])
+}
+
+// PostTypeName maps the post type value back to its name in config
+func PostTypeName(postType int64) string {
+
+	for name, value := range config.Config.Models.PostType {
+		if int64(value) == postType {
+			return name
+		}
+	}
+	return DefaultPolicyName
+}
+
+// ScanCSS checks css against the css blocklist in config
+func ScanCSS(field string, css string) []Stripped {
+	return Scan(field, "css", css, config.Config.Sanitizer.CSSBlocklist)
+}
+
+// ScanJS checks javascript against the js blocklist in config
+func ScanJS(field string, js string) []Stripped {
+	return Scan(field, "javascript", js, config.Config.Sanitizer.JSBlocklist)
+}
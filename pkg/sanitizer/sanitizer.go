@@ -0,0 +1,192 @@
+package sanitizer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Stripped records a single piece of input which is not allowed by the policy
+type Stripped struct {
+	Field  string `json:"field"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Policy maps allowed tag names to their allowed attributes
+type Policy map[string][]string
+
+// rawContentTags are tags whose whole content is dropped together with the tag
+var rawContentTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+}
+
+// urlAttributes are attributes that have to be checked against unsafe schemes
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"srcset":     true,
+}
+
+// safeSchemes are schemes allowed in url attributes besides relative urls
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// safeURL tells whether val of url attribute name is relative or of safe schemes.
+// ASCII whitespace and control characters are removed before parsing, since browsers ignore them in schemes.
+func safeURL(name, val string) bool {
+
+	candidates := []string{val}
+	if name == "srcset" {
+		candidates = candidates[:0]
+		for _, candidate := range strings.Split(val, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				candidates = append(candidates, fields[0])
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		cleaned := strings.Map(func(r rune) rune {
+			if r <= 0x20 || r == 0x7f {
+				return -1
+			}
+			return r
+		}, candidate)
+		u, err := url.Parse(cleaned)
+		if err != nil || (u.Scheme != "" && !safeSchemes[u.Scheme]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Policy) allowTag(tag string) bool {
+	_, ok := p[tag]
+	return ok
+}
+
+func (p Policy) allowAttr(tag, attr string) bool {
+	for _, allowed := range p[tag] {
+		if allowed == attr || allowed == "*" {
+			return true
+		}
+	}
+	// Attributes listed under "*" apply to every allowed tag
+	for _, allowed := range p["*"] {
+		if allowed == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitize walks through the HTML in input and removes every tag and attribute not allowed by policy.
+// It returns the cleaned HTML and everything stripped from the input.
+func Sanitize(field string, input string, policy Policy) (result string, stripped []Stripped) {
+
+	var (
+		buf       bytes.Buffer
+		skipDepth int
+		skipTag   string
+		seen      = make(map[Stripped]bool)
+	)
+	record := func(s Stripped) {
+		if !seen[s] {
+			seen[s] = true
+			stripped = append(stripped, s)
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				record(Stripped{Field: field, Kind: "html", Name: "malformed", Reason: z.Err().Error()})
+			}
+			break
+		}
+		token := z.Token()
+
+		// Drop everything inside tags like <script> until the matching end tag
+		if skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && token.Data == skipTag:
+				skipDepth++
+			case tt == html.EndTagToken && token.Data == skipTag:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if !policy.allowTag(token.Data) || token.Data == "*" {
+				record(Stripped{Field: field, Kind: "tag", Name: token.Data, Reason: "tag not allowed"})
+				if rawContentTags[token.Data] && tt == html.StartTagToken {
+					skipDepth, skipTag = 1, token.Data
+				}
+				continue
+			}
+			attrs := make([]html.Attribute, 0, len(token.Attr))
+			for _, attr := range token.Attr {
+				name := strings.ToLower(attr.Key)
+				switch {
+				case strings.HasPrefix(name, "on"):
+					record(Stripped{Field: field, Kind: "attribute", Name: fmt.Sprintf("%s.%s", token.Data, name), Reason: "event handler not allowed"})
+				case !policy.allowAttr(token.Data, name):
+					record(Stripped{Field: field, Kind: "attribute", Name: fmt.Sprintf("%s.%s", token.Data, name), Reason: "attribute not allowed"})
+				case urlAttributes[name] && !safeURL(name, attr.Val):
+					record(Stripped{Field: field, Kind: "attribute", Name: fmt.Sprintf("%s.%s", token.Data, name), Reason: "unsafe url scheme"})
+				default:
+					attrs = append(attrs, attr)
+				}
+			}
+			token.Attr = attrs
+			buf.WriteString(token.String())
+		case html.EndTagToken:
+			if policy.allowTag(token.Data) {
+				buf.WriteString(token.String())
+			}
+		case html.TextToken:
+			buf.WriteString(token.String())
+		case html.CommentToken, html.DoctypeToken:
+			// Comments and doctype are never kept in content
+		}
+	}
+	return buf.String(), stripped
+}
+
+// Scan checks code in input against the blocklist patterns.
+// kind is used to label the result, such as "css" or "javascript".
+func Scan(field string, kind string, input string, blocklist []string) (stripped []Stripped) {
+
+	for _, pattern := range blocklist {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			continue
+		}
+		if match := re.FindString(input); match != "" {
+			stripped = append(stripped, Stripped{Field: field, Kind: kind, Name: match, Reason: fmt.Sprintf("matches blocked pattern %s", pattern)})
+		}
+	}
+	return stripped
+}
@@ -0,0 +1,89 @@
+package sanitizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+
+	policy := Policy{
+		"*":   []string{"class"},
+		"p":   []string{},
+		"a":   []string{"href"},
+		"img": []string{"srcset"},
+	}
+	for _, tc := range []struct {
+		name     string
+		input    string
+		result   string
+		stripped []Stripped
+	}{
+		{"allowed", `<p class="lead">hello <a href="https://www.readr.tw">readr</a></p>`, `<p class="lead">hello <a href="https://www.readr.tw">readr</a></p>`, nil},
+		{"script", `<p>hi</p><script>alert(1)</script>`, `<p>hi</p>`, []Stripped{
+			{Field: "content", Kind: "tag", Name: "script", Reason: "tag not allowed"},
+		}},
+		{"unknown-tag-keeps-text", `<span>text</span>`, `text`, []Stripped{
+			{Field: "content", Kind: "tag", Name: "span", Reason: "tag not allowed"},
+		}},
+		{"event-handler", `<p onclick="x()">a</p>`, `<p>a</p>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "p.onclick", Reason: "event handler not allowed"},
+		}},
+		{"attribute", `<p style="color:red">a</p>`, `<p>a</p>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "p.style", Reason: "attribute not allowed"},
+		}},
+		{"javascript-url", `<a href=" JavaScript:alert(1)">a</a>`, `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"javascript-url-tab", "<a href=\"java\tscript:alert(1)\">a</a>", `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"javascript-url-entity", `<a href="jav&#x09;ascript:alert(1)">a</a>`, `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"javascript-url-newline", `<a href="&#x01;java&#x0A;script&#x0D;:alert(1)">a</a>`, `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"vbscript-url", `<a href="VBScript:msgbox(1)">a</a>`, `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"data-url", `<a href="data:text/html;base64,PHNjcmlwdD4=">a</a>`, `<a>a</a>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "a.href", Reason: "unsafe url scheme"},
+		}},
+		{"srcset", `<img srcset="/a.jpg 1x, javascript:alert(1) 2x">`, `<img>`, []Stripped{
+			{Field: "content", Kind: "attribute", Name: "img.srcset", Reason: "unsafe url scheme"},
+		}},
+		{"safe-urls", `<a href="/post/1">a</a><a href="mailto:readr@readr.tw">b</a><a href="#top">c</a><img srcset="/a.jpg 1x, https://www.readr.tw/b.jpg 2x">`,
+			`<a href="/post/1">a</a><a href="mailto:readr@readr.tw">b</a><a href="#top">c</a><img srcset="/a.jpg 1x, https://www.readr.tw/b.jpg 2x">`, nil},
+		{"comment", `<p>a<!-- hidden --></p>`, `<p>a</p>`, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, stripped := Sanitize("content", tc.input, policy)
+			assert.Equal(t, tc.result, result)
+			assert.Equal(t, tc.stripped, stripped)
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+
+	blocklist := []string{`eval\s*\(`, `document\.cookie`}
+	for _, tc := range []struct {
+		name     string
+		input    string
+		stripped []Stripped
+	}{
+		{"clean", `console.log("ok")`, nil},
+		{"eval", `EVAL ("1")`, []Stripped{
+			{Field: "js", Kind: "javascript", Name: "EVAL (", Reason: `matches blocked pattern eval\s*\(`},
+		}},
+		{"cookie", `var c = document.cookie`, []Stripped{
+			{Field: "js", Kind: "javascript", Name: "document.cookie", Reason: `matches blocked pattern document\.cookie`},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.stripped, Scan("js", "javascript", tc.input, blocklist))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs is the limit of urls in a single sitemap file defined by sitemaps.org
+const MaxURLs = 50000
+
+const (
+	sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	newsNS    = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+// URL is a single location in sitemap
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// NewsURL is a single article in news sitemap
+type NewsURL struct {
+	Loc       string
+	Title     string
+	Published time.Time
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name  `xml:"sitemapindex"`
+	NS       string    `xml:"xmlns,attr"`
+	Sitemaps []xmlLink `xml:"sitemap"`
+}
+
+type urlSet struct {
+	XMLName xml.Name  `xml:"urlset"`
+	NS      string    `xml:"xmlns,attr"`
+	NewsNS  string    `xml:"xmlns:news,attr,omitempty"`
+	URLs    []xmlLink `xml:"url"`
+}
+
+type xmlLink struct {
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+	News    *xmlNews `xml:"news:news,omitempty"`
+}
+
+type xmlNews struct {
+	Publication struct {
+		Name     string `xml:"news:name"`
+		Language string `xml:"news:language"`
+	} `xml:"news:publication"`
+	PublicationDate string `xml:"news:publication_date"`
+	Title           string `xml:"news:title"`
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Index renders a sitemap index which points to each child sitemap
+func Index(sitemaps []URL) ([]byte, error) {
+
+	index := sitemapIndex{NS: sitemapNS, Sitemaps: make([]xmlLink, 0, len(sitemaps))}
+	for _, s := range sitemaps {
+		index.Sitemaps = append(index.Sitemaps, xmlLink{Loc: s.Loc, LastMod: lastMod(s.LastMod)})
+	}
+	return marshal(index)
+}
+
+// URLSet renders a sitemap listing urls
+func URLSet(urls []URL) ([]byte, error) {
+
+	set := urlSet{NS: sitemapNS, URLs: make([]xmlLink, 0, len(urls))}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, xmlLink{Loc: u.Loc, LastMod: lastMod(u.LastMod)})
+	}
+	return marshal(set)
+}
+
+// News renders a Google News sitemap for articles published by publication in language
+func News(publication string, language string, urls []NewsURL) ([]byte, error) {
+
+	set := urlSet{NS: sitemapNS, NewsNS: newsNS, URLs: make([]xmlLink, 0, len(urls))}
+	for _, u := range urls {
+		news := xmlNews{PublicationDate: lastMod(u.Published), Title: u.Title}
+		news.Publication.Name = publication
+		news.Publication.Language = language
+		set.URLs = append(set.URLs, xmlLink{Loc: u.Loc, News: &news})
+	}
+	return marshal(set)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,47 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+
+	body, err := Index([]URL{
+		{Loc: "https://www.readr.tw/sitemaps/posts-1.xml", LastMod: time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Loc: "https://www.readr.tw/sitemaps/tags-1.xml"},
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(body), `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, string(body), `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, string(body), `<loc>https://www.readr.tw/sitemaps/posts-1.xml</loc>`)
+	assert.Contains(t, string(body), `<lastmod>2019-05-01T10:00:00Z</lastmod>`)
+	assert.Equal(t, 1, strings.Count(string(body), "<lastmod>"))
+}
+
+func TestURLSet(t *testing.T) {
+
+	body, err := URLSet([]URL{{Loc: "https://www.readr.tw/post/1?a=1&b=2", LastMod: time.Date(2019, 5, 1, 18, 0, 0, 0, time.FixedZone("CST", 8*3600))}})
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, string(body), `<loc>https://www.readr.tw/post/1?a=1&amp;b=2</loc>`)
+	assert.Contains(t, string(body), `<lastmod>2019-05-01T10:00:00Z</lastmod>`)
+
+	empty, err := URLSet(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, string(empty), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`)
+}
+
+func TestNews(t *testing.T) {
+
+	body, err := News("READr", "zh-tw", []NewsURL{{Loc: "https://www.readr.tw/post/1", Title: "title", Published: time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)}})
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"`)
+	assert.Contains(t, string(body), `<news:name>READr</news:name>`)
+	assert.Contains(t, string(body), `<news:language>zh-tw</news:language>`)
+	assert.Contains(t, string(body), `<news:publication_date>2019-05-01T10:00:00Z</news:publication_date>`)
+	assert.Contains(t, string(body), `<news:title>title</news:title>`)
+}
@@ -0,0 +1,161 @@
+package textstats
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// CJKPerMinute is the number of CJK characters read in one minute
+	CJKPerMinute = 400
+	// WordsPerMinute is the number of space-separated words read in one minute
+	WordsPerMinute = 230
+	// SecondsPerImage is the time spent on looking at one image
+	SecondsPerImage = 12
+)
+
+// embedTags are elements counted as embedded media
+var embedTags = map[string]bool{
+	"iframe": true,
+	"video":  true,
+	"audio":  true,
+	"embed":  true,
+	"object": true,
+}
+
+// embedClasses are blockquote classes which embed scripts turn into widgets
+var embedClasses = []string{"twitter-tweet", "instagram-media", "tiktok-embed"}
+
+// skippedTags are elements whose text is never read
+var skippedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+}
+
+// Stats holds text statistics of a piece of HTML
+type Stats struct {
+	Characters int
+	CJK        int
+	Words      int
+	Images     int
+	Embeds     int
+}
+
+// IsCJK reports whether r is read as a word by itself, which includes Han, Kana and Hangul
+func IsCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// Text counts characters and words of plain text.
+// Every CJK character counts as one word, since there is no space between them.
+func Text(text string) (s Stats) {
+
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case IsCJK(r):
+			s.CJK++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if !inWord {
+				s.Words++
+			}
+			inWord = true
+		default:
+			// Punctuation breaks words, except the ones inside words like "don't" or "3.14"
+			if r != '\'' && r != '.' && r != '-' && r != '’' {
+				inWord = false
+			}
+		}
+		s.Characters++
+	}
+	s.Words += s.CJK
+	return s
+}
+
+// HTML counts text, images and embeds of HTML content
+func HTML(content string) (s Stats) {
+
+	var text strings.Builder
+	skipping := 0
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			s.Add(Text(text.String()))
+			return s
+		case html.TextToken:
+			if skipping == 0 {
+				text.Write(z.Text())
+				text.WriteByte(' ')
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if skippedTags[token.Data] && token.Type == html.StartTagToken {
+				skipping++
+			}
+			switch {
+			case token.Data == "img":
+				s.Images++
+			case embedTags[token.Data]:
+				s.Embeds++
+			case token.Data == "blockquote" && hasClass(token, embedClasses):
+				s.Embeds++
+			}
+			// Block elements separate words
+			text.WriteByte(' ')
+		case html.EndTagToken:
+			if name, _ := z.TagName(); skippedTags[string(name)] && skipping > 0 {
+				skipping--
+			}
+			text.WriteByte(' ')
+		}
+	}
+}
+
+func hasClass(token html.Token, classes []string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		for _, class := range strings.Fields(attr.Val) {
+			for _, c := range classes {
+				if class == c {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Add accumulates o into s
+func (s *Stats) Add(o Stats) {
+	s.Characters += o.Characters
+	s.CJK += o.CJK
+	s.Words += o.Words
+	s.Images += o.Images
+	s.Embeds += o.Embeds
+}
+
+// ReadingTime estimates minutes to read, rounded up.
+// CJK characters and other words are read at different speed.
+func (s Stats) ReadingTime() int {
+
+	if s.Characters == 0 && s.Images == 0 {
+		return 0
+	}
+	minutes := float64(s.CJK)/float64(CJKPerMinute) +
+		float64(s.Words-s.CJK)/float64(WordsPerMinute) +
+		float64(s.Images*SecondsPerImage)/60
+	return int(math.Max(1, math.Ceil(minutes)))
+}
@@ -0,0 +1,41 @@
+package textstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+
+	for _, tc := range []struct {
+		name  string
+		text  string
+		stats Stats
+	}{
+		{"Empty", "  ", Stats{}},
+		{"English", "Don't panic, it's 3.14 only.", Stats{Characters: 24, Words: 5}},
+		{"Chinese", "讀者，你好。", Stats{Characters: 6, CJK: 4, Words: 4}},
+		{"Mixed", "READr 是新聞平台 2019", Stats{Characters: 14, CJK: 5, Words: 7}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.stats, Text(tc.text))
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+
+	content := `<p>新聞<b>內容</b></p><script>var a = "忽略";</script>
+		<img src="a.jpg"><iframe src="https://www.youtube.com/embed/x"></iframe>
+		<blockquote class="twitter-tweet"><p>tweet</p></blockquote><p>one<br>two</p>`
+	assert.Equal(t, Stats{Characters: 15, CJK: 4, Words: 7, Images: 1, Embeds: 2}, HTML(content))
+}
+
+func TestReadingTime(t *testing.T) {
+
+	assert.Equal(t, 0, Stats{}.ReadingTime())
+	assert.Equal(t, 1, Stats{Characters: 10, CJK: 10, Words: 10}.ReadingTime())
+	assert.Equal(t, 3, Stats{Characters: 1200, CJK: 800, Words: 1030}.ReadingTime())
+	assert.Equal(t, 2, Stats{Images: 6}.ReadingTime())
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -28,9 +29,9 @@ func (c *mockCommentAPI) GetComments(args *models.GetCommentArgs) (result []mode
 		// models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
 		// models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
 		// models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}, Status: rrsql.NullInt{int64(models.CommentStatus["hide"].(float64)), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
+		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
+		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
+		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
 	}
 
 	switch len(args.Author) {
@@ -47,7 +48,7 @@ func (c *mockCommentAPI) GetComments(args *models.GetCommentArgs) (result []mode
 func (c *mockCommentAPI) GetComment(id int) (comment models.CommentAuthor, err error) {
 	if id == 1 {
 		// return models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"pi1", true}, rrsql.NullInt{2, true}, rrsql.NullInt{0, true}}, nil
-		return models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"pi1", true}, rrsql.NullInt{2, true}, rrsql.NullInt{0, true}}, nil
+		return models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"pi1", true}, rrsql.NullInt{2, true}, rrsql.NullInt{0, true}, nil}, nil
 	} else {
 		return comment, errors.New("Comment Not Found")
 	}
@@ -64,9 +65,9 @@ func (c *mockCommentAPI) GetReportedComments(args *models.GetReportedCommentArgs
 		// models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
 		// models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}, IP: rrsql.NullString{"5.6.7.8", true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"pi2", true}, rrsql.NullInt{3, true}, rrsql.NullInt{0, true}},
 		// models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}, Status: rrsql.NullInt{int64(models.CommentStatus["hide"].(float64)), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, IP: rrsql.NullString{"5.6.7.8", true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"pi2", true}, rrsql.NullInt{3, true}, rrsql.NullInt{0, true}},
-		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
+		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
+		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, IP: rrsql.NullString{"5.6.7.8", true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"pi2", true}, rrsql.NullInt{3, true}, rrsql.NullInt{0, true}, nil},
+		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", true}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
 	}
 
 	var mockReports = []models.ReportedComment{
@@ -99,6 +100,22 @@ func (c *mockCommentAPI) UpdateCommentAmountByResource(resourceName string, reso
 }
 func (c *mockCommentAPI) UpdateAllCommentAmount() (err error) { return err }
 
+type mockCommentLinkAPI struct {
+	links map[int64][]string
+}
+
+func (m *mockCommentLinkAPI) SetLinks(commentID int64, urls []string) error {
+	if m.links == nil {
+		m.links = make(map[int64][]string)
+	}
+	m.links[commentID] = urls
+	return nil
+}
+func (m *mockCommentLinkAPI) GetLinks(commentIDs []int64) (map[int64][]models.CommentLink, error) {
+	return map[int64][]models.CommentLink{}, nil
+}
+func (m *mockCommentLinkAPI) Enrich(limit int) (int, error) { return 0, nil }
+
 func TestRouteComments(t *testing.T) {
 
 	var mockComments = []models.InsertCommentArgs{
@@ -114,9 +131,9 @@ func TestRouteComments(t *testing.T) {
 		// models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
 		// models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
 		// models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(models.CommentActive["active"].(float64)), true}, Status: rrsql.NullInt{int64(models.CommentStatus["hide"].(float64)), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
-		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}},
+		models.CommentAuthor{models.Comment{ID: 1, Body: rrsql.NullString{"Comment No.1", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{91, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest1", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
+		models.CommentAuthor{models.Comment{ID: 2, Body: rrsql.NullString{"Comment No.2", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/91", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
+		models.CommentAuthor{models.Comment{ID: 3, Body: rrsql.NullString{"Comment No.3", true}, Resource: rrsql.NullString{"http://dev.readr.tw/post/90", true}, Author: rrsql.NullInt{92, true}, Active: rrsql.NullInt{int64(config.Config.Models.Comment["active"]), true}, Status: rrsql.NullInt{int64(config.Config.Models.CommentStatus["hide"]), true}}, rrsql.NullString{"commenttest2", true}, rrsql.NullString{"", false}, rrsql.NullInt{0, false}, rrsql.NullInt{0, false}, nil},
 	}
 
 	var mockReports = []models.ReportedComment{
@@ -150,7 +167,7 @@ func TestRouteComments(t *testing.T) {
 		models.Project{ID: 920, PostID: 91, Active: rrsql.NullInt{1, true}, UpdatedAt: rrsql.NullTime{time.Date(2015, time.November, 10, 23, 0, 0, 0, time.UTC), true}, PublishStatus: rrsql.NullInt{2, true}},
 		models.Project{ID: 921, PostID: 92, Active: rrsql.NullInt{1, true}, UpdatedAt: rrsql.NullTime{time.Date(2016, time.November, 10, 23, 0, 0, 0, time.UTC), true}, PublishStatus: rrsql.NullInt{2, true}},
 	} {
-		err := models.ProjectAPI.InsertProject(params)
+		_, err := models.ProjectAPI.InsertProject(params)
 		if err != nil {
 			log.Printf("Insert Project fail when init test case. Error: %v", err)
 		}
@@ -283,6 +300,26 @@ func TestRouteComments(t *testing.T) {
 			genericDoTest(transformPubsub(testcase), t, asserter)
 		}
 	})
+	t.Run("SetCommentLinks", func(t *testing.T) {
+		backup := models.CommentLinkAPI
+		mock := &mockCommentLinkAPI{}
+		models.CommentLinkAPI = mock
+		defer func() { models.CommentLinkAPI = backup }()
+
+		for _, tc := range []struct {
+			testcase genericTestcase
+			id       int64
+			links    []string
+		}{
+			{genericTestcase{"InsertCommentWithUrlsOK", "post", "/comment", `{"body":"https://www.readr.tw/post/274 and http://news.ltn.com.tw/news/focus/paper/1191781?a=1&b=2","resource":"http://dev.readr.tw/post/90","author":91,"resource_name":"post","resource_id":90}`, http.StatusOK, ``}, 0, []string{"https://www.readr.tw/post/274", "http://news.ltn.com.tw/news/focus/paper/1191781?a=1&b=2"}},
+			{genericTestcase{"UpdateCommentRemoveUrlsOK", "put", "/comment", `{"id":1, "body":"no more links"}`, http.StatusOK, ``}, 1, nil},
+		} {
+			genericDoTest(transformPubsub(tc.testcase), t, asserterDummy)
+			if links, ok := mock.links[tc.id]; !ok || !reflect.DeepEqual(links, tc.links) {
+				t.Errorf("%s expect links %v but get %v", tc.testcase.name, tc.links, links)
+			}
+		}
+	})
 	t.Run("UpdateComment", func(t *testing.T) {
 		for _, testcase := range []genericTestcase{
 			genericTestcase{"UpdateCommentOK", "put", "/comment", `{"id":1, "body":"modified"}`, http.StatusOK, ``},
@@ -356,7 +393,7 @@ func TestPubsubComments(t *testing.T) {
 		models.Project{ID: 920, PostID: 91, Active: rrsql.NullInt{1, true}, UpdatedAt: rrsql.NullTime{time.Date(2015, time.November, 10, 23, 0, 0, 0, time.UTC), true}, PublishStatus: rrsql.NullInt{2, true}, Slug: rrsql.NullString{"slug920", true}},
 		models.Project{ID: 921, PostID: 92, Active: rrsql.NullInt{1, true}, UpdatedAt: rrsql.NullTime{time.Date(2016, time.November, 10, 23, 0, 0, 0, time.UTC), true}, PublishStatus: rrsql.NullInt{2, true}, Slug: rrsql.NullString{"slug921", true}},
 	} {
-		err := models.ProjectAPI.InsertProject(params)
+		_, err := models.ProjectAPI.InsertProject(params)
 		if err != nil {
 			log.Printf("Insert Project fail when init test case. Error: %v", err)
 		}
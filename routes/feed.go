@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/feed"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+type feedHandler struct{}
+
+// feedScope narrows feed down to a tag, a project or an author
+type feedScope struct {
+	Tagging   int64 `form:"tagging"`
+	ProjectID int64 `form:"project_id"`
+	Author    int64 `form:"author"`
+}
+
+func (s feedScope) String() string {
+	return fmt.Sprintf("tagging=%d&project_id=%d&author=%d", s.Tagging, s.ProjectID, s.Author)
+}
+
+func (s feedScope) query() string {
+	params := make([]string, 0)
+	if s.Tagging != 0 {
+		params = append(params, fmt.Sprintf("tagging=%d", s.Tagging))
+	}
+	if s.ProjectID != 0 {
+		params = append(params, fmt.Sprintf("project_id=%d", s.ProjectID))
+	}
+	if s.Author != 0 {
+		params = append(params, fmt.Sprintf("author=%d", s.Author))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(params, "&")
+}
+
+func (r *feedHandler) postArgs(scope feedScope) *models.PostArgs {
+
+	return models.NewPostArgs(func(args *models.PostArgs) {
+		args.MaxResult = 20
+		if config.Config.Feed.MaxItems > 0 {
+			args.MaxResult = config.Config.Feed.MaxItems
+		}
+		args.Sorting = "-published_at"
+		args.ShowAuthor = true
+		args.ShowTag = true
+		args.Active = map[string][]int{"$in": []int{config.Config.Models.Posts["active"]}}
+		args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.PostPublishStatus["publish"]}}
+		args.ProjectID = -1
+		if scope.ProjectID != 0 {
+			args.ProjectID = scope.ProjectID
+		}
+		args.Tagging = scope.Tagging
+		args.Credited = scope.Author
+	})
+}
+
+func (r *feedHandler) build(format string, scope feedScope) (result feed.Feed, err error) {
+
+	posts, err := models.PostAPI.GetPosts(r.postArgs(scope))
+	if err != nil {
+		return result, err
+	}
+
+	result = feed.Feed{
+		Title:       config.Config.Feed.Title,
+		Link:        config.Config.DomainName,
+		FeedURL:     fmt.Sprintf("%s/feeds/posts.%s%s", config.Config.DomainName, format, scope.query()),
+		Description: config.Config.Feed.Description,
+	}
+	for _, post := range posts {
+		link := utils.GenerateResourceInfo("post", int(post.ID), "")
+		item := feed.Item{
+			ID:        link,
+			Title:     post.Title.String,
+			Link:      link,
+			Summary:   r.summary(post),
+			Image:     post.HeroImage.String,
+			Published: post.PublishedAt.Time,
+			Updated:   post.UpdatedAt.Time,
+		}
+		if !post.UpdatedAt.Valid {
+			item.Updated = post.PublishedAt.Time
+		}
+		for _, author := range post.Authors {
+			if author.Nickname.Valid {
+				item.Authors = append(item.Authors, author.Nickname.String)
+			}
+		}
+		for _, tag := range post.Tags {
+			item.Categories = append(item.Categories, tag.Text)
+		}
+		if item.Updated.After(result.Updated) {
+			result.Updated = item.Updated
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// summary cuts abstract from post content, and falls back to og_description
+func (r *feedHandler) summary(post models.TaggedPostMember) string {
+
+	length := config.Config.Feed.AbstractLength
+	if length <= 0 {
+		length = 100
+	}
+	if post.Content.Valid && post.Content.String != "" {
+		abstract, err := utils.CutAbstract(post.Content.String, length, func(abstract string) string { return abstract })
+		if err == nil && abstract != "" {
+			return abstract
+		}
+	}
+	return post.OgDescription.String
+}
+
+func (r *feedHandler) render(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var scope feedScope
+		if err := c.ShouldBindQuery(&scope); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Scope"})
+			return
+		}
+
+		key := models.FeedCache.Key(format, scope.String())
+		cached, ok := models.FeedCache.Get(key)
+		if !ok {
+			f, err := r.build(format, scope)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
+			body, err := feed.Render(f, format)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
+			cached = models.CachedFeed{Body: body, ETag: fmt.Sprintf(`"%x"`, sha1.Sum(body))}
+			if !f.Updated.IsZero() {
+				cached.LastModified = f.Updated.UTC().Format(http.TimeFormat)
+			}
+			if err = models.FeedCache.Set(key, cached); err != nil {
+				log.Printf("Feed %s is served without cache: %v\n", key, err)
+			}
+		}
+
+		c.Header("ETag", cached.ETag)
+		if cached.LastModified != "" {
+			c.Header("Last-Modified", cached.LastModified)
+		}
+		if r.notModified(c, cached) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, feed.ContentTypes[format], cached.Body)
+	}
+}
+
+// notModified checks If-None-Match first, and If-Modified-Since only when If-None-Match is absent
+func (r *feedHandler) notModified(c *gin.Context, cached models.CachedFeed) bool {
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, etag := range strings.Split(match, ",") {
+			if etag = strings.TrimSpace(etag); etag == cached.ETag || etag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since := c.GetHeader("If-Modified-Since"); since != "" && cached.LastModified != "" {
+		sinceTime, err := time.Parse(http.TimeFormat, since)
+		if err != nil {
+			return false
+		}
+		modified, err := time.Parse(http.TimeFormat, cached.LastModified)
+		if err != nil {
+			return false
+		}
+		return !modified.After(sinceTime)
+	}
+	return false
+}
+
+func (r *feedHandler) SetRoutes(router *gin.Engine) {
+
+	feedRouter := router.Group("/feeds")
+	{
+		for _, format := range []string{feed.FormatRSS, feed.FormatAtom, feed.FormatJSON} {
+			feedRouter.GET("/posts."+format, r.render(format))
+		}
+	}
+}
+
+var FeedHandler feedHandler
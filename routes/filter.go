@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
 	rt "github.com/readr-media/readr-restful/internal/router"
 	"github.com/readr-media/readr-restful/models"
 	"github.com/readr-media/readr-restful/pkg/asset"
@@ -47,6 +48,17 @@ func (r *filterHandler) bindQuery(c *gin.Context, args *models.FilterArgs) (err
 	if c.Query("author") != "" {
 		args.Author = strings.Split(c.Query("author"), ",")
 	}
+	if c.Query("author_type") != "" {
+		// Credit types are given by name, such as author_type=writer,photographer
+		args.AuthorType = make([]int, 0)
+		for _, name := range strings.Split(c.Query("author_type"), ",") {
+			authorType, ok := config.Config.Models.AuthorType[strings.TrimSpace(name)]
+			if !ok {
+				return errors.New("Invalid Author Type")
+			}
+			args.AuthorType = append(args.AuthorType, authorType)
+		}
+	}
 	if c.Query("tag") != "" {
 		args.Tag = strings.Split(c.Query("tag"), ",")
 	}
@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/jsonld"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+// postJSONLD assembles NewsArticle for news and review, and Article for other post types.
+// gated tells whether the content is behind memo points of its project.
+func postJSONLD(post models.TaggedPostMember, gated bool) jsonld.CreativeWork {
+
+	url := utils.GenerateResourceInfo("post", int(post.ID), "")
+	work := jsonld.CreativeWork{
+		Context:          jsonld.Context,
+		Type:             "Article",
+		Headline:         post.Title.String,
+		Description:      post.OgDescription.String,
+		URL:              url,
+		MainEntityOfPage: url,
+		Image:            jsonld.Images(post.HeroImage.String, post.OgImage.String, post.LinkImage.String),
+		DatePublished:    jsonld.Date(post.PublishedAt.Time),
+		DateModified:     jsonld.Date(post.UpdatedAt.Time),
+		Publisher:        jsonld.Publisher(),
+	}
+	switch post.Type.Int {
+	case int64(config.Config.Models.PostType["news"]), int64(config.Config.Models.PostType["review"]):
+		work.Type = "NewsArticle"
+	}
+	if work.Description == "" {
+		work.Description, _ = utils.CutAbstract(post.Content.String, 100, func(abstract string) string { return abstract })
+	}
+	for _, author := range post.Authors {
+		work.Author = append(work.Author, authorJSONLD(author))
+	}
+	tags := make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tags = append(tags, tag.Text)
+	}
+	work.Keywords = jsonld.Keywords(tags)
+	work.Paywall(gated)
+	return work
+}
+
+// projectJSONLD assembles CreativeWork for project
+func projectJSONLD(project models.ProjectAuthors) jsonld.CreativeWork {
+
+	url := utils.GenerateResourceInfo("project", project.ID, project.Slug.String)
+	work := jsonld.CreativeWork{
+		Context:          jsonld.Context,
+		Type:             "CreativeWork",
+		Name:             project.Title.String,
+		Description:      project.Description.String,
+		URL:              url,
+		MainEntityOfPage: url,
+		Image:            jsonld.Images(project.HeroImage.String, project.OgImage.String),
+		DatePublished:    jsonld.Date(project.PublishedAt.Time),
+		DateModified:     jsonld.Date(project.UpdatedAt.Time),
+		Publisher:        jsonld.Publisher(),
+	}
+	if work.Description == "" {
+		work.Description = project.OgDescription.String
+	}
+	if project.ContentUpdateTime.Valid && project.ContentUpdateTime.Time.After(project.UpdatedAt.Time) {
+		work.DateModified = jsonld.Date(project.ContentUpdateTime.Time)
+	}
+	for _, author := range project.Authors {
+		if author.ID == nil || author.Nickname == nil {
+			continue
+		}
+		work.Author = append(work.Author, jsonld.Person{
+			Type: "Person",
+			Name: author.Nickname.String,
+			URL:  utils.GenerateResourceInfo("member", int(*author.ID), ""),
+		})
+	}
+	tags := make([]string, 0, len(project.TagList))
+	for _, tag := range project.TagList {
+		tags = append(tags, tag.Content)
+	}
+	work.Keywords = jsonld.Keywords(tags)
+	return work
+}
+
+func authorJSONLD(author models.AuthorBasic) jsonld.Person {
+
+	person := jsonld.Person{
+		Type:        "Person",
+		Name:        author.Nickname.String,
+		URL:         utils.GenerateResourceInfo("member", int(author.ID), ""),
+		Description: author.Description.String,
+	}
+	if author.ProfileImage.Valid && author.ProfileImage.String != "" {
+		person.Image = &jsonld.ImageObject{Type: "ImageObject", URL: author.ProfileImage.String}
+	}
+	return person
+}
+
+// memberJSONLD assembles Person for public member profile
+func memberJSONLD(member models.Member) jsonld.Person {
+
+	person := authorJSONLD(models.AuthorBasic{
+		ID:           member.ID,
+		Nickname:     member.Nickname,
+		Description:  member.Description,
+		ProfileImage: member.ProfileImage,
+	})
+	person.Context = jsonld.Context
+	return person
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/mail"
+)
+
+type linkCheckHandler struct {
+	// running is set while a check runs in background, which wg waits for
+	running int32
+	wg      sync.WaitGroup
+}
+
+// GetBroken lists broken links for editors, such as /links/broken?type=post&show_history=true
+func (r *linkCheckHandler) GetBroken(c *gin.Context) {
+
+	args := &models.GetBrokenLinksArgs{MaxResult: 20, Page: 1}
+	if err := c.ShouldBindQuery(args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if args.Type != "" {
+		if err := models.ValidateLinkCheckType(args.Type); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+	if args.MaxResult <= 0 || args.Page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Paging"})
+		return
+	}
+
+	links, err := models.LinkCheckAPI.GetBroken(args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": links})
+}
+
+// Check re-validates links due in background, and is expected to be called periodically.
+// Probing a batch takes minutes, so it is refused while the previous one is still running.
+func (r *linkCheckHandler) Check(c *gin.Context) {
+
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		c.JSON(http.StatusConflict, gin.H{"Error": "Link Check Running"})
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer atomic.StoreInt32(&r.running, 0)
+		r.run()
+	}()
+	c.Status(http.StatusAccepted)
+}
+
+// run syncs links to check, checks a batch of them and notifies links newly broken
+func (r *linkCheckHandler) run() {
+
+	if err := models.LinkCheckAPI.Sync(); err != nil {
+		log.Printf("Error sync links to check: %v\n", err)
+		return
+	}
+	batch := config.Config.LinkCheck.BatchSize
+	if batch <= 0 {
+		batch = 100
+	}
+	checked, err := models.LinkCheckAPI.Check(batch)
+	if err != nil {
+		log.Printf("Error check links after %d checked: %v\n", checked, err)
+		return
+	}
+	log.Printf("Links checked: %d, notified: %d\n", checked, r.notify())
+}
+
+// notify tells members responsible for links newly broken, and returns how many links are notified.
+// Each channel is recorded on its own, so that a failed one is retried later without repeating the others.
+func (r *linkCheckHandler) notify() (notified int) {
+
+	channels := make([]string, 0)
+	if config.Config.LinkCheck.NotifySlack {
+		channels = append(channels, "slack")
+	}
+	if config.Config.LinkCheck.NotifyMail {
+		channels = append(channels, "mail")
+	}
+	if len(channels) == 0 {
+		return 0
+	}
+	notices, err := models.LinkCheckAPI.Unnotified(channels)
+	if err != nil {
+		log.Printf("Error get broken links to notify: %v\n", err)
+		return 0
+	}
+
+	// Notices are sorted by member
+	for start := 0; start < len(notices); {
+		end := start
+		for end < len(notices) && notices[end].MemberID == notices[start].MemberID {
+			end++
+		}
+		group := notices[start:end]
+		start = end
+
+		member := "未指定成員"
+		if group[0].MemberNickname.Valid {
+			member = group[0].MemberNickname.String
+		}
+		sent := make(map[int64]bool)
+		if config.Config.LinkCheck.NotifySlack {
+			if pending := unnotifiedOf(group, func(n models.LinkCheckNotice) bool { return n.SlackNotifiedAt.Valid }); len(pending) > 0 {
+				if err := models.SlackHelper.SendBrokenLinksNotify(member, pending); err != nil {
+					log.Printf("Error notify broken links through slack: %v\n", err)
+				} else {
+					r.markNotified(pending, "slack", sent)
+				}
+			}
+		}
+		if config.Config.LinkCheck.NotifyMail && group[0].MemberMail.Valid {
+			if pending := unnotifiedOf(group, func(n models.LinkCheckNotice) bool { return n.MailNotifiedAt.Valid }); len(pending) > 0 {
+				lines := make([]string, 0, len(pending))
+				for _, n := range pending {
+					lines = append(lines, fmt.Sprintf("%s %d: %s (%s)", n.ResourceType, n.ResourceID, html.EscapeString(n.URL), n.Status))
+				}
+				if err := mail.MailAPI.Send(mail.MailArgs{
+					Receiver: []string{group[0].MemberMail.String},
+					Subject:  fmt.Sprintf("%d 個連結已失效", len(pending)),
+					Payload:  strings.Join(lines, "<br>"),
+				}); err != nil {
+					log.Printf("Error notify broken links through mail: %v\n", err)
+				} else {
+					r.markNotified(pending, "mail", sent)
+				}
+			}
+		}
+		notified += len(sent)
+	}
+	return notified
+}
+
+// markNotified records notices sent through channel, and collects them in sent
+func (r *linkCheckHandler) markNotified(notices []models.LinkCheckNotice, channel string, sent map[int64]bool) {
+
+	ids := make([]int64, len(notices))
+	for i, n := range notices {
+		ids[i] = n.ID
+	}
+	if err := models.LinkCheckAPI.MarkNotified(ids, channel); err != nil {
+		return
+	}
+	for _, id := range ids {
+		sent[id] = true
+	}
+}
+
+// unnotifiedOf filters notices not notified yet through a channel
+func unnotifiedOf(notices []models.LinkCheckNotice, notified func(models.LinkCheckNotice) bool) []models.LinkCheckNotice {
+	result := make([]models.LinkCheckNotice, 0, len(notices))
+	for _, n := range notices {
+		if !notified(n) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func (r *linkCheckHandler) SetRoutes(router *gin.Engine) {
+	router.GET("/links/broken", r.GetBroken)
+	router.PUT("/links/check", r.Check)
+}
+
+var LinkCheckHandler linkCheckHandler
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/mail"
+)
+
+type mockLinkCheckAPI struct {
+	links    []models.LinkCheck
+	notified map[string][]int64
+}
+
+func (a *mockLinkCheckAPI) Sync() error { return nil }
+
+func (a *mockLinkCheckAPI) Check(limit int) (int, error) {
+	checked := 0
+	for i := range a.links {
+		if checked == limit {
+			break
+		}
+		a.links[i].Failures++
+		checked++
+	}
+	return checked, nil
+}
+
+func (a *mockLinkCheckAPI) GetBroken(args *models.GetBrokenLinksArgs) ([]models.LinkCheck, error) {
+	result := make([]models.LinkCheck, 0)
+	for _, link := range a.links {
+		if (args.Type == "" || link.ResourceType == args.Type) && (args.MemberID == 0 || link.MemberID.Int == args.MemberID) {
+			result = append(result, link)
+		}
+	}
+	return result, nil
+}
+
+func (a *mockLinkCheckAPI) Unnotified(channels []string) ([]models.LinkCheckNotice, error) {
+	result := make([]models.LinkCheckNotice, 0)
+	for _, link := range a.links {
+		for _, channel := range channels {
+			if (channel == "slack" && !link.SlackNotifiedAt.Valid) || (channel == "mail" && !link.MailNotifiedAt.Valid) {
+				result = append(result, models.LinkCheckNotice{LinkCheck: link, MemberMail: rrsql.NullString{String: "editor@readr.tw", Valid: link.MemberID.Valid}})
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (a *mockLinkCheckAPI) MarkNotified(ids []int64, channel string) error {
+	if a.notified == nil {
+		a.notified = make(map[string][]int64)
+	}
+	a.notified[channel] = append(a.notified[channel], ids...)
+	for i := range a.links {
+		for _, id := range ids {
+			if a.links[i].ID == id && channel == "mail" {
+				a.links[i].MailNotifiedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
+			}
+		}
+	}
+	return nil
+}
+
+type failingMailAPI struct {
+	mockMailAPI
+}
+
+func (m *failingMailAPI) Send(args mail.MailArgs) error { return errors.New("Mail Server Down") }
+
+func TestRouteLinkCheck(t *testing.T) {
+
+	backup := models.LinkCheckAPI
+	mock := &mockLinkCheckAPI{links: []models.LinkCheck{
+		{ID: 1, ResourceType: "post", ResourceID: 10, URL: "https://www.readr.tw/gone", MemberID: rrsql.NullInt{Int: 1, Valid: true}, Status: models.LinkNotFound, Failures: 3},
+		{ID: 2, ResourceType: "card_image", ResourceID: 20, URL: "https://www.readr.tw/gone.jpg", MemberID: rrsql.NullInt{Int: 1, Valid: true}, Status: models.LinkSoft404, Failures: 3},
+		{ID: 3, ResourceType: "comment", ResourceID: 30, URL: "https://www.readr.tw/moved", Status: models.LinkDomainChanged, Failures: 4},
+	}}
+	models.LinkCheckAPI = mock
+	notifyMail := config.Config.LinkCheck.NotifyMail
+	config.Config.LinkCheck.NotifyMail = true
+	defer func() {
+		models.LinkCheckAPI = backup
+		config.Config.LinkCheck.NotifyMail = notifyMail
+	}()
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		url      string
+		httpcode int
+		resp     string
+	}{
+		{"GetBroken", "GET", "/links/broken?type=post", http.StatusOK, `{"_items":[{"id":1,"resource_type":"post","resource_id":10,"url":"https://www.readr.tw/gone","member_id":1,"status":"not_found","http_status":null,"final_url":null,"failures":3,"checked_at":null,"slack_notified_at":null,"mail_notified_at":null,"created_at":null}]}`},
+		{"GetBrokenOfMember", "GET", "/links/broken?member_id=1", http.StatusOK, `{"_items":[{"id":1,"resource_type":"post","resource_id":10,"url":"https://www.readr.tw/gone","member_id":1,"status":"not_found","http_status":null,"final_url":null,"failures":3,"checked_at":null,"slack_notified_at":null,"mail_notified_at":null,"created_at":null},{"id":2,"resource_type":"card_image","resource_id":20,"url":"https://www.readr.tw/gone.jpg","member_id":1,"status":"soft_404","http_status":null,"final_url":null,"failures":3,"checked_at":null,"slack_notified_at":null,"mail_notified_at":null,"created_at":null}]}`},
+		{"GetBrokenInvalidType", "GET", "/links/broken?type=memo", http.StatusBadRequest, `{"Error":"Invalid Type"}`},
+		{"GetBrokenInvalidPaging", "GET", "/links/broken?max_result=0", http.StatusBadRequest, `{"Error":"Invalid Paging"}`},
+		{"Check", "PUT", "/links/check", http.StatusAccepted, ``},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			if w.Body.String() != tc.resp {
+				t.Errorf("%s expect response %v but get %v", tc.name, tc.resp, w.Body.String())
+			}
+		})
+	}
+	LinkCheckHandler.wg.Wait()
+	for _, link := range mock.links {
+		if link.Failures < 4 {
+			t.Errorf("expect link %d checked but get %d failures", link.ID, link.Failures)
+		}
+	}
+	// Links without member to mail are not recorded as notified
+	if !reflect.DeepEqual(mock.notified, map[string][]int64{"mail": []int64{1, 2}}) {
+		t.Errorf("expect links 1, 2 notified through mail but get %v", mock.notified)
+	}
+}
+
+func TestRouteLinkCheckRunning(t *testing.T) {
+
+	atomic.StoreInt32(&LinkCheckHandler.running, 1)
+	defer atomic.StoreInt32(&LinkCheckHandler.running, 0)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/links/check", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict || w.Body.String() != `{"Error":"Link Check Running"}` {
+		t.Errorf("expect check running refused but get %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLinkCheckNotify(t *testing.T) {
+
+	backup := models.LinkCheckAPI
+	mailBackup := mail.MailAPI
+	notifyMail := config.Config.LinkCheck.NotifyMail
+	config.Config.LinkCheck.NotifyMail = true
+	defer func() {
+		models.LinkCheckAPI = backup
+		mail.MailAPI = mailBackup
+		config.Config.LinkCheck.NotifyMail = notifyMail
+	}()
+
+	for _, tc := range []struct {
+		name     string
+		mailAPI  mail.MailInterface
+		links    []models.LinkCheck
+		notified int
+		marked   map[string][]int64
+	}{
+		{"Mail", new(mockMailAPI), []models.LinkCheck{
+			{ID: 1, MemberID: rrsql.NullInt{Int: 1, Valid: true}, Status: models.LinkNotFound, Failures: 3},
+			{ID: 2, MemberID: rrsql.NullInt{Int: 2, Valid: true}, Status: models.LinkNotFound, Failures: 3},
+		}, 2, map[string][]int64{"mail": []int64{1, 2}}},
+		{"MailNotifiedBefore", new(mockMailAPI), []models.LinkCheck{
+			{ID: 1, MemberID: rrsql.NullInt{Int: 1, Valid: true}, Status: models.LinkNotFound, Failures: 3, MailNotifiedAt: rrsql.NullTime{Time: time.Now(), Valid: true}},
+		}, 0, nil},
+		{"NoMember", new(mockMailAPI), []models.LinkCheck{
+			{ID: 3, Status: models.LinkDomainChanged, Failures: 3},
+		}, 0, nil},
+		{"MailFailed", new(failingMailAPI), []models.LinkCheck{
+			{ID: 1, MemberID: rrsql.NullInt{Int: 1, Valid: true}, Status: models.LinkNotFound, Failures: 3},
+		}, 0, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &mockLinkCheckAPI{links: tc.links}
+			models.LinkCheckAPI = mock
+			mail.MailAPI = tc.mailAPI
+
+			if notified := LinkCheckHandler.notify(); notified != tc.notified {
+				t.Errorf("%s expect %d links notified but get %d", tc.name, tc.notified, notified)
+			}
+			if !reflect.DeepEqual(mock.notified, tc.marked) {
+				t.Errorf("%s expect links marked %v but get %v", tc.name, tc.marked, mock.notified)
+			}
+		})
+	}
+}
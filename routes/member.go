@@ -135,6 +135,37 @@ func (r *memberHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"_items": []models.Member{member}})
 }
 
+func (r *memberHandler) GetJSONLD(c *gin.Context) {
+
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+
+	member, err := models.MemberAPI.GetMember(models.GetMemberArgs{
+		ID:     id,
+		IDType: "id",
+	})
+	if err != nil {
+		switch err.Error() {
+		case "User Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": "User Not Found"})
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+			return
+		}
+	}
+	// Hidden or inactive profiles are not public
+	if member.Active.Int != int64(config.Config.Models.Members["active"]) || (member.HideProfile.Valid && member.HideProfile.Bool) {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "User Not Found"})
+		return
+	}
+	c.JSON(http.StatusOK, memberJSONLD(member))
+}
+
 func (r *memberHandler) Post(c *gin.Context) {
 
 	member := models.Member{}
@@ -403,7 +434,8 @@ func (r *memberHandler) SetRoutes(router *gin.Engine) {
 
 	memberRouter := router.Group("/member")
 	{
-		memberRouter.GET("/:id", r.Get)
+		memberRouter.GET("/:id", rt.Conditional("member"), r.Get)
+		memberRouter.GET("/:id/jsonld", r.GetJSONLD)
 		memberRouter.POST("", r.Post)
 		memberRouter.PUT("", r.Put)
 		memberRouter.DELETE("/:id", r.Delete)
@@ -412,7 +444,7 @@ func (r *memberHandler) SetRoutes(router *gin.Engine) {
 	}
 	membersRouter := router.Group("/members")
 	{
-		membersRouter.GET("", r.GetAll)
+		membersRouter.GET("", rt.Conditional("members"), r.GetAll)
 		membersRouter.PUT("", r.ActivateAll)
 		membersRouter.DELETE("", r.DeleteAll)
 
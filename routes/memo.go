@@ -289,6 +289,7 @@ func (r *memoHandler) Put(c *gin.Context) {
 
 	if memo.PublishStatus.Valid || memo.Active.Valid {
 		r.PublishHandler([]int{int(memo.ID)})
+		r.ProgressHandler([]int{int(memo.ID)})
 	}
 	if memo.UpdatedBy.Valid {
 		r.UpdateHandler([]int{int(memo.ID)}, memo.UpdatedBy.Int)
@@ -315,6 +316,7 @@ func (r *memoHandler) Delete(c *gin.Context) {
 		}
 	}
 	r.UpdateHandler([]int{id})
+	r.ProgressHandler([]int{id})
 
 	c.Status(http.StatusOK)
 }
@@ -357,6 +359,7 @@ func (r *memoHandler) DeleteMany(c *gin.Context) {
 	} else {
 		r.UpdateHandler(params.IDs)
 	}
+	r.ProgressHandler(params.IDs)
 
 	c.Status(http.StatusOK)
 }
@@ -468,6 +471,17 @@ func (r *memoHandler) UpdateHandler(ids []int, params ...int64) error {
 	return nil
 }
 
+// ProgressHandler updates progress of projects counting memos, whenever memos are published, unpublished or deleted
+func (r *memoHandler) ProgressHandler(ids []int) {
+
+	if len(ids) == 0 {
+		return
+	}
+	if err := models.MilestoneAPI.UpdateMemosProgress(ids); err != nil {
+		log.Printf("Error update progress of projects of memos %v: %v\n", ids, err)
+	}
+}
+
 func (r *memoHandler) SetRoutes(router *gin.Engine) {
 
 	memoRouter := router.Group("/memo")
@@ -0,0 +1,160 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/mail"
+)
+
+type milestoneHandler struct{}
+
+// Get lists milestones of a project by target date, such as /milestones?project_id=1
+func (r *milestoneHandler) Get(c *gin.Context) {
+
+	projectID, err := strconv.Atoi(c.Query("project_id"))
+	if err != nil || projectID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Project ID"})
+		return
+	}
+	milestones, err := models.MilestoneAPI.GetMilestones(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": milestones})
+}
+
+func (r *milestoneHandler) Post(c *gin.Context) {
+
+	milestone := models.Milestone{}
+	if err := c.ShouldBindJSON(&milestone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Milestone"})
+		return
+	}
+	if milestone.ProjectID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Project ID"})
+		return
+	}
+	if !milestone.Title.Valid || milestone.Title.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Title"})
+		return
+	}
+	if milestone.Weight.Valid && milestone.Weight.Int <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Weight"})
+		return
+	}
+	milestone.CreatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
+	milestone.UpdatedAt = milestone.CreatedAt
+
+	id, err := models.MilestoneAPI.InsertMilestone(milestone)
+	if err != nil {
+		switch err.Error() {
+		case "Project Not Found":
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+func (r *milestoneHandler) Put(c *gin.Context) {
+
+	milestone := models.Milestone{}
+	if err := c.ShouldBindJSON(&milestone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Milestone"})
+		return
+	}
+	if milestone.ID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Milestone ID"})
+		return
+	}
+	if milestone.Title.Valid && milestone.Title.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Title"})
+		return
+	}
+	if milestone.Weight.Valid && milestone.Weight.Int <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Weight"})
+		return
+	}
+	milestone.CreatedAt.Valid = false
+	milestone.UpdatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
+
+	reached, err := models.MilestoneAPI.UpdateMilestone(milestone)
+	if err != nil {
+		switch err {
+		case rrsql.ItemNotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Milestone Not Found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	if reached {
+		r.notify(milestone.ID)
+	}
+	c.Status(http.StatusOK)
+}
+
+func (r *milestoneHandler) Delete(c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+	if err = models.MilestoneAPI.DeleteMilestone(id); err != nil {
+		switch err {
+		case rrsql.ItemNotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Milestone Not Found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// notify tells followers of a published project that one of its milestones is reached
+func (r *milestoneHandler) notify(id int) {
+
+	milestone, err := models.MilestoneAPI.GetMilestone(id)
+	if err != nil {
+		log.Printf("Error get reached milestone %d: %v\n", id, err)
+		return
+	}
+	project, err := models.ProjectAPI.GetProject(models.Project{ID: milestone.ProjectID})
+	if err != nil {
+		log.Printf("Error get project of milestone %d: %v\n", id, err)
+		return
+	}
+	if project.PublishStatus.Int != int64(config.Config.Models.ProjectsPublishStatus["publish"]) ||
+		project.Active.Int != int64(config.Config.Models.ProjectsActive["active"]) {
+		return
+	}
+
+	reached := models.ProjectMilestone{Milestone: milestone, Project: project}
+	go models.NotificationGen.GenerateProjectNotifications(reached, "milestone")
+	go mail.MailAPI.SendMilestoneMail(reached)
+}
+
+func (r *milestoneHandler) SetRoutes(router *gin.Engine) {
+
+	milestoneRouter := router.Group("/milestones")
+	{
+		milestoneRouter.GET("", r.Get)
+		milestoneRouter.POST("", r.Post)
+		milestoneRouter.PUT("", r.Put)
+		milestoneRouter.DELETE("/:id", r.Delete)
+	}
+}
+
+var MilestoneHandler milestoneHandler
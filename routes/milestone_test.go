@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/models"
+)
+
+type mockMilestoneAPI struct {
+	milestones []models.Milestone
+	progressed []int
+	memos      []int
+}
+
+func (a *mockMilestoneAPI) GetMilestone(id int) (models.Milestone, error) {
+	for _, m := range a.milestones {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return models.Milestone{}, rrsql.ItemNotFoundError
+}
+
+func (a *mockMilestoneAPI) GetMilestones(projectID int) ([]models.Milestone, error) {
+	result := make([]models.Milestone, 0)
+	for _, m := range a.milestones {
+		if m.ProjectID == projectID {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (a *mockMilestoneAPI) InsertMilestone(m models.Milestone) (int, error) {
+	m.ID = len(a.milestones) + 1
+	a.milestones = append(a.milestones, m)
+	return m.ID, a.UpdateProgress(m.ProjectID)
+}
+
+func (a *mockMilestoneAPI) UpdateMilestone(m models.Milestone) (reached bool, err error) {
+	for i, current := range a.milestones {
+		if current.ID == m.ID {
+			reached = !current.DoneAt.Valid && m.DoneAt.Valid
+			if m.DoneAt.Valid {
+				a.milestones[i].DoneAt = m.DoneAt
+			}
+			return reached, a.UpdateProgress(current.ProjectID)
+		}
+	}
+	return false, rrsql.ItemNotFoundError
+}
+
+func (a *mockMilestoneAPI) DeleteMilestone(id int) error {
+	for i, m := range a.milestones {
+		if m.ID == id {
+			a.milestones = append(a.milestones[:i], a.milestones[i+1:]...)
+			return a.UpdateProgress(m.ProjectID)
+		}
+	}
+	return rrsql.ItemNotFoundError
+}
+
+func (a *mockMilestoneAPI) UpdateProgress(projectID int) error {
+	a.progressed = append(a.progressed, projectID)
+	return nil
+}
+
+func (a *mockMilestoneAPI) UpdateMemosProgress(memoIDs []int) error {
+	a.memos = append(a.memos, memoIDs...)
+	return nil
+}
+
+func TestRouteMilestone(t *testing.T) {
+
+	backup := models.MilestoneAPI
+	mock := &mockMilestoneAPI{milestones: []models.Milestone{
+		{ID: 1, ProjectID: 1, Title: rrsql.NullString{String: "Survey", Valid: true}, Weight: rrsql.NullInt{Int: 1, Valid: true}},
+	}}
+	models.MilestoneAPI = mock
+	defer func() { models.MilestoneAPI = backup }()
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		httpcode int
+		resp     string
+	}{
+		{"Get", "GET", "/milestones?project_id=1", ``, http.StatusOK, `{"_items":[{"id":1,"project_id":1,"title":"Survey","description":null,"weight":1,"target_at":null,"done_at":null,"created_at":null,"updated_at":null,"updated_by":null}]}`},
+		{"GetInvalidProject", "GET", "/milestones", ``, http.StatusBadRequest, `{"Error":"Invalid Project ID"}`},
+		{"Post", "POST", "/milestones", `{"project_id":1,"title":"Interview","weight":2}`, http.StatusOK, `{"id":2}`},
+		{"PostNoTitle", "POST", "/milestones", `{"project_id":1}`, http.StatusBadRequest, `{"Error":"Invalid Title"}`},
+		{"PostInvalidWeight", "POST", "/milestones", `{"project_id":1,"title":"Interview","weight":0}`, http.StatusBadRequest, `{"Error":"Invalid Weight"}`},
+		{"PostInvalidProject", "POST", "/milestones", `{"title":"Interview"}`, http.StatusBadRequest, `{"Error":"Invalid Project ID"}`},
+		{"PutReached", "PUT", "/milestones", `{"id":1,"done_at":"2018-05-17T02:54:25Z"}`, http.StatusOK, ``},
+		{"PutNotFound", "PUT", "/milestones", `{"id":404,"done_at":"2018-05-17T02:54:25Z"}`, http.StatusNotFound, `{"Error":"Milestone Not Found"}`},
+		{"Delete", "DELETE", "/milestones/2", ``, http.StatusOK, ``},
+		{"DeleteNotFound", "DELETE", "/milestones/2", ``, http.StatusNotFound, `{"Error":"Milestone Not Found"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			if w.Body.String() != tc.resp {
+				t.Errorf("%s expect response %v but get %v", tc.name, tc.resp, w.Body.String())
+			}
+		})
+	}
+	if !mock.milestones[0].DoneAt.Valid {
+		t.Errorf("expect milestone 1 done but get %v", mock.milestones[0])
+	}
+	// Progress is computed after post, put and delete
+	if len(mock.progressed) != 3 {
+		t.Errorf("expect progress updated 3 times but get %v", mock.progressed)
+	}
+}
+
+func TestRouteMemoProgress(t *testing.T) {
+
+	var postTest mockPostAPI
+	memo := rrsql.NullInt{Int: int64(config.Config.Models.PostType["memo"]), Valid: true}
+	postTest.setup([]models.TaggedPostMember{
+		{Post: models.Post{ID: 1, Type: memo}},
+		{Post: models.Post{ID: 2, Type: memo}},
+		{Post: models.Post{ID: 3, Type: memo}},
+		{Post: models.Post{ID: 4, Type: memo}},
+		{Post: models.Post{ID: 5, Type: rrsql.NullInt{Int: int64(config.Config.Models.PostType["memo"]) + 1, Valid: true}}},
+	})
+	defer postTest.teardown()
+	backup := models.MilestoneAPI
+	defer func() { models.MilestoneAPI = backup }()
+
+	// Progress of projects counting memos is updated whenever memos are published, unpublished or deleted,
+	// and never for posts of other types
+	for _, tc := range []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		memos  []int
+	}{
+		{"Delete", "DELETE", "/post/1", ``, []int{1}},
+		{"DeleteAll", "DELETE", "/posts?ids=[2,3,5]&updated_by=1", ``, []int{2, 3}},
+		{"PutUnpublish", "PUT", "/post", fmt.Sprintf(`{"id":4,"version":1,"updated_by":1,"publish_status":%d}`, config.Config.Models.PostPublishStatus["unpublish"]), []int{4}},
+		{"PutPublish", "PUT", "/post", fmt.Sprintf(`{"id":4,"version":2,"updated_by":1,"publish_status":%d}`, config.Config.Models.PostPublishStatus["publish"]), []int{4}},
+		{"PutTitle", "PUT", "/post", `{"id":4,"version":3,"updated_by":1,"title":"Memo"}`, nil},
+		{"PublishAll", "PUT", "/posts", `{"ids":[2,3,5],"updated_by":1}`, []int{2, 3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := new(mockMilestoneAPI)
+			models.MilestoneAPI = mock
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s want HTTP code %d but get %d: %s", tc.name, http.StatusOK, w.Code, w.Body.String())
+			}
+			if !reflect.DeepEqual(mock.memos, tc.memos) {
+				t.Errorf("%s expect progress updated for memos %v but get %v", tc.name, tc.memos, mock.memos)
+			}
+		})
+	}
+}
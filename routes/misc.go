@@ -42,7 +42,7 @@ func (r *miscHandler) GetUrlMeta(c *gin.Context) {
 	}
 	var result []urlMetaInfo
 	for _, url := range matchedUrls {
-		ogInfo, err := models.OGParser.GetOGInfoFromUrl(url)
+		ogInfo, err := models.UnfurlAPI.Unfurl(url)
 		if err != nil {
 			log.Printf("Parse url meta fail: %s, %v \n", url, err.Error())
 		}
@@ -67,6 +67,7 @@ func (r *miscHandler) PublishResources(c *gin.Context) {
 	} else {
 		MemoHandler.PublishHandler(memoIDs)
 		MemoHandler.UpdateHandler(memoIDs)
+		MemoHandler.ProgressHandler(memoIDs)
 	}
 
 	reportIDs, err := models.ReportAPI.SchedulePublish()
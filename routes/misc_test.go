@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/readr-media/readr-restful/models"
+)
+
+type mockUnfurlAPI struct {
+	calls int
+}
+
+func (a *mockUnfurlAPI) Unfurl(rawURL string) (*models.OGInfo, error) {
+	a.calls++
+	if rawURL == "http://www.internal.tw/admin" {
+		return nil, errors.New("Blocked Address")
+	}
+	return &models.OGInfo{Title: "readr", Favicon: "https://www.readr.tw/favicon.ico"}, nil
+}
+
+func TestRouteUrlMeta(t *testing.T) {
+
+	backup := models.UnfurlAPI
+	models.UnfurlAPI = &mockUnfurlAPI{}
+	defer func() { models.UnfurlAPI = backup }()
+
+	for _, tc := range []struct {
+		name     string
+		url      string
+		httpcode int
+		resp     string
+	}{
+		{"Unfurl", "https://www.readr.tw/post/1", http.StatusOK, `{"_items":[{"url":"https://www.readr.tw/post/1","og_info":{"og_title":"readr","og_description":"","og_image":"","og_site_name":"","twitter":{"card":"","site":"","title":"","description":"","image":""},"favicon":"https://www.readr.tw/favicon.ico"}}]}`},
+		{"Blocked", "http://www.internal.tw/admin", http.StatusOK, `{"_items":[{"url":"http://www.internal.tw/admin","og_info":null}]}`},
+		{"InvalidUrl", "readr", http.StatusBadRequest, `{"Error":"Invalid Url String"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/url/meta?url="+url.QueryEscape(tc.url), nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			if w.Body.String() != tc.resp {
+				t.Errorf("%s expect response %v but get %v", tc.name, tc.resp, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package routes
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,7 +19,9 @@ import (
 	rt "github.com/readr-media/readr-restful/internal/router"
 	"github.com/readr-media/readr-restful/internal/rrsql"
 	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/cards"
 	"github.com/readr-media/readr-restful/pkg/mail"
+	"github.com/readr-media/readr-restful/pkg/sanitizer"
 )
 
 type postHandler struct{}
@@ -25,6 +32,18 @@ func (r *postHandler) bindQuery(c *gin.Context, args *models.PostArgs) (err erro
 		args.ProjectID = -1
 	}
 
+	if c.Query("lang") != "" {
+		if err = models.ValidateLanguage(c.Query("lang")); err != nil {
+			return err
+		}
+		args.Lang = c.Query("lang")
+	}
+	if c.Query("language") != "" {
+		if err = models.ValidateLanguage(c.Query("language")); err != nil {
+			return err
+		}
+		args.Language = c.Query("language")
+	}
 	if err = c.ShouldBindQuery(args); err == nil {
 		return nil
 	}
@@ -57,6 +76,20 @@ func (r *postHandler) bindQuery(c *gin.Context, args *models.PostArgs) (err erro
 			return err
 		}
 	}
+	if c.Query("reading_time") != "" && args.ReadingTime == nil {
+		if err = json.Unmarshal([]byte(c.Query("reading_time")), &args.ReadingTime); err != nil {
+			return err
+		} else if err = models.ValidateRange(args.ReadingTime); err != nil {
+			return err
+		}
+	}
+	if c.Query("word_count") != "" && args.WordCount == nil {
+		if err = json.Unmarshal([]byte(c.Query("word_count")), &args.WordCount); err != nil {
+			return err
+		} else if err = models.ValidateRange(args.WordCount); err != nil {
+			return err
+		}
+	}
 	if c.Query("sort") != "" && r.validatePostSorting(c.Query("sort")) {
 		args.Sorting = c.Query("sort")
 	}
@@ -74,6 +107,12 @@ func (r *postHandler) bindQuery(c *gin.Context, args *models.PostArgs) (err erro
 			return err
 		}
 	}
+	if c.Query("tagging") != "" {
+		args.Tagging, err = strconv.ParseInt(c.Query("tagging"), 10, 64)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -156,6 +195,203 @@ func (r *postHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"_items": []models.TaggedPostMember{post}})
 }
 
+func (r *postHandler) GetJSONLD(c *gin.Context) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+
+	post, err := models.PostAPI.GetPost(uint32(id), models.NewPostArgs(func(args *models.PostArgs) {
+		args.ProjectID = -1
+		args.ShowAuthor = true
+		args.ShowTag = true
+		args.Active = map[string][]int{"$in": []int{config.Config.Models.Posts["active"]}}
+		args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.PostPublishStatus["publish"]}}
+	}))
+	if err != nil {
+		switch err.Error() {
+		case "Post Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Post Not Found"})
+			return
+		default:
+			log.Println("Get Post Error: ", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+			return
+		}
+	}
+
+	// Memos are gated when their project requires points to read
+	var gated bool
+	if post.Type.Int == int64(config.Config.Models.PostType["memo"]) && post.ProjectID.Valid {
+		project, err := models.ProjectAPI.GetProject(models.Project{ID: int(post.ProjectID.Int)})
+		if err == nil && project.MemoPoints.Valid && project.MemoPoints.Int > 0 {
+			gated = true
+		}
+	}
+	c.JSON(http.StatusOK, postJSONLD(post, gated))
+}
+
+// GetRelated returns published posts related to post :id, ranked by score
+func (r *postHandler) GetRelated(c *gin.Context) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+
+	related, err := models.RelatedPostAPI.Get(uint32(id))
+	if err != nil {
+		switch err.Error() {
+		case "Post Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Post Not Found"})
+			return
+		default:
+			log.Println("Get Related Posts Error: ", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+			return
+		}
+	}
+	if len(related) == 0 {
+		c.JSON(http.StatusOK, gin.H{"_items": []models.TaggedPostMember{}})
+		return
+	}
+
+	// Related posts are fetched in one page, which holds at most 255 posts as max_result is uint8
+	if len(related) > math.MaxUint8 {
+		related = related[:math.MaxUint8]
+	}
+	ids := make([]uint32, 0, len(related))
+	for _, v := range related {
+		ids = append(ids, v.ID)
+	}
+	posts, err := models.PostAPI.GetPosts(models.NewPostArgs(func(args *models.PostArgs) {
+		args.IDs = ids
+		args.ProjectID = -1
+		args.ShowAuthor = true
+		args.ShowTag = true
+		args.MaxResult = uint8(len(ids))
+		args.Active = map[string][]int{"$in": []int{config.Config.Models.Posts["active"]}}
+		args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.PostPublishStatus["publish"]}}
+	}))
+	if err != nil {
+		log.Println("Get Related Posts Error: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		return
+	}
+
+	// Keep the order of ranking
+	rank := make(map[uint32]int, len(related))
+	for i, v := range related {
+		rank[v.ID] = i
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return rank[posts[i].ID] < rank[posts[j].ID] })
+	c.JSON(http.StatusOK, gin.H{"_items": posts})
+}
+
+// GetTranslations lists languages post :id is available in
+func (r *postHandler) GetTranslations(c *gin.Context) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+	languages, err := models.PostTranslationAPI.Languages(uint32(id))
+	if err != nil {
+		log.Println("Get Post Translations Error: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": languages})
+}
+
+// PutTranslation creates or replaces the translation of post :id in :lang
+func (r *postHandler) PutTranslation(c *gin.Context) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+	lang := c.Param("lang")
+	if err = models.ValidateLanguage(lang); err != nil || models.IsSourceLanguage(lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Language"})
+		return
+	}
+
+	var translation models.PostTranslation
+	if err = c.ShouldBindJSON(&translation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Translation"})
+		return
+	}
+	translation.PostID, translation.Language = uint32(id), lang
+	translation.UpdatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
+
+	// Translated content goes through the same sanitizer as the source
+	content := models.PostDescription{Post: models.Post{ID: uint32(id), Content: translation.Content, UpdatedBy: translation.UpdatedBy}}
+	for _, card := range translation.Cards {
+		content.NewsCards = append(content.NewsCards, cards.NewsCard{Title: card.Title, Description: card.Description})
+	}
+	if !r.validateContent(c, content) {
+		return
+	}
+
+	if err = models.PostTranslationAPI.Upsert(translation); err != nil {
+		switch err.Error() {
+		case "Post Not Found", "Card Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		default:
+			log.Println("Put Post Translation Error: ", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		}
+		return
+	}
+	r.reindex(uint32(id))
+	c.Status(http.StatusOK)
+}
+
+// DeleteTranslation removes the translation of post :id in :lang
+func (r *postHandler) DeleteTranslation(c *gin.Context) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+	lang := c.Param("lang")
+	if err = models.PostTranslationAPI.Delete(uint32(id), lang); err != nil {
+		switch err.Error() {
+		case "Translation Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		default:
+			log.Println("Delete Post Translation Error: ", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		}
+		return
+	}
+	go models.SearchFeed.DeletePostTranslation(int(id), lang)
+	c.Status(http.StatusOK)
+}
+
+// reindex updates search feed of post id if it is published
+func (r *postHandler) reindex(id uint32) {
+
+	post, err := models.PostAPI.GetPost(id, models.NewPostArgs(func(args *models.PostArgs) {
+		args.ProjectID = -1
+		args.ShowTag = true
+		args.ShowCard = true
+		args.Active = map[string][]int{"$in": []int{config.Config.Models.Posts["active"]}}
+		args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.PostPublishStatus["publish"]}}
+	}))
+	if err != nil {
+		return
+	}
+	go models.SearchFeed.InsertPost([]models.TaggedPostMember{post})
+}
+
 func (r *postHandler) Post(c *gin.Context) {
 
 	var post models.PostDescription
@@ -173,6 +409,10 @@ func (r *postHandler) Post(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Author"})
 		return
 	}
+	if err := validateAuthors(post.Authors); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
 
 	// CreatedAt and UpdatedAt set default to now
 	post.CreatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
@@ -204,6 +444,11 @@ func (r *postHandler) Post(c *gin.Context) {
 		post.ProjectID = rrsql.NullInt{Int: 0, Valid: true}
 	}
 
+	if !r.validateContent(c, post) {
+		return
+	}
+	models.FillPostLink(&post.Post, "")
+
 	// Assign post.authors to post.author when post.authors is empty
 	// This is a temporary measure before fe complete the author assignment in the insert post API
 	if post.Post.Author.Valid && len(post.Authors) == 0 {
@@ -247,6 +492,11 @@ func (r *postHandler) Put(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Post"})
 		return
 	}
+	// Version of post being edited is required, so that edits from others are never overwritten
+	if !post.Version.Valid {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"Error": "Missing Version"})
+		return
+	}
 	// Discard CreatedAt even if there is data
 	if post.CreatedAt.Valid {
 		post.CreatedAt.Time = time.Time{}
@@ -266,6 +516,22 @@ func (r *postHandler) Put(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Neither updated_by or author is valid"})
 		return
 	}
+	if err := validateAuthors(post.Authors); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	if !r.validateContent(c, post) {
+		return
+	}
+	if post.Link.Valid {
+		// Meta of link is fetched again only when the link is changed
+		current := post.Link.String
+		if stored, err := models.PostAPI.GetPost(post.ID, &models.PostArgs{ProjectID: -1}); err == nil {
+			current = stored.Link.String
+		}
+		models.FillPostLink(&post.Post, current)
+	}
 
 	err = models.PostAPI.UpdatePost(post)
 	if err != nil {
@@ -273,17 +539,22 @@ func (r *postHandler) Put(c *gin.Context) {
 		case rrsql.ItemNotFoundError:
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Post Not Found"})
 			return
+		case rrsql.VersionConflictError:
+			r.conflict(c, post.ID)
+			return
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
 		}
 	}
+	post.Version.Int++
 
 	if (post.PublishStatus.Valid && post.PublishStatus.Int != int64(config.Config.Models.PostPublishStatus["publish"])) ||
 		(post.Active.Valid && post.Active.Int != int64(config.Config.Models.Posts["active"])) {
 		// Case: Set a post to unpublished state, Delete the post from cache/searcher
 		go models.SearchFeed.DeletePost([]int{int(post.ID)})
 		go models.PostCache.Update(post.Post)
+		progressMemos([]int{int(post.ID)})
 
 		if post.PublishStatus.Valid && post.PublishStatus.Int == int64(config.Config.Models.PostPublishStatus["pending"]) {
 			m, err := models.PostAPI.GetPostAuthor(post.ID)
@@ -322,6 +593,28 @@ func (r *postHandler) Put(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// conflict responds to a stale update with the current post, so that editors could merge their changes into it
+func (r *postHandler) conflict(c *gin.Context, id uint32) {
+
+	post, err := models.PostAPI.GetPost(id, models.NewPostArgs(func(args *models.PostArgs) {
+		args.ProjectID = -1
+		args.ShowAuthor = true
+		args.ShowTag = true
+		args.ShowCard = true
+	}))
+	if err != nil {
+		switch err.Error() {
+		case "Post Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Post Not Found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"Error": "Version Conflict", "_items": []models.TaggedPostMember{post}})
+}
+
 func (r *postHandler) DeleteAll(c *gin.Context) {
 
 	params := models.PostUpdateArgs{}
@@ -365,6 +658,7 @@ func (r *postHandler) DeleteAll(c *gin.Context) {
 
 	go models.SearchFeed.DeletePost(params.IDs)
 	go models.PostCache.UpdateAll(params)
+	progressMemos(params.IDs)
 
 	c.Status(http.StatusOK)
 }
@@ -384,6 +678,7 @@ func (r *postHandler) Delete(c *gin.Context) {
 			return
 		}
 	}
+	progressMemos([]int{int(id)})
 	c.Status(http.StatusOK)
 }
 
@@ -443,38 +738,404 @@ func (r *postHandler) Count(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"_meta": resp})
 }
 
-/*
+// exportFilter narrows exported posts down with title, content, tag, published_at and updated_at of FilterPostArgs.
+// ok is false when there is no such filter in query.
+func (r *postHandler) exportFilter(c *gin.Context) (ids []uint32, ok bool, err error) {
+
+	for _, key := range []string{"title", "content", "tag", "published_at", "updated_at"} {
+		if c.Query(key) != "" {
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	var filter = &models.FilterArgs{}
+	if err = FilterHandler.bindQuery(c, filter); err != nil {
+		return nil, true, err
+	}
+	// author, id, slug and result shaper belong to PostArgs
+	filter.Author, filter.ID, filter.Slug = nil, 0, ""
+	filter.MaxResult, filter.Page, filter.Sorting = 0, 0, ""
+
+	filtered, err := models.PostAPI.FilterPosts(&models.FilterPostArgs{FilterArgs: *filter})
+	if err != nil {
+		return nil, true, err
+	}
+	ids = make([]uint32, 0, len(filtered))
+	for _, post := range filtered {
+		ids = append(ids, uint32(post.ID))
+	}
+	return ids, true, nil
+}
+
+func (r *postHandler) Export(c *gin.Context) {
+
+	var args = models.NewPostArgs()
+	if err := r.bindQuery(c, args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if args.Active == nil {
+		args.DefaultActive()
+	}
+
+	ids, ok, err := r.exportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if ok {
+		if args.IDs != nil {
+			requested := make(map[uint32]bool)
+			for _, id := range args.IDs {
+				requested[id] = true
+			}
+			intersection := make([]uint32, 0)
+			for _, id := range ids {
+				if requested[id] {
+					intersection = append(intersection, id)
+				}
+			}
+			ids = intersection
+		}
+		if len(ids) == 0 {
+			c.Data(http.StatusOK, "application/x-ndjson; charset=utf-8", nil)
+			return
+		}
+		args.IDs = ids
+	}
+
+	args.MaxResult = 100
+	args.ShowAuthor = true
+	args.ShowTag = true
+	args.ShowCard = true
+
+	encoder := json.NewEncoder(c.Writer)
+	for page := 1; ; page++ {
+		// Sorting is rewritten when parsed, so reset it for every page
+		args.Page, args.Sorting = uint16(page), "post_id"
+		posts, err := models.PostAPI.GetPosts(args)
+		if err != nil {
+			if page == 1 {
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
+			log.Printf("Export posts error at page %d: %v\n", page, err)
+			return
+		}
+		if page == 1 {
+			c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+			c.Header("Content-Disposition", "attachment; filename=posts.jsonl")
+			c.Status(http.StatusOK)
+		}
+		for _, post := range posts {
+			if err = encoder.Encode(models.NewPostDescription(post)); err != nil {
+				log.Printf("Export posts error when writing post %d: %v\n", post.ID, err)
+				return
+			}
+		}
+		c.Writer.Flush()
+		if len(posts) < int(args.MaxResult) {
+			return
+		}
+	}
+}
+
+func (r *postHandler) Import(c *gin.Context) {
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	var (
+		records []models.PostDescription
+		lines   []int
+		results = make([]models.PostImportResult, 0)
+	)
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record models.PostDescription
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			results = append(results, models.PostImportResult{Line: line, Action: "error", Error: err.Error()})
+			continue
+		}
+		if record.Post == (models.Post{}) {
+			results = append(results, models.PostImportResult{Line: line, Action: "error", Error: "Invalid Post"})
+			continue
+		}
+		// Imported posts go through the same sanitizer as those saved one by one
+		if stripped, err := r.checkContent(record); err != nil || len(stripped) > 0 {
+			result := models.PostImportResult{Line: line, ID: record.ID, Slug: record.Slug.String, Action: "error", Error: "Content Not Allowed", Stripped: stripped}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+			continue
+		}
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	imported, err := models.PostAPI.ImportPosts(records, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	for i := range imported {
+		imported[i].Line = lines[i]
+	}
+	results = append(results, imported...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+
+	if !dryRun && len(imported) > 0 {
+		go models.PostCache.SyncFromDataStorage()
+		go models.FeedCache.Invalidate()
+		go models.SitemapCache.Invalidate()
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": results, "dry_run": dryRun})
+}
+
+// Hot returns trending posts in order, which could be filtered by type, project_id and tagging
 func (r *postHandler) Hot(c *gin.Context) {
-	result, err := models.PostAPI.Hot()
+
+	var args = models.NewPostArgs()
+	if err := r.bindQuery(c, args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	ids, err := models.TrendingPostAPI.Get()
+	if err != nil {
+		log.Println("Get Trending Posts Error: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusOK, gin.H{"_items": []models.TaggedPostMember{}})
+		return
+	}
+
+	// Filter all trending posts, and page them after ranking
+	maxResult, page := int(args.MaxResult), int(args.Page)
+	args.IDs, args.MaxResult, args.Page, args.Sorting = ids, 0, 0, ""
+	args.Active = map[string][]int{"$in": []int{config.Config.Models.Posts["active"]}}
+	args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.PostPublishStatus["publish"]}}
+	posts, err := models.PostAPI.GetPosts(args)
 	if err != nil {
+		log.Println("Get Trending Posts Error: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		return
+	}
+
+	rank := make(map[uint32]int, len(ids))
+	for i, id := range ids {
+		rank[id] = i
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return rank[posts[i].ID] < rank[posts[j].ID] })
+	if page < 1 {
+		page = 1
+	}
+	if start := (page - 1) * maxResult; start >= len(posts) {
+		posts = []models.TaggedPostMember{}
+	} else if end := start + maxResult; maxResult > 0 && end < len(posts) {
+		posts = posts[start:end]
+	} else {
+		posts = posts[start:]
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": posts})
+}
+
+func (r *postHandler) PutHot(c *gin.Context) {
+	if err := models.TrendingPostAPI.Update(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"_items": result})
+	c.Status(http.StatusOK)
 }
-*/
 
 func (r *postHandler) PutCache(c *gin.Context) {
 	models.PostCache.SyncFromDataStorage()
 	c.Status(http.StatusOK)
 }
 
+func (r *postHandler) PutRelated(c *gin.Context) {
+	if err := models.RelatedPostAPI.Precompute(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// PutStats computes text statistics of posts stored before they were counted, such as /posts/stats?max_result=1000
+func (r *postHandler) PutStats(c *gin.Context) {
+
+	limit := 1000
+	if c.Query("max_result") != "" {
+		var err error
+		if limit, err = strconv.Atoi(c.Query("max_result")); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Max Result"})
+			return
+		}
+	}
+	updated, err := models.PostAPI.BackfillStats(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	if updated > 0 {
+		go models.PostCache.SyncFromDataStorage()
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
 func (r *postHandler) validatePostSorting(sort string) bool {
 	for _, v := range strings.Split(sort, ",") {
-		if matched, err := regexp.MatchString("-?(updated_at|created_at|published_at|post_id|author|comment_amount)", v); err != nil || !matched {
+		if matched, err := regexp.MatchString("-?(updated_at|created_at|published_at|post_id|author|comment_amount|reading_time|word_count|char_count)", v); err != nil || !matched {
 			return false
 		}
 	}
 	return true
 }
 
+// validateAuthors checks credit types and positions of authors
+func validateAuthors(authors []models.AuthorInput) error {
+	for _, author := range authors {
+		if err := models.ValidateAuthorType(author.Type.Int); err != nil {
+			return err
+		}
+		if author.Position.Valid && author.Position.Int < 0 {
+			return errors.New("Invalid Author Position")
+		}
+	}
+	return nil
+}
+
+// errCodeNotAllowed is returned when updater of post has no permission to save css or javascript
+var errCodeNotAllowed = errors.New("Not Allowed To Save CSS Or JavaScript")
+
+// validateContent runs the sanitizer policy over content, css, javascript and cards of post.
+// It writes the error response and returns false if post should not be saved.
+func (r *postHandler) validateContent(c *gin.Context, post models.PostDescription) bool {
+
+	stripped, err := r.checkContent(post)
+	switch {
+	case err == errCodeNotAllowed:
+		c.JSON(http.StatusForbidden, gin.H{"Error": err.Error()})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return false
+	case len(stripped) > 0:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "Content Not Allowed", "stripped": stripped})
+		return false
+	}
+	return true
+}
+
+// checkContent returns everything in content, css, javascript and cards of post not allowed by the sanitizer policy
+func (r *postHandler) checkContent(post models.PostDescription) ([]sanitizer.Stripped, error) {
+
+	if (post.CSS.Valid && post.CSS.String != "") || (post.JS.Valid && post.JS.String != "") {
+		allowed, err := r.canSaveCode(post.UpdatedBy.Int)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errCodeNotAllowed
+		}
+	}
+
+	stripped := make([]sanitizer.Stripped, 0)
+	if post.Content.Valid {
+		postType := post.Type
+		if !postType.Valid && post.ID != 0 {
+			// Type is not given when updating, use the stored one to decide the policy
+			stored, err := models.PostAPI.GetPost(post.ID, &models.PostArgs{ProjectID: -1})
+			if err == nil {
+				postType = stored.Type
+			}
+		}
+		policyName := sanitizer.DefaultPolicyName
+		if postType.Valid {
+			policyName = sanitizer.PostTypeName(postType.Int)
+		}
+		_, s := sanitizer.Sanitize("content", post.Content.String, sanitizer.PolicyFor(policyName))
+		stripped = append(stripped, s...)
+	}
+	if post.CSS.Valid {
+		stripped = append(stripped, sanitizer.ScanCSS("css", post.CSS.String)...)
+	}
+	if post.JS.Valid {
+		stripped = append(stripped, sanitizer.ScanJS("javascript", post.JS.String)...)
+	}
+	for i, card := range post.NewsCards {
+		if card.Title.Valid {
+			_, s := sanitizer.Sanitize(fmt.Sprintf("cards.%d.title", i), card.Title.String, sanitizer.PolicyFor("card"))
+			stripped = append(stripped, s...)
+		}
+		if card.Description.Valid {
+			_, s := sanitizer.Sanitize(fmt.Sprintf("cards.%d.description", i), card.Description.String, sanitizer.PolicyFor("card"))
+			stripped = append(stripped, s...)
+		}
+	}
+	return stripped, nil
+}
+
+// canSaveCode checks if the role of member has the permission to save css or javascript
+func (r *postHandler) canSaveCode(memberID int64) (bool, error) {
+
+	member, err := models.MemberAPI.GetMember(models.GetMemberArgs{IDType: "id", ID: strconv.FormatInt(memberID, 10)})
+	if err != nil {
+		if err.Error() == "User Not Found" {
+			return false, nil
+		}
+		return false, err
+	}
+	permissions, err := models.PermissionAPI.GetPermissions([]models.Permission{
+		models.Permission{
+			Role:   int(member.Role.Int),
+			Object: rrsql.NullString{String: config.Config.Sanitizer.CodePermission, Valid: true},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if p.Permission.Valid && p.Permission.Int == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (r *postHandler) PublishHandler(ids []uint32) error {
+	return r.publishPipeline(ids, true)
+}
+
+// publishPipeline feeds posts which are active and published to search, cache, feeds and sitemaps.
+// Notifications are generated only if notify is set, so that restored posts don't notify followers again.
+func (r *postHandler) publishPipeline(ids []uint32, notify bool) error {
 	// Insert to SearchFeed / Redis PostCache / Redis notification
 	// Send notify mail / slack message
 
 	if len(ids) == 0 {
 		return nil
 	}
+	// Memos are published or unpublished here, which progress of projects may count
+	postIDs := make([]int, len(ids))
+	for i, id := range ids {
+		postIDs[i] = int(id)
+	}
+	progressMemos(postIDs)
 
 	posts, err := models.PostAPI.GetPosts(models.NewPostArgs(func(arg *models.PostArgs) {
 		arg.ProjectID = -1
@@ -495,17 +1156,38 @@ func (r *postHandler) PublishHandler(ids []uint32) error {
 		if post.Active.Int == int64(config.Config.Models.Posts["active"]) &&
 			post.PublishStatus.Int == int64(config.Config.Models.PostPublishStatus["publish"]) {
 
-			go models.NotificationGen.GeneratePostNotifications(post)
+			if notify {
+				go models.NotificationGen.GeneratePostNotifications(post)
+			}
 
 			validPosts = append(validPosts, post)
 		}
 	}
 	go models.PostCache.SyncFromDataStorage()
 	go models.SearchFeed.InsertPost(validPosts)
+	go models.FeedCache.Invalidate()
+	go models.SitemapCache.Invalidate()
 
 	return nil
 }
 
+// progressMemos updates progress of projects counting memos among posts of ids, leaving out posts of other types
+func progressMemos(ids []int) {
+
+	memoIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		post, err := models.PostAPI.GetPost(uint32(id), &models.PostArgs{ProjectID: -1})
+		if err != nil {
+			log.Printf("Error getting type of post %d: %v\n", id, err)
+			continue
+		}
+		if post.Type.Valid && post.Type.Int == int64(config.Config.Models.PostType["memo"]) {
+			memoIDs = append(memoIDs, id)
+		}
+	}
+	MemoHandler.ProgressHandler(memoIDs)
+}
+
 func (r *postHandler) UpdateHandler(post models.PostDescription) error {
 
 	go models.PostCache.Update(post.Post)
@@ -516,21 +1198,31 @@ func (r *postHandler) SetRoutes(router *gin.Engine) {
 
 	postRouter := router.Group("/post")
 	{
-		postRouter.GET("/:id", r.Get)
+		postRouter.GET("/:id", rt.Conditional("post"), r.Get)
+		postRouter.GET("/:id/jsonld", r.GetJSONLD)
+		postRouter.GET("/:id/related", r.GetRelated)
+		postRouter.GET("/:id/translations", r.GetTranslations)
+		postRouter.PUT("/:id/translations/:lang", r.PutTranslation)
+		postRouter.DELETE("/:id/translations/:lang", r.DeleteTranslation)
 		postRouter.POST("", r.Post)
 		postRouter.PUT("", r.Put)
 		postRouter.DELETE("/:id", r.Delete)
 	}
 	postsRouter := router.Group("/posts")
 	{
-		postsRouter.GET("", r.GetAll)
+		postsRouter.GET("", rt.Conditional("posts"), r.GetAll)
 		postsRouter.GET("/active", r.GetActivePosts)
 		postsRouter.DELETE("", r.DeleteAll)
 		postsRouter.PUT("", r.PublishAll)
 
 		postsRouter.GET("/count", r.Count)
-		//postsRouter.GET("/hot", r.Hot)
+		postsRouter.GET("/export", r.Export)
+		postsRouter.POST("/import", r.Import)
+		postsRouter.GET("/hot", r.Hot)
+		postsRouter.PUT("/hot", r.PutHot)
 		postsRouter.PUT("/cache", r.PutCache)
+		postsRouter.PUT("/related", r.PutRelated)
+		postsRouter.PUT("/stats", r.PutStats)
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -150,8 +151,17 @@ func (a *mockPostAPI) UpdatePost(p models.PostDescription) (err error) {
 	err = rrsql.ItemNotFoundError
 	for index, value := range a.mockPostDS {
 		if value.ID == p.Post.ID {
+			// Version of a post starts from 1, as the column default
+			current := int64(1)
+			if value.Version.Valid {
+				current = value.Version.Int
+			}
+			if p.Post.Version.Int != current {
+				return rrsql.VersionConflictError
+			}
 			a.mockPostDS[index].LikeAmount = p.Post.LikeAmount
 			a.mockPostDS[index].Title = p.Post.Title
+			a.mockPostDS[index].Version = rrsql.NullInt{Int: p.Post.Version.Int + 1, Valid: true}
 			err = nil
 			return err
 		}
@@ -221,10 +231,108 @@ func (a *mockPostAPI) GetPostAuthor(id uint32) (member models.Member, err error)
 func (a *mockPostAPI) FilterPosts(args *models.FilterPostArgs) (result []models.FilteredPost, err error) {
 	return result, err
 }
+func (a *mockPostAPI) ImportPosts(records []models.PostDescription, dryRun bool) (results []models.PostImportResult, err error) {
+	for _, record := range records {
+		results = append(results, models.PostImportResult{ID: record.ID, Slug: record.Slug.String, Action: "update"})
+	}
+	return results, nil
+}
+
+// BackfillStats pretends there are 3 posts without stats
+func (a *mockPostAPI) BackfillStats(limit int) (int, error) {
+	if limit < 3 {
+		return limit, nil
+	}
+	return 3, nil
+}
+
+type mockRelatedPostAPI struct {
+	apiBackup models.RelatedPostInterface
+}
+
+func (a *mockRelatedPostAPI) setup() {
+	a.apiBackup = models.RelatedPostAPI
+	models.RelatedPostAPI = a
+}
+
+func (a *mockRelatedPostAPI) teardown() {
+	models.RelatedPostAPI = a.apiBackup
+}
+
+func (a *mockRelatedPostAPI) Get(postID uint32) ([]models.RelatedPost, error) {
+	if postID == 12345 {
+		return nil, errors.New("Post Not Found")
+	}
+	if postID == 300 {
+		related := make([]models.RelatedPost, 0, 300)
+		for id := uint32(1); id <= 300; id++ {
+			related = append(related, models.RelatedPost{ID: id})
+		}
+		return related, nil
+	}
+	return []models.RelatedPost{}, nil
+}
+
+func (a *mockRelatedPostAPI) Precompute() error { return nil }
+
+type mockTrendingPostAPI struct {
+	apiBackup models.TrendingPostInterface
+}
+
+func (a *mockTrendingPostAPI) setup() {
+	a.apiBackup = models.TrendingPostAPI
+	models.TrendingPostAPI = a
+}
+
+func (a *mockTrendingPostAPI) teardown() {
+	models.TrendingPostAPI = a.apiBackup
+}
+
+func (a *mockTrendingPostAPI) Get() ([]uint32, error) { return []uint32{6, 1, 4, 2}, nil }
+
+func (a *mockTrendingPostAPI) Update() error { return nil }
+
+type mockPostTranslationAPI struct {
+	apiBackup models.PostTranslationInterface
+}
+
+func (a *mockPostTranslationAPI) setup() {
+	a.apiBackup = models.PostTranslationAPI
+	models.PostTranslationAPI = a
+}
+
+func (a *mockPostTranslationAPI) teardown() {
+	models.PostTranslationAPI = a.apiBackup
+}
+
+func (a *mockPostTranslationAPI) Get(ids []uint32, lang string) (map[uint32]models.PostTranslation, error) {
+	return map[uint32]models.PostTranslation{}, nil
+}
+
+func (a *mockPostTranslationAPI) Languages(id uint32) ([]string, error) {
+	return []string{config.Config.Translation.SourceLanguage}, nil
+}
+
+func (a *mockPostTranslationAPI) Upsert(t models.PostTranslation) error {
+	if t.PostID == 12345 {
+		return errors.New("Post Not Found")
+	}
+	return nil
+}
+
+func (a *mockPostTranslationAPI) Delete(id uint32, lang string) error {
+	if id == 12345 {
+		return errors.New("Translation Not Found")
+	}
+	return nil
+}
 
 func TestRoutePost(t *testing.T) {
 
 	var postTest mockPostAPI
+	var relatedTest mockRelatedPostAPI
+	var trendingTest mockTrendingPostAPI
+	var translationTest mockPostTranslationAPI
 
 	posts := []models.TaggedPostMember{
 		{Post: mockPostDS[0], Authors: memberToAuthor(mockMembers[0]), UpdatedBy: memberToBasic(mockMembers[0])},
@@ -262,6 +370,8 @@ func TestRoutePost(t *testing.T) {
 					[]models.TaggedPostMember{posts[0]}},
 				genericTestcase{"Project ID", "GET", `/posts?project_id=11000`, ``, http.StatusOK,
 					[]models.TaggedPostMember{posts[2], posts[1]}},
+				genericTestcase{"InvalidReadingTime", "GET", `/posts?reading_time={"$eq":3}`, ``, http.StatusBadRequest,
+					`{"Error":"Invalid range operator $eq"}`},
 			},
 		},
 		TestStep{
@@ -292,6 +402,8 @@ func TestRoutePost(t *testing.T) {
 				genericTestcase{"WithPost", "POST", `/post`, `{"authors":[{"member_id":1, "author_type":0}],"title":"Why so serious?", "type":4, "project_id":100001}`, http.StatusOK, ``},
 				genericTestcase{"WithMultipleAuthors", "POST", `/post`, `{"authors":[{"member_id":52, "author_type":"0"},{"member_id":53, "author_type":0}],"title":"OK google"}`, http.StatusOK, ``},
 				genericTestcase{"WithMultipleCards", "POST", `/post`, `{"authors":[{"member_id":52, "author_type":"0"}],"cards":[{"title":"card1","active":1},{"title":"card2","active":1}],"title":"OK google"}`, http.StatusOK, ``},
+				genericTestcase{"WithCredits", "POST", `/post`, `{"authors":[{"member_id":52, "author_type":0},{"member_id":53, "author_type":1, "position":0},{"member_id":54, "author_type":1, "position":1}],"title":"OK google"}`, http.StatusOK, ``},
+				genericTestcase{"InvalidAuthorType", "POST", `/post`, `{"authors":[{"member_id":52, "author_type":99}],"title":"OK google"}`, http.StatusBadRequest, `{"Error":"Invalid Author Type"}`},
 			},
 		},
 		TestStep{
@@ -300,14 +412,17 @@ func TestRoutePost(t *testing.T) {
 			teardown: func() { postTest.teardown() },
 			register: &postTest,
 			cases: []genericTestcase{
-				genericTestcase{"UpdateCurrent", "PUT", `/post`, `{"id":1,"authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
-				genericTestcase{"NotExisted", "PUT", `/post`, `{"id":12345, "authors":[{"member_id":1, "author_type":0}]}`, http.StatusBadRequest, `{"Error":"Post Not Found"}`},
-				genericTestcase{"UpdateTags", "PUT", `/post`, `{"id":1, "tags":[5,3], "updated_by":1}`, http.StatusOK, ``},
+				genericTestcase{"UpdateCurrent", "PUT", `/post`, `{"id":1,"version":1,"authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
+				genericTestcase{"NotExisted", "PUT", `/post`, `{"id":12345,"version":1, "authors":[{"member_id":1, "author_type":0}]}`, http.StatusBadRequest, `{"Error":"Post Not Found"}`},
+				genericTestcase{"UpdateTags", "PUT", `/post`, `{"id":1,"version":2, "tags":[5,3], "updated_by":1}`, http.StatusOK, ``},
 				// UpdateSchedule the same with UpdateTags, need to be changed or confirmed
-				genericTestcase{"DeleteTags", "PUT", `/post`, `{"id":1, "tags":[], "updated_by":1}`, http.StatusOK, ``},
-				genericTestcase{"UpdateProjectID", "PUT", `/post`, `{"id":1, "project_id":100002, "updated_by":1}`, http.StatusOK, ``},
-				genericTestcase{"UpdateAuthor", "PUT", `/post`, `{"id":1, "authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
-				genericTestcase{"UpdateCards", "PUT", `/post`, `{"id":1, "cards":[{"id":1, "title":"modified card1", "active":1}, {"title":"inserted card2", "active":1}], "authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
+				genericTestcase{"DeleteTags", "PUT", `/post`, `{"id":1,"version":3, "tags":[], "updated_by":1}`, http.StatusOK, ``},
+				genericTestcase{"UpdateProjectID", "PUT", `/post`, `{"id":1,"version":4, "project_id":100002, "updated_by":1}`, http.StatusOK, ``},
+				genericTestcase{"UpdateAuthor", "PUT", `/post`, `{"id":1,"version":5, "authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
+				genericTestcase{"InvalidAuthorPosition", "PUT", `/post`, `{"id":1,"version":6, "authors":[{"member_id":2, "author_type":0, "position":-1}]}`, http.StatusBadRequest, `{"Error":"Invalid Author Position"}`},
+				genericTestcase{"UpdateCards", "PUT", `/post`, `{"id":1,"version":6, "cards":[{"id":1, "title":"modified card1", "active":1}, {"title":"inserted card2", "active":1}], "authors":[{"member_id":2, "author_type":0}]}`, http.StatusOK, ``},
+				genericTestcase{"MissingVersion", "PUT", `/post`, `{"id":1, "title":"no version", "updated_by":1}`, http.StatusPreconditionRequired, `{"Error":"Missing Version"}`},
+				genericTestcase{"StaleVersion", "PUT", `/post`, `{"id":1,"version":6, "title":"stale", "updated_by":1}`, http.StatusConflict, []models.TaggedPostMember{posts[0]}},
 			},
 		},
 		TestStep{
@@ -356,6 +471,80 @@ func TestRoutePost(t *testing.T) {
 				genericTestcase{"NoValidActive", "GET", `/posts/count?active={"$nin":[-3,-4]}`, ``, http.StatusBadRequest, `{"Error":"No valid active request"}`},
 				genericTestcase{"Type", "GET", `/posts/count?type={"$in":[1,2]}`, ``, http.StatusOK, `{"_meta":{"total":3}}`}},
 		},
+		TestStep{
+			name:     "Import",
+			init:     func() { postTest.setup(posts) },
+			teardown: func() { postTest.teardown() },
+			register: &postTest,
+			cases: []genericTestcase{
+				genericTestcase{"DryRun", "POST", `/posts/import?dry_run=true`, `{"id":1,"slug":"slug","title":"title"}
+x
+{}
+
+{"id":2,"title":"title"}`, http.StatusOK, `{"_items":[{"line":1,"id":1,"slug":"slug","action":"update"},{"line":2,"action":"error","error":"invalid character 'x' looking for beginning of value"},{"line":3,"action":"error","error":"Invalid Post"},{"line":5,"id":2,"action":"update"}],"dry_run":true}`},
+				genericTestcase{"Empty", "POST", `/posts/import`, ``, http.StatusOK, `{"_items":[],"dry_run":false}`},
+				genericTestcase{"ContentNotAllowed", "POST", `/posts/import?dry_run=true`, `{"id":1,"slug":"slug","content":"<script>alert(1)</script>"}
+{"id":2,"title":"title","css":"p{}","updated_by":404}
+{"id":3,"title":"title"}`, http.StatusOK, `{"_items":[{"line":1,"id":1,"slug":"slug","action":"error","error":"Content Not Allowed","stripped":[{"field":"content","kind":"tag","name":"script","reason":"tag not allowed"}]},{"line":2,"id":2,"action":"error","error":"Not Allowed To Save CSS Or JavaScript"},{"line":3,"id":3,"action":"update"}],"dry_run":true}`},
+			},
+		},
+		TestStep{
+			name:     "Stats",
+			init:     func() { postTest.setup(posts) },
+			teardown: func() { postTest.teardown() },
+			register: &postTest,
+			cases: []genericTestcase{
+				genericTestcase{"Default", "PUT", `/posts/stats`, ``, http.StatusOK, `{"updated":3}`},
+				genericTestcase{"MaxResult", "PUT", `/posts/stats?max_result=2`, ``, http.StatusOK, `{"updated":2}`},
+				genericTestcase{"InvalidMaxResult", "PUT", `/posts/stats?max_result=0`, ``, http.StatusBadRequest, `{"Error":"Invalid Max Result"}`},
+			},
+		},
+		TestStep{
+			name:     "Translations",
+			init:     func() { postTest.setup(posts); translationTest.setup() },
+			teardown: func() { postTest.teardown(); translationTest.teardown() },
+			register: &postTest,
+			cases: []genericTestcase{
+				genericTestcase{"Fallback", "GET", `/post/1?lang=en`, ``, http.StatusOK,
+					[]models.TaggedPostMember{posts[0]}},
+				genericTestcase{"InvalidLang", "GET", `/post/1?lang=fr`, ``, http.StatusBadRequest, `{"Error":"Invalid Language"}`},
+				genericTestcase{"List", "GET", `/post/1/translations`, ``, http.StatusOK, `{"_items":["zh-TW"]}`},
+				genericTestcase{"Put", "PUT", `/post/1/translations/en`, `{"title":"title","content":"<p>content</p>"}`, http.StatusOK, ``},
+				genericTestcase{"PutSource", "PUT", `/post/1/translations/zh-TW`, `{"title":"title"}`, http.StatusBadRequest, `{"Error":"Invalid Language"}`},
+				genericTestcase{"PutNotFound", "PUT", `/post/12345/translations/en`, `{"title":"title"}`, http.StatusNotFound, `{"Error":"Post Not Found"}`},
+				genericTestcase{"Delete", "DELETE", `/post/1/translations/en`, ``, http.StatusOK, ``},
+				genericTestcase{"DeleteNotFound", "DELETE", `/post/12345/translations/en`, ``, http.StatusNotFound, `{"Error":"Translation Not Found"}`},
+			},
+		},
+		TestStep{
+			name:     "Hot",
+			init:     func() { postTest.setup(posts); trendingTest.setup() },
+			teardown: func() { postTest.teardown(); trendingTest.teardown() },
+			register: &postTest,
+			cases: []genericTestcase{
+				genericTestcase{"Ranked", "GET", `/posts/hot`, ``, http.StatusOK,
+					[]models.TaggedPostMember{posts[2], posts[0], posts[3], posts[1]}},
+				genericTestcase{"Paged", "GET", `/posts/hot?max_result=2&page=2`, ``, http.StatusOK,
+					[]models.TaggedPostMember{posts[3], posts[1]}},
+				genericTestcase{"Type", "GET", `/posts/hot?type={"$in":[1,2]}`, ``, http.StatusOK,
+					[]models.TaggedPostMember{posts[0], posts[3], posts[1]}},
+				genericTestcase{"OutOfRange", "GET", `/posts/hot?max_result=2&page=3`, ``, http.StatusOK,
+					[]models.TaggedPostMember{}},
+				genericTestcase{"Update", "PUT", `/posts/hot`, ``, http.StatusOK, ``},
+			},
+		},
+		TestStep{
+			name:     "Related",
+			init:     func() { postTest.setup(posts); relatedTest.setup() },
+			teardown: func() { postTest.teardown(); relatedTest.teardown() },
+			register: &postTest,
+			cases: []genericTestcase{
+				genericTestcase{"NoRelated", "GET", `/post/1/related`, ``, http.StatusOK, `{"_items":[]}`},
+				genericTestcase{"NotFound", "GET", `/post/12345/related`, ``, http.StatusNotFound, `{"Error":"Post Not Found"}`},
+				genericTestcase{"InvalidID", "GET", `/post/abc/related`, ``, http.StatusBadRequest, `{"Error":"ID Must Be Integer"}`},
+				genericTestcase{"Precompute", "PUT", `/posts/related`, ``, http.StatusOK, ``},
+			},
+		},
 	}
 	asserter := func(resp string, tc genericTestcase, t *testing.T) {
 
@@ -403,6 +592,127 @@ func TestRoutePost(t *testing.T) {
 // 	mockPostDS = mockPostDSBack
 // }
 
+func TestRoutePostEmbed(t *testing.T) {
+
+	var postTest mockPostAPI
+	postTest.setup([]models.TaggedPostMember{})
+	defer postTest.teardown()
+	backup := models.UnfurlAPI
+	models.UnfurlAPI = &mockUnfurlAPI{}
+	defer func() { models.UnfurlAPI = backup }()
+
+	for _, tc := range []struct {
+		name  string
+		body  string
+		embed *models.Embed
+		post  models.Post
+	}{
+		{"YouTube", `{"authors":[{"member_id":2, "author_type":0}],"link":"https://youtu.be/dQw4w9WgXcQ?t=42"}`,
+			&models.Embed{Provider: "youtube", ID: "dQw4w9WgXcQ", URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", EmbedURL: "https://www.youtube.com/embed/dQw4w9WgXcQ", AspectRatio: "16:9"},
+			models.Post{Link: rrsql.NullString{String: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Valid: true}, VideoID: rrsql.NullString{String: "dQw4w9WgXcQ", Valid: true}, LinkTitle: rrsql.NullString{String: "readr", Valid: true}, LinkImage: rrsql.NullString{String: "https://img.youtube.com/vi/dQw4w9WgXcQ/hqdefault.jpg", Valid: true}}},
+		{"VimeoKeepsGivenFields", `{"authors":[{"member_id":2, "author_type":0}],"link":"https://player.vimeo.com/video/76979871","link_title":"Given","video_id":"given"}`,
+			&models.Embed{Provider: "vimeo", ID: "76979871", URL: "https://vimeo.com/76979871", EmbedURL: "https://player.vimeo.com/video/76979871", AspectRatio: "16:9"},
+			models.Post{Link: rrsql.NullString{String: "https://vimeo.com/76979871", Valid: true}, VideoID: rrsql.NullString{String: "given", Valid: true}, LinkTitle: rrsql.NullString{String: "Given", Valid: true}}},
+		{"ApplePodcasts", `{"authors":[{"member_id":2, "author_type":0}],"link":"https://podcasts.apple.com/tw/podcast/readr/id1234567?l=en&i=1000456"}`,
+			&models.Embed{Provider: "apple_podcasts", ID: "tw/podcast/readr/id1234567?i=1000456", URL: "https://podcasts.apple.com/tw/podcast/readr/id1234567?i=1000456", EmbedURL: "https://embed.podcasts.apple.com/tw/podcast/readr/id1234567?i=1000456", Height: 175},
+			models.Post{Link: rrsql.NullString{String: "https://podcasts.apple.com/tw/podcast/readr/id1234567?i=1000456", Valid: true}, VideoID: rrsql.NullString{String: "tw/podcast/readr/id1234567?i=1000456", Valid: true}, LinkTitle: rrsql.NullString{String: "readr", Valid: true}}},
+		{"NotEmbed", `{"authors":[{"member_id":2, "author_type":0}],"link":"https://www.readr.tw/post/1"}`,
+			nil,
+			models.Post{Link: rrsql.NullString{String: "https://www.readr.tw/post/1", Valid: true}, LinkTitle: rrsql.NullString{String: "readr", Valid: true}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/post", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s want HTTP code %d but get %d: %s", tc.name, http.StatusOK, w.Code, w.Body.String())
+			}
+			got := postTest.mockPostDS[len(postTest.mockPostDS)-1].Post
+			if got.Link != tc.post.Link || got.VideoID != tc.post.VideoID || got.LinkTitle != tc.post.LinkTitle || got.LinkImage != tc.post.LinkImage {
+				t.Errorf("%s expect link fields %v %v %v %v but get %v %v %v %v", tc.name,
+					tc.post.Link, tc.post.VideoID, tc.post.LinkTitle, tc.post.LinkImage, got.Link, got.VideoID, got.LinkTitle, got.LinkImage)
+			}
+			if embed := models.ParseEmbed(got.Link.String); !reflect.DeepEqual(embed, tc.embed) {
+				t.Errorf("%s expect embed %v but get %v", tc.name, tc.embed, embed)
+			}
+		})
+	}
+}
+
+func TestRoutePostLinkUnfurlOnChange(t *testing.T) {
+
+	var postTest mockPostAPI
+	postTest.setup([]models.TaggedPostMember{
+		{Post: models.Post{ID: 1, Link: rrsql.NullString{String: "https://www.readr.tw/post/1", Valid: true}}},
+	})
+	defer postTest.teardown()
+	backup := models.UnfurlAPI
+	unfurl := &mockUnfurlAPI{}
+	models.UnfurlAPI = unfurl
+	defer func() { models.UnfurlAPI = backup }()
+
+	for _, tc := range []struct {
+		name  string
+		body  string
+		calls int
+	}{
+		{"Unchanged", `{"id":1,"version":1,"updated_by":2,"link":"https://www.readr.tw/post/1"}`, 0},
+		{"NoLink", `{"id":1,"version":2,"updated_by":2,"title":"readr"}`, 0},
+		{"Changed", `{"id":1,"version":3,"updated_by":2,"link":"https://www.readr.tw/post/2"}`, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			unfurl.calls = 0
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/post", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s want HTTP code %d but get %d: %s", tc.name, http.StatusOK, w.Code, w.Body.String())
+			}
+			if unfurl.calls != tc.calls {
+				t.Errorf("%s expect %d unfurl calls but get %d", tc.name, tc.calls, unfurl.calls)
+			}
+		})
+	}
+}
+
+// argsPostAPI records arguments of GetPosts
+type argsPostAPI struct {
+	mockPostAPI
+	args *models.PostArgs
+}
+
+func (a *argsPostAPI) GetPosts(args *models.PostArgs) ([]models.TaggedPostMember, error) {
+	a.args = args
+	return []models.TaggedPostMember{}, nil
+}
+
+func TestRoutePostRelatedMaxResult(t *testing.T) {
+
+	var relatedTest mockRelatedPostAPI
+	relatedTest.setup()
+	defer relatedTest.teardown()
+	postTest := &argsPostAPI{}
+	backup := models.PostAPI
+	models.PostAPI = postTest
+	defer func() { models.PostAPI = backup }()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/post/300/related", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want HTTP code %d but get %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	// 300 related posts are capped at 255 instead of wrapping max_result around to 44
+	if len(postTest.args.IDs) != 255 || postTest.args.MaxResult != 255 || postTest.args.IDs[0] != 1 {
+		t.Errorf("expect top 255 related posts fetched but get %d ids with max_result %d", len(postTest.args.IDs), postTest.args.MaxResult)
+	}
+}
+
 type ExpectResp struct {
 	httpcode int
 	err      string
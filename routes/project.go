@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/readr-media/readr-restful/config"
+	rt "github.com/readr-media/readr-restful/internal/router"
 	"github.com/readr-media/readr-restful/internal/rrsql"
 	"github.com/readr-media/readr-restful/models"
 )
@@ -21,6 +22,8 @@ import (
 type taggedProject struct {
 	models.Project
 	Tags rrsql.NullIntSlice `json:"tags" db:"tags"`
+	// Authors replaces credits of project if present, and clears them if empty
+	Authors []models.AuthorInput `json:"authors" db:"authors"`
 }
 
 type projectHandler struct {
@@ -172,6 +175,33 @@ func (r *projectHandler) GetContents(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"_items": result})
 }
 
+func (r *projectHandler) GetJSONLD(c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+
+	var args = models.GetProjectArgs{}
+	args.Default()
+	args.IDs = []int{id}
+	args.Active = map[string][]int{"$in": []int{config.Config.Models.ProjectsActive["active"]}}
+	args.PublishStatus = map[string][]int{"$in": []int{config.Config.Models.ProjectsPublishStatus["publish"]}}
+	args.Fields = []string{"id", "nickname"}
+
+	projects, err := models.ProjectAPI.GetProjects(args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	if len(projects) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Project Not Found"})
+		return
+	}
+	c.JSON(http.StatusOK, projectJSONLD(projects[0]))
+}
+
 func (r *projectHandler) Post(c *gin.Context) {
 
 	project := taggedProject{}
@@ -190,6 +220,14 @@ func (r *projectHandler) Post(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Parameter"})
 		return
 	}
+	if err = r.validateProgress(project.Project); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err = r.validateCredits(project.Authors); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
 
 	// if project.Status.Valid == true && project.Status.Int == int64(models.ProjectStatus["done"].(float64)) && project.Slug.Valid == false {
 	if project.Status.Valid == true && project.Status.Int == int64(config.Config.Models.ProjectsStatus["done"]) && project.Slug.Valid == false {
@@ -206,7 +244,7 @@ func (r *projectHandler) Post(c *gin.Context) {
 	}
 	project.UpdatedAt = rrsql.NullTime{time.Now(), true}
 
-	err = models.ProjectAPI.InsertProject(project.Project)
+	project.ID, err = models.ProjectAPI.InsertProject(project.Project)
 	if err != nil {
 		switch err.Error() {
 		case "Duplicate entry":
@@ -226,6 +264,13 @@ func (r *projectHandler) Post(c *gin.Context) {
 		}
 	}
 
+	if len(project.Authors) > 0 {
+		if err = models.ProjectAPI.InsertAuthors(project.ID, project.Authors); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+
 	c.Status(http.StatusOK)
 }
 
@@ -242,11 +287,30 @@ func (r *projectHandler) Put(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Project Data"})
 		return
 	}
+	if !project.Version.Valid {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"Error": "Missing Version"})
+		return
+	}
 
 	if project.Active.Valid == true && !r.validateProjectStatus(project.Active.Int) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Parameter"})
 		return
 	}
+	if err = r.validateProgress(project.Project); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err = r.validateCredits(project.Authors); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	// Progress could only be typed in for projects not computing it
+	if project.Progress.Valid && !project.ProgressSource.Valid {
+		if p, err := models.ProjectAPI.GetProject(project.Project); err == nil && p.ProgressSource.Valid && p.ProgressSource.String != models.ProgressManual {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Progress Is Computed"})
+			return
+		}
+	}
 
 	// if project.Status.Valid == true && project.Status.Int == int64(models.ProjectStatus["done"].(float64)) {
 	if project.Status.Valid == true && project.Status.Int == int64(config.Config.Models.ProjectsStatus["done"]) {
@@ -271,6 +335,9 @@ func (r *projectHandler) Put(c *gin.Context) {
 		case "Project Not Found":
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Project Not Found"})
 			return
+		case rrsql.VersionConflictError.Error():
+			r.conflict(c, project.ID)
+			return
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
 			return
@@ -285,9 +352,74 @@ func (r *projectHandler) Put(c *gin.Context) {
 		}
 	}
 
+	if project.Authors != nil {
+		if err = models.ProjectAPI.UpdateAuthors(project.ID, project.Authors); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+
+	if project.ProgressSource.Valid || project.PlannedMemos.Valid {
+		if err = models.MilestoneAPI.UpdateProgress(project.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+
+	if project.PublishStatus.Valid || project.Active.Valid || project.Slug.Valid {
+		go models.SitemapCache.Invalidate()
+	}
+
 	c.Status(http.StatusOK)
 }
 
+// validateCredits checks members and credit types of project authors
+func (r *projectHandler) validateCredits(authors []models.AuthorInput) error {
+	for _, author := range authors {
+		if !author.MemberID.Valid {
+			return errors.New("Invalid Author")
+		}
+	}
+	return validateAuthors(authors)
+}
+
+// validateProgress checks the way progress is computed, and what it is computed against
+func (r *projectHandler) validateProgress(p models.Project) error {
+
+	if p.ProgressSource.Valid {
+		if err := models.ValidateProgressSource(p.ProgressSource.String); err != nil {
+			return err
+		}
+		if p.ProgressSource.String != models.ProgressManual && p.Progress.Valid {
+			return errors.New("Progress Is Computed")
+		}
+	}
+	if p.PlannedMemos.Valid && p.PlannedMemos.Int <= 0 {
+		return errors.New("Invalid Planned Memos")
+	}
+	return nil
+}
+
+// conflict responds to a stale update with the current project, so that editors could merge their changes into it
+func (r *projectHandler) conflict(c *gin.Context, id int) {
+
+	args := models.GetProjectArgs{}
+	args.Default()
+	args.IDs = []int{id}
+	args.Fields = args.FullAuthorTags()
+	args.MaxResult = 1
+	projects, err := models.ProjectAPI.GetProjects(args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	if len(projects) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Project Not Found"})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"Error": "Version Conflict", "_items": projects})
+}
+
 func (r *projectHandler) Delete(c *gin.Context) {
 
 	id, err := strconv.Atoi(c.Param("id"))
@@ -311,16 +443,75 @@ func (r *projectHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+type orderArgs struct {
+	IDs []int `json:"ids"`
+}
+
+// PutPostsOrder rewrites order of all posts in project at once
+func (r *projectHandler) PutPostsOrder(c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "ID Must Be Integer"})
+		return
+	}
+	var args orderArgs
+	if err = c.ShouldBindJSON(&args); err != nil || len(args.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Order"})
+		return
+	}
+
+	if err = models.ProjectAPI.ReorderPosts(id, args.IDs); err != nil {
+		switch err.Error() {
+		case "Project Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		case "Duplicate ID", "Post Not In Project", "Incomplete Order":
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// PutOrder rewrites order of all projects on the homepage at once
+func (r *projectHandler) PutOrder(c *gin.Context) {
+
+	var args orderArgs
+	if err := c.ShouldBindJSON(&args); err != nil || len(args.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Order"})
+		return
+	}
+
+	if err := models.ProjectAPI.Reorder(args.IDs); err != nil {
+		switch err.Error() {
+		case "Duplicate ID", "Project Not Found", "Incomplete Order":
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 func (r *projectHandler) SetRoutes(router *gin.Engine) {
 	projectRouter := router.Group("/project")
 	{
 		projectRouter.GET("/count", r.Count)
-		projectRouter.GET("/list", r.Get)
+		projectRouter.GET("/list", rt.Conditional("project_list"), r.Get)
 		projectRouter.GET("/contents/:id", r.GetContents)
+		// Unlike /post/:id/jsonld and /member/:id/jsonld, GET /project/:id/jsonld conflicts with
+		// static /count and /list above in gin v1.3.0, which panics on wildcard with existing children.
+		// It follows /contents/:id instead.
+		projectRouter.GET("/jsonld/:id", r.GetJSONLD)
 		projectRouter.POST("", r.Post)
 		projectRouter.PUT("", r.Put)
 		projectRouter.DELETE("/:id", r.Delete)
+		projectRouter.PUT("/:id/posts/order", r.PutPostsOrder)
 	}
+	router.PUT("/projects/order", r.PutOrder)
 }
 
 func (r *projectHandler) validateProjectStatus(i int64) bool {
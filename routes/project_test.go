@@ -18,7 +18,10 @@ import (
 
 var mockProjectDS = []models.Project{}
 
-var mockProjectAuthors = []models.Stunt{}
+var mockProjectAuthors = []models.ProjectCredit{}
+
+// mockProjectCredits records credits of projects set through InsertAuthors and UpdateAuthors
+var mockProjectCredits = map[int][]models.AuthorInput{}
 
 type mockProjectAPI struct{}
 
@@ -72,36 +75,44 @@ func (a *mockProjectAPI) GetProjects(args models.GetProjectArgs) (result []model
 			{Project: models.Project{ID: 32233, Title: rrsql.NullString{"OK", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{61, true}, Slug: rrsql.NullString{"sampleslug0002", true}, Status: rrsql.NullInt{2, true}}},
 		}, nil
 	}
+	if len(args.IDs) == 1 {
+		for _, project := range mockProjectDS {
+			if project.ID == args.IDs[0] {
+				return []models.ProjectAuthors{{Project: project}}, nil
+			}
+		}
+		return []models.ProjectAuthors{}, nil
+	}
 	if len(args.IDs) == 2 {
 		if reflect.DeepEqual([]string(args.Fields), args.FullAuthorTags()) {
 			return []models.ProjectAuthors{
 				models.ProjectAuthors{
 					Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-					Authors: []models.Stunt{mockProjectAuthors[0]},
+					Authors: []models.ProjectCredit{mockProjectAuthors[0]},
 				},
 				models.ProjectAuthors{
 					Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-					Authors: []models.Stunt{mockProjectAuthors[0], mockProjectAuthors[1]},
+					Authors: []models.ProjectCredit{mockProjectAuthors[0], mockProjectAuthors[1]},
 				}}, nil
 		} else if reflect.DeepEqual([]string(args.Fields), []string{"id", "nickname"}) {
 			return []models.ProjectAuthors{
 				models.ProjectAuthors{
 					Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-					Authors: []models.Stunt{models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}}},
 				},
 				models.ProjectAuthors{
 					Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-					Authors: []models.Stunt{models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}, models.Stunt{ID: mockProjectAuthors[1].ID, Nickname: mockProjectAuthors[1].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}}, models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[1].ID, Nickname: mockProjectAuthors[1].Nickname}}},
 				}}, nil
 		}
 		return []models.ProjectAuthors{
 			models.ProjectAuthors{
 				Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-				Authors: []models.Stunt{models.Stunt{Nickname: mockProjectAuthors[0].Nickname}},
+				Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[0].Nickname}}},
 			},
 			models.ProjectAuthors{
 				Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-				Authors: []models.Stunt{models.Stunt{Nickname: mockProjectAuthors[0].Nickname}, models.Stunt{Nickname: mockProjectAuthors[1].Nickname}},
+				Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[0].Nickname}}, models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[1].Nickname}}},
 			},
 		}, nil
 	} else if len(args.IDs) == 1 {
@@ -139,10 +150,10 @@ func (a *mockProjectAPI) FilterProjects(args *models.FilterProjectArgs) (result
 	return result, err
 }
 
-func (a *mockProjectAPI) InsertProject(p models.Project) error {
+func (a *mockProjectAPI) InsertProject(p models.Project) (int, error) {
 	for _, project := range mockProjectDS {
 		if p.ID == project.ID {
-			return errors.New("Duplicate entry")
+			return 0, errors.New("Duplicate entry")
 		}
 	}
 
@@ -152,6 +163,16 @@ func (a *mockProjectAPI) InsertProject(p models.Project) error {
 		mockProjectDS[lastIndex-1].ID = lastIndex
 	}
 
+	return mockProjectDS[len(mockProjectDS)-1].ID, nil
+}
+
+func (a *mockProjectAPI) InsertAuthors(projectID int, authors []models.AuthorInput) error {
+	mockProjectCredits[projectID] = append(mockProjectCredits[projectID], authors...)
+	return nil
+}
+
+func (a *mockProjectAPI) UpdateAuthors(projectID int, authors []models.AuthorInput) error {
+	mockProjectCredits[projectID] = authors
 	return nil
 }
 
@@ -159,6 +180,15 @@ func (a *mockProjectAPI) UpdateProjects(p models.Project) error {
 	err := errors.New("Project Not Found")
 	for index, value := range mockProjectDS {
 		if value.ID == p.ID {
+			// Version of a project starts from 1, as the column default
+			current := int64(1)
+			if value.Version.Valid {
+				current = value.Version.Int
+			}
+			if p.Version.Valid && p.Version.Int != current {
+				return rrsql.VersionConflictError
+			}
+			p.Version = rrsql.NullInt{Int: current + 1, Valid: true}
 			mockProjectDS[index] = p
 			err = nil
 			break
@@ -182,6 +212,53 @@ func (a *mockProjectAPI) SchedulePublish() error {
 	return nil
 }
 
+// mockOrder checks ids for duplicates and those not in members, as orders are validated in models
+func mockOrder(ids []int, members map[int]bool, notMember string) error {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return errors.New("Duplicate ID")
+		}
+		seen[id] = true
+		if members != nil && !members[id] {
+			return errors.New(notMember)
+		}
+	}
+	return nil
+}
+
+func (a *mockProjectAPI) ReorderPosts(id int, postIDs []int) error {
+	for _, value := range mockProjectDS {
+		if value.ID == id {
+			// Posts of projects are not kept in mock, so any post is taken as in project
+			return mockOrder(postIDs, nil, "Post Not In Project")
+		}
+	}
+	return errors.New("Project Not Found")
+}
+
+func (a *mockProjectAPI) Reorder(ids []int) error {
+	// Orders are written to a copy, leaving fixture of later tests untouched
+	projects := make([]models.Project, len(mockProjectDS))
+	copy(projects, mockProjectDS)
+
+	members := make(map[int]bool, len(projects))
+	for _, project := range projects {
+		members[project.ID] = true
+	}
+	if err := mockOrder(ids, members, "Project Not Found"); err != nil {
+		return err
+	}
+	for index := range projects {
+		for i, id := range ids {
+			if projects[index].ID == id {
+				projects[index].Order = rrsql.NullInt{Int: int64(len(ids) - i), Valid: true}
+			}
+		}
+	}
+	return nil
+}
+
 var MockProjectAPI mockProjectAPI
 
 func TestRouteProjects(t *testing.T) {
@@ -198,7 +275,7 @@ func TestRouteProjects(t *testing.T) {
 		models.Project{Active: rrsql.NullInt{1, true}, Title: rrsql.NullString{"Alpha", true}, PublishStatus: rrsql.NullInt{1, true}, Progress: rrsql.NullFloat{99.87, true}},
 		models.Project{ID: 32767, Active: rrsql.NullInt{1, true}, Title: rrsql.NullString{"Omega", true}, Order: rrsql.NullInt{99999, true}},
 	} {
-		err := models.ProjectAPI.InsertProject(params)
+		_, err := models.ProjectAPI.InsertProject(params)
 		if err != nil {
 			log.Printf("Insert project fail when init test case. Error: %v", err)
 		}
@@ -257,23 +334,41 @@ func TestRouteProjects(t *testing.T) {
 			genericTestcase{"PostProjectEmptyBody", "POST", "/project", ``, http.StatusBadRequest, `{"Error":"Invalid Project"}`},
 			genericTestcase{"PostProjectDupe", "POST", "/project", `{"id":32767, "title":"Dupe"}`, http.StatusBadRequest, `{"Error":"Project Already Existed"}`},
 			genericTestcase{"PostProjectInvalidActive", "POST", "/project", `{"id":11493, "title":"InvActive", "active":3}`, http.StatusBadRequest, `{"Error":"Invalid Parameter"}`},
+			genericTestcase{"PostProjectInvalidProgressSource", "POST", "/project", `{"id":11493, "title":"InvSource", "progress_source":"posts"}`, http.StatusBadRequest, `{"Error":"Invalid Progress Source"}`},
+			genericTestcase{"PostProjectCredits", "POST", "/project", `{"id":32240,"title":"Credited","authors":[{"member_id":1,"author_type":0},{"member_id":2,"author_type":1}]}`, http.StatusOK, ``},
+			genericTestcase{"PostProjectInvalidCreditType", "POST", "/project", `{"id":32241,"title":"Credited","authors":[{"member_id":1,"author_type":7}]}`, http.StatusBadRequest, `{"Error":"Invalid Author Type"}`},
+			genericTestcase{"PostProjectCreditNoMember", "POST", "/project", `{"id":32241,"title":"Credited","authors":[{"author_type":0}]}`, http.StatusBadRequest, `{"Error":"Invalid Author"}`},
 		}
 		for _, tc := range testcases {
 			genericDoTest(tc, t, asserter)
 		}
+		if len(mockProjectCredits[32240]) != 2 {
+			t.Errorf("expect 2 credits of project 32240 but get %v", mockProjectCredits[32240])
+		}
 	})
 	t.Run("PutProject", func(t *testing.T) {
 		testcases := []genericTestcase{
-			genericTestcase{"UpdateProjectOK", "PUT", "/project", `{"id":32767,"title":"Modified","active":1,"project_order":99999}`, http.StatusOK, ``},
-			genericTestcase{"UpdateProjectNotExist", "PUT", "/project", `{"id":11493,"title":"NotExist"}`, http.StatusBadRequest, `{"Error":"Project Not Found"}`},
-			genericTestcase{"UpdateProjectInvalidActive", "PUT", "/project", `{"id":32767,"active":3}`, http.StatusBadRequest, `{"Error":"Invalid Parameter"}`},
-			genericTestcase{"UpdatePublishProjectWithNoSlug", "PUT", "/project", `{"id":32769,"status":2}`, http.StatusBadRequest, `{"Error":"Must Have Slug Before Publish"}`},
-			genericTestcase{"UpdateProjectStatusOK", "PUT", "/project", `{"id":32768,"status":2}`, http.StatusOK, ``},
-			genericTestcase{"UpdateProjectProgressOK", "PUT", "/project", `{"id":32768,"progress":99}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectOK", "PUT", "/project", `{"id":32767,"version":1,"title":"Modified","active":1,"project_order":99999}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectNotExist", "PUT", "/project", `{"id":11493,"version":1,"title":"NotExist"}`, http.StatusBadRequest, `{"Error":"Project Not Found"}`},
+			genericTestcase{"UpdateProjectInvalidActive", "PUT", "/project", `{"id":32767,"version":2,"active":3}`, http.StatusBadRequest, `{"Error":"Invalid Parameter"}`},
+			genericTestcase{"UpdatePublishProjectWithNoSlug", "PUT", "/project", `{"id":32769,"version":1,"status":2}`, http.StatusBadRequest, `{"Error":"Must Have Slug Before Publish"}`},
+			genericTestcase{"UpdateProjectStatusOK", "PUT", "/project", `{"id":32768,"version":1,"status":2}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectProgressOK", "PUT", "/project", `{"id":32768,"version":2,"progress":99}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectProgressComputed", "PUT", "/project", `{"id":32768,"version":3,"progress_source":"milestones","progress":99}`, http.StatusBadRequest, `{"Error":"Progress Is Computed"}`},
+			genericTestcase{"UpdateProjectInvalidPlannedMemos", "PUT", "/project", `{"id":32768,"version":3,"progress_source":"memos","planned_memos":0}`, http.StatusBadRequest, `{"Error":"Invalid Planned Memos"}`},
+			genericTestcase{"UpdateProjectProgressSourceOK", "PUT", "/project", `{"id":32767,"version":1,"progress_source":"memos","planned_memos":10}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectCredits", "PUT", "/project", `{"id":32767,"version":2,"authors":[{"member_id":3,"author_type":2,"position":1}]}`, http.StatusOK, ``},
+			genericTestcase{"UpdateProjectMissingVersion", "PUT", "/project", `{"id":32767,"title":"NoVersion"}`, http.StatusPreconditionRequired, `{"Error":"Missing Version"}`},
+			genericTestcase{"UpdateProjectStaleVersion", "PUT", "/project", `{"id":1,"version":0,"title":"Stale"}`, http.StatusConflict, []models.ProjectAuthors{
+				models.ProjectAuthors{Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}}},
+			}},
 		}
 		for _, tc := range testcases {
 			genericDoTest(tc, t, asserter)
 		}
+		if credits := mockProjectCredits[32767]; len(credits) != 1 || credits[0].MemberID.Int != 3 {
+			t.Errorf("expect credits of project 32767 replaced but get %v", credits)
+		}
 	})
 	t.Run("GetProject", func(t *testing.T) {
 		testcases := []genericTestcase{
@@ -293,11 +388,11 @@ func TestRouteProjects(t *testing.T) {
 			genericTestcase{"GetProjectWithIDsOK", "GET", `/project/list?ids=[1,32767]`, ``, http.StatusOK, []models.ProjectAuthors{
 				models.ProjectAuthors{
 					Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-					Authors: []models.Stunt{models.Stunt{Nickname: mockProjectAuthors[0].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[0].Nickname}}},
 				},
 				models.ProjectAuthors{
 					Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-					Authors: []models.Stunt{models.Stunt{Nickname: mockProjectAuthors[0].Nickname}, models.Stunt{Nickname: mockProjectAuthors[1].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[0].Nickname}}, models.ProjectCredit{Stunt: models.Stunt{Nickname: mockProjectAuthors[1].Nickname}}},
 				},
 			}},
 			genericTestcase{"GetProjectWithIDsNotFound", "GET", "/project/list?ids=[9527]", ``, http.StatusOK, `{"_items":[]}`},
@@ -334,21 +429,21 @@ func TestRouteProjects(t *testing.T) {
 			genericTestcase{"GetProjectWithAuthorsFieldsSet", "GET", `/project/list?ids=[1,32767]&fields=["id","nickname"]`, ``, http.StatusOK, []models.ProjectAuthors{
 				models.ProjectAuthors{
 					Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-					Authors: []models.Stunt{models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}}},
 				},
 				models.ProjectAuthors{
 					Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-					Authors: []models.Stunt{models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}, models.Stunt{ID: mockProjectAuthors[1].ID, Nickname: mockProjectAuthors[1].Nickname}},
+					Authors: []models.ProjectCredit{models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[0].ID, Nickname: mockProjectAuthors[0].Nickname}}, models.ProjectCredit{Stunt: models.Stunt{ID: mockProjectAuthors[1].ID, Nickname: mockProjectAuthors[1].Nickname}}},
 				},
 			}},
 			genericTestcase{"GetProjectWithAuthorsFull", "GET", `/project/list?ids=[1,32767]&mode=full`, ``, http.StatusOK, []models.ProjectAuthors{
 				models.ProjectAuthors{
 					Project: models.Project{ID: 32767, Title: rrsql.NullString{"Modified", true}, Active: rrsql.NullInt{1, true}, Order: rrsql.NullInt{99999, true}},
-					Authors: []models.Stunt{mockProjectAuthors[0]},
+					Authors: []models.ProjectCredit{mockProjectAuthors[0]},
 				},
 				models.ProjectAuthors{
 					Project: models.Project{ID: 1, Title: rrsql.NullString{"Alpha", true}, Active: rrsql.NullInt{1, true}},
-					Authors: []models.Stunt{mockProjectAuthors[0], mockProjectAuthors[1]},
+					Authors: []models.ProjectCredit{mockProjectAuthors[0], mockProjectAuthors[1]},
 				},
 			}},
 			genericTestcase{"GetProjectWithAuthorsInvalidFields", "GET", `/project/list?fields=["cat"]`, ``, http.StatusBadRequest, `{"Error":"Invalid Fields"}`},
@@ -367,6 +462,23 @@ func TestRouteProjects(t *testing.T) {
 			genericDoTest(tc, t, asserter)
 		}
 	})
+	t.Run("PutOrder", func(t *testing.T) {
+		testcases := []genericTestcase{
+			genericTestcase{"ReorderPostsOK", "PUT", "/project/32767/posts/order", `{"ids":[2,1]}`, http.StatusOK, ``},
+			genericTestcase{"ReorderPostsInvalidID", "PUT", "/project/unknown/posts/order", `{"ids":[2,1]}`, http.StatusBadRequest, `{"Error":"ID Must Be Integer"}`},
+			genericTestcase{"ReorderPostsEmpty", "PUT", "/project/32767/posts/order", `{"ids":[]}`, http.StatusBadRequest, `{"Error":"Invalid Order"}`},
+			genericTestcase{"ReorderPostsDuplicate", "PUT", "/project/32767/posts/order", `{"ids":[1,1]}`, http.StatusBadRequest, `{"Error":"Duplicate ID"}`},
+			genericTestcase{"ReorderPostsProjectNotFound", "PUT", "/project/9527/posts/order", `{"ids":[2,1]}`, http.StatusNotFound, `{"Error":"Project Not Found"}`},
+			genericTestcase{"ReorderProjectsOK", "PUT", "/projects/order", `{"ids":[32767,1]}`, http.StatusOK, ``},
+			genericTestcase{"ReorderProjectsNotFound", "PUT", "/projects/order", `{"ids":[32767,1,9527]}`, http.StatusBadRequest, `{"Error":"Project Not Found"}`},
+			genericTestcase{"ReorderProjectsDuplicate", "PUT", "/projects/order", `{"ids":[32767,32767]}`, http.StatusBadRequest, `{"Error":"Duplicate ID"}`},
+			genericTestcase{"ReorderPostsDuplicateNotAdjacent", "PUT", "/project/32767/posts/order", `{"ids":[1,2,1]}`, http.StatusBadRequest, `{"Error":"Duplicate ID"}`},
+			genericTestcase{"ReorderProjectsInvalidBody", "PUT", "/projects/order", `{"ids":"1"}`, http.StatusBadRequest, `{"Error":"Invalid Order"}`},
+		}
+		for _, tc := range testcases {
+			genericDoTest(tc, t, asserter)
+		}
+	})
 	t.Run("DeleteProject", func(t *testing.T) {
 		testcases := []genericTestcase{
 			genericTestcase{"DeleteProjectOK", "DELETE", "/project/32767", ``, http.StatusOK, ``},
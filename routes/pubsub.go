@@ -162,32 +162,8 @@ func (r *pubsubHandler) Push(c *gin.Context) {
 				return
 			}
 
-			comment.Body.String = strings.Trim(html.EscapeString(comment.Body.String), " \n")
-			escapedBody := url.PathEscape(comment.Body.String)
-			escapedBody = strings.Replace(escapedBody, `%2F`, "/", -1)
-			escapedBody = strings.Replace(escapedBody, `%20`, " ", -1)
-			commentUrls := r.parseUrl(escapedBody)
-			if len(commentUrls) > 0 {
-				for _, v := range commentUrls {
-					if !comment.OgTitle.Valid {
-						ogInfo, err := models.OGParser.GetOGInfoFromUrl(v)
-						if err != nil {
-							log.Printf("%s %s parse embeded url fail: %v \n", msgType, actionType, err.Error())
-							c.JSON(http.StatusOK, gin.H{"Error": fmt.Sprintf("%s %s parse embeded url fail: %v \n", msgType, actionType, err.Error())})
-							break
-						}
-						comment.OgTitle = rrsql.NullString{String: ogInfo.Title, Valid: true}
-						if ogInfo.Description != "" {
-							comment.OgDescription = rrsql.NullString{String: ogInfo.Description, Valid: true}
-						}
-						if ogInfo.Image != "" {
-							comment.OgImage = rrsql.NullString{String: ogInfo.Image, Valid: true}
-						}
-					}
-					escapedBody = strings.Replace(escapedBody, v, fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, v, v), -1)
-				}
-				comment.Body.String, _ = url.PathUnescape(escapedBody)
-			}
+			var commentUrls []string
+			comment.Body.String, commentUrls = r.linkify(comment.Body.String)
 
 			comment.CreatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
 			// comment.Active = rrsql.NullInt{Int: int64(models.CommentActive["active"].(float64)), Valid: true}
@@ -202,6 +178,13 @@ func (r *pubsubHandler) Push(c *gin.Context) {
 				return
 			}
 
+			// Link previews are fetched by worker in background
+			if len(commentUrls) > 0 {
+				if err = models.CommentLinkAPI.SetLinks(commentID, commentUrls); err != nil {
+					log.Printf("%s %s set links fail: %v \n", msgType, actionType, err.Error())
+				}
+			}
+
 			err = models.CommentAPI.UpdateCommentAmountByResource(comment.ResourceName.String, int(comment.ResourceID.Int), "+")
 			if err != nil {
 				log.Printf("%s %s fail: %v \n", msgType, "update comment amount", err.Error())
@@ -249,33 +232,9 @@ func (r *pubsubHandler) Push(c *gin.Context) {
 				return
 			}
 
+			var commentUrls []string
 			if comment.Body.Valid {
-				comment.Body.String = strings.Trim(html.EscapeString(comment.Body.String), " \n")
-				escapedBody := url.PathEscape(comment.Body.String)
-				escapedBody = strings.Replace(escapedBody, `%2F`, "/", -1)
-				escapedBody = strings.Replace(escapedBody, `%20`, " ", -1)
-				commentUrls := r.parseUrl(escapedBody)
-				if len(commentUrls) > 0 {
-					for _, v := range commentUrls {
-						if !comment.OgTitle.Valid {
-							ogInfo, err := models.OGParser.GetOGInfoFromUrl(v)
-							if err != nil {
-								log.Printf("%s %s parse embeded url fail: %v \n", msgType, actionType, err.Error())
-								c.JSON(http.StatusOK, gin.H{"Error": fmt.Sprintf("%s %s parse embeded url fail: %v \n", msgType, actionType, err.Error())})
-								break
-							}
-							comment.OgTitle = rrsql.NullString{String: ogInfo.Title, Valid: true}
-							if ogInfo.Description != "" {
-								comment.OgDescription = rrsql.NullString{String: ogInfo.Description, Valid: true}
-							}
-							if ogInfo.Image != "" {
-								comment.OgImage = rrsql.NullString{String: ogInfo.Image, Valid: true}
-							}
-						}
-						escapedBody = strings.Replace(escapedBody, v, fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, v, v), -1)
-					}
-					comment.Body.String, _ = url.PathUnescape(escapedBody)
-				}
+				comment.Body.String, commentUrls = r.linkify(comment.Body.String)
 			}
 
 			comment.UpdatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
@@ -283,6 +242,10 @@ func (r *pubsubHandler) Push(c *gin.Context) {
 			err = models.CommentAPI.UpdateComment(comment)
 			if err != nil {
 				log.Printf("%s %s UpdateComment fail: %v \n", msgType, actionType, err.Error())
+			} else if comment.Body.Valid {
+				if err = models.CommentLinkAPI.SetLinks(comment.ID, commentUrls); err != nil {
+					log.Printf("%s %s set links fail: %v \n", msgType, actionType, err.Error())
+				}
 			}
 
 			if comment.Status.Valid || comment.Active.Valid {
@@ -348,6 +311,30 @@ func (r *pubsubHandler) Push(c *gin.Context) {
 	}
 }
 
+// linkify escapes comment body and turns urls in it into anchors.
+// It returns the rendered body, with the urls found.
+func (r *pubsubHandler) linkify(body string) (string, []string) {
+
+	body = strings.Trim(html.EscapeString(body), " \n")
+	escapedBody := url.PathEscape(body)
+	escapedBody = strings.Replace(escapedBody, `%2F`, "/", -1)
+	escapedBody = strings.Replace(escapedBody, `%20`, " ", -1)
+
+	commentUrls := r.parseUrl(escapedBody)
+	if len(commentUrls) == 0 {
+		return body, nil
+	}
+	urls := make([]string, 0, len(commentUrls))
+	for _, v := range commentUrls {
+		escapedBody = strings.Replace(escapedBody, v, fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, v, v), -1)
+		if u, err := url.PathUnescape(v); err == nil {
+			urls = append(urls, html.UnescapeString(u))
+		}
+	}
+	body, _ = url.PathUnescape(escapedBody)
+	return body, urls
+}
+
 func (r *pubsubHandler) parseUrl(body string) []string {
 	matchResult := regexp.MustCompile("https?:\\/\\/(www\\.)?[-a-zA-Z0-9@:%._\\+~#=]{2,256}\\.[a-z]{2,6}([-a-zA-Z0-9@:%_\\+.~#?&\\/\\/=]*)").FindAllString(body, -1)
 	return matchResult
@@ -75,6 +75,7 @@ func TestMain(m *testing.M) {
 	SetRoutes(r)
 
 	models.CommentAPI = new(mockCommentAPI)
+	models.CommentLinkAPI = new(mockCommentLinkAPI)
 	models.FollowingAPI = new(mockFollowingAPI)
 	models.ProjectAPI = new(mockProjectAPI)
 	models.MemberAPI = new(mockMemberAPI)
@@ -85,6 +86,7 @@ func TestMain(m *testing.M) {
 	mail.MailAPI = new(mockMailAPI)
 	models.ReportAPI = new(mockReportAPI)
 	models.PointsAPI = new(mockPointsAPI)
+	models.MilestoneAPI = new(mockMilestoneAPI)
 	models.NotificationGen = new(mockNotificationGenerator)
 
 	models.FollowCache = new(mockFollowCache)
@@ -247,10 +249,11 @@ func (m *mockMailAPI) SendDailyDigest(s []string) (err error)
 func (m *mockMailAPI) SendProjectUpdateMail(resource interface{}, resourceTyep string) (err error) {
 	return err
 }
-func (m *mockMailAPI) SendCECommentNotify(tmp models.TaggedPostMember) (err error)   { return nil }
-func (m *mockMailAPI) SendReportPublishMail(report models.ReportAuthors) (err error) { return nil }
-func (m *mockMailAPI) SendMemoPublishMail(memo models.MemoDetail) (err error)        { return nil }
-func (m *mockMailAPI) SendFollowProjectMail(args models.FollowArgs) (err error)      { return nil }
+func (m *mockMailAPI) SendCECommentNotify(tmp models.TaggedPostMember) (err error)     { return nil }
+func (m *mockMailAPI) SendReportPublishMail(report models.ReportAuthors) (err error)   { return nil }
+func (m *mockMailAPI) SendMemoPublishMail(memo models.MemoDetail) (err error)          { return nil }
+func (m *mockMailAPI) SendFollowProjectMail(args models.FollowArgs) (err error)        { return nil }
+func (m *mockMailAPI) SendMilestoneMail(milestone models.ProjectMilestone) (err error) { return nil }
 
 // func getRouter() *gin.Engine {
 // 	r := gin.Default()
@@ -20,10 +20,13 @@ func SetRoutes(router *gin.Engine) {
 		&AuthHandler,
 		&CommentsHandler,
 		&cards.Router,
+		&FeedHandler,
 		&FilterHandler,
 		&FollowingHandler,
+		&LinkCheckHandler,
 		&mail.Router,
 		&MemberHandler,
+		&MilestoneHandler,
 		//&MemoHandler,
 		&MiscHandler,
 		&NotificationHandler,
@@ -32,8 +35,11 @@ func SetRoutes(router *gin.Engine) {
 		&PostHandler,
 		&ProjectHandler,
 		&PubsubHandler,
+		&SitemapHandler,
 		//&ReportHandler,
 		&TagHandler,
+		&TrashHandler,
+		&ViewHandler,
 		&poll.Router,
 		&promotion.Router,
 		&subscription.Router,
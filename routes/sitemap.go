@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/models"
+	"github.com/readr-media/readr-restful/pkg/sitemap"
+	"github.com/readr-media/readr-restful/utils"
+)
+
+type sitemapHandler struct{}
+
+var errSitemapNotFound = errors.New("Sitemap Not Found")
+
+var sitemapFilePattern = regexp.MustCompile(`^(posts|projects|tags|members)-([0-9]+)\.xml$`)
+
+func (r *sitemapHandler) pageSize() int {
+	if size := config.Config.Sitemap.PageSize; size > 0 && size <= sitemap.MaxURLs {
+		return size
+	}
+	return sitemap.MaxURLs
+}
+
+func (r *sitemapHandler) location(file string) string {
+	return fmt.Sprintf("%s/sitemaps/%s", config.Config.DomainName, file)
+}
+
+// resourceURL maps sitemap resources to the url of their pages on site
+func (r *sitemapHandler) resourceURL(resource string, entry models.SitemapEntry) string {
+	switch resource {
+	case "posts":
+		// Memos are read within their series
+		if entry.Type.Valid && entry.Type.Int == int64(config.Config.Models.PostType["memo"]) && entry.ProjectSlug.Valid {
+			return utils.GenerateResourceInfo("memo", entry.ID, entry.ProjectSlug.String)
+		}
+		return utils.GenerateResourceInfo("post", entry.ID, "")
+	case "projects":
+		return utils.GenerateResourceInfo("project", entry.ID, entry.Slug.String)
+	case "tags":
+		return utils.GenerateResourceInfo("tag", entry.ID, "")
+	case "members":
+		return utils.GenerateResourceInfo("member", entry.ID, "")
+	}
+	return ""
+}
+
+func (r *sitemapHandler) buildIndex() ([]byte, error) {
+
+	sitemaps := []sitemap.URL{{Loc: r.location("news.xml")}}
+	for _, resource := range models.SitemapResources {
+		count, err := models.SitemapAPI.Count(resource)
+		if err != nil {
+			return nil, err
+		}
+		for page := 1; (page-1)*r.pageSize() < count; page++ {
+			sitemaps = append(sitemaps, sitemap.URL{Loc: r.location(fmt.Sprintf("%s-%d.xml", resource, page))})
+		}
+	}
+	return sitemap.Index(sitemaps)
+}
+
+func (r *sitemapHandler) buildURLSet(resource string, page int) ([]byte, error) {
+
+	entries, err := models.SitemapAPI.GetEntries(resource, (page-1)*r.pageSize(), r.pageSize())
+	if err != nil {
+		return nil, err
+	}
+	// Pages past the last one are not listed in index
+	if len(entries) == 0 {
+		return nil, errSitemapNotFound
+	}
+	urls := make([]sitemap.URL, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, sitemap.URL{Loc: r.resourceURL(resource, entry), LastMod: entry.LastMod()})
+	}
+	return sitemap.URLSet(urls)
+}
+
+func (r *sitemapHandler) buildNews() ([]byte, error) {
+
+	hours := config.Config.Sitemap.NewsHours
+	if hours <= 0 {
+		hours = 48
+	}
+	entries, err := models.SitemapAPI.GetRecentPosts(time.Now().Add(-time.Duration(hours) * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]sitemap.NewsURL, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, sitemap.NewsURL{Loc: r.resourceURL("posts", entry), Title: entry.Title.String, Published: entry.PublishedAt.Time})
+	}
+	return sitemap.News(config.Config.Sitemap.NewsPublication, config.Config.Sitemap.NewsLanguage, urls)
+}
+
+// serve responds sitemap saved under name in cache, or builds it if there is no cache
+func (r *sitemapHandler) serve(c *gin.Context, name string, build func() ([]byte, error)) {
+
+	key := models.SitemapCache.Key("xml", name)
+	cached, ok := models.SitemapCache.Get(key)
+	if !ok {
+		body, err := build()
+		if err == errSitemapNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		cached = models.CachedFeed{Body: body, ETag: fmt.Sprintf(`"%x"`, sha1.Sum(body))}
+		if err = models.SitemapCache.Set(key, cached); err != nil {
+			log.Printf("Sitemap %s is served without cache: %v\n", key, err)
+		}
+	}
+	c.Header("ETag", cached.ETag)
+	if c.GetHeader("If-None-Match") == cached.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", cached.Body)
+}
+
+func (r *sitemapHandler) GetIndex(c *gin.Context) {
+	r.serve(c, "index", r.buildIndex)
+}
+
+func (r *sitemapHandler) Get(c *gin.Context) {
+
+	file := c.Param("file")
+	if file == "news.xml" {
+		r.serve(c, "news", r.buildNews)
+		return
+	}
+
+	matches := sitemapFilePattern.FindStringSubmatch(file)
+	if matches == nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Sitemap Not Found"})
+		return
+	}
+	resource := matches[1]
+	page, err := strconv.Atoi(matches[2])
+	if err != nil || page < 1 {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Sitemap Not Found"})
+		return
+	}
+	r.serve(c, fmt.Sprintf("%s-%d", resource, page), func() ([]byte, error) { return r.buildURLSet(resource, page) })
+}
+
+func (r *sitemapHandler) SetRoutes(router *gin.Engine) {
+
+	router.GET("/sitemap.xml", r.GetIndex)
+	router.GET("/sitemaps/:file", r.Get)
+}
+
+var SitemapHandler sitemapHandler
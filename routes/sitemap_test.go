@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/models"
+)
+
+type mockSitemapAPI struct {
+	entries map[string][]models.SitemapEntry
+}
+
+func (a *mockSitemapAPI) Count(resource string) (int, error) {
+	return len(a.entries[resource]), nil
+}
+
+func (a *mockSitemapAPI) GetEntries(resource string, offset int, limit int) ([]models.SitemapEntry, error) {
+	entries := a.entries[resource]
+	if offset >= len(entries) {
+		return []models.SitemapEntry{}, nil
+	}
+	if offset+limit > len(entries) {
+		limit = len(entries) - offset
+	}
+	return entries[offset : offset+limit], nil
+}
+
+func (a *mockSitemapAPI) GetRecentPosts(since time.Time) ([]models.SitemapEntry, error) {
+	return a.entries["posts"], nil
+}
+
+func TestRouteSitemap(t *testing.T) {
+
+	backup := models.SitemapAPI
+	models.SitemapAPI = &mockSitemapAPI{entries: map[string][]models.SitemapEntry{
+		"posts": {
+			{ID: 1, Type: rrsql.NullInt{Int: 0, Valid: true}},
+			{ID: 2, Type: rrsql.NullInt{Int: int64(config.Config.Models.PostType["memo"]), Valid: true}, ProjectSlug: rrsql.NullString{String: "series01", Valid: true}},
+		},
+	}}
+	defer func() { models.SitemapAPI = backup }()
+
+	for _, tc := range []struct {
+		name     string
+		url      string
+		httpcode int
+		contains []string
+	}{
+		{"Posts", "/sitemaps/posts-1.xml", http.StatusOK, []string{"/post/1</loc>", "/series/series01/2</loc>"}},
+		{"PastLastPage", "/sitemaps/posts-2.xml", http.StatusNotFound, []string{`{"Error":"Sitemap Not Found"}`}},
+		{"EmptyResource", "/sitemaps/tags-1.xml", http.StatusNotFound, []string{`{"Error":"Sitemap Not Found"}`}},
+		{"InvalidFile", "/sitemaps/memos-1.xml", http.StatusNotFound, []string{`{"Error":"Sitemap Not Found"}`}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			for _, s := range tc.contains {
+				if !strings.Contains(w.Body.String(), s) {
+					t.Errorf("%s expect response containing %s but get %s", tc.name, s, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 
@@ -139,7 +140,7 @@ func (r *tagHandler) Post(c *gin.Context) {
 	tag_id, err := models.TagAPI.InsertTag(args)
 	if err != nil {
 		switch err.Error() {
-		case "Duplicate Entry":
+		case "Duplicate Entry", "Invalid Parent":
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		default:
@@ -165,11 +166,13 @@ func (r *tagHandler) Put(c *gin.Context) {
 	}
 	args.UpdatedAt = rrsql.NullTime{Time: time.Now(), Valid: true}
 	args.CreatedAt = rrsql.NullTime{Valid: false}
+	// Aliases are only set by merge
+	args.AliasOf = rrsql.NullInt{Valid: false}
 
 	err = models.TagAPI.UpdateTag(args)
 	if err != nil {
 		switch err.Error() {
-		case "Duplicate Entry":
+		case "Duplicate Entry", "Invalid Parent":
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		case rrsql.ItemNotFoundError.Error():
@@ -215,6 +218,89 @@ func (r *tagHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// Merge moves tagging and follows of source tags to target, and leaves sources as aliases of target
+func (r *tagHandler) Merge(c *gin.Context) {
+
+	args := models.MergeTagsArgs{}
+	if err := c.ShouldBindJSON(&args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err := args.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	if err := models.TagAPI.MergeTags(args); err != nil {
+		switch err.Error() {
+		case rrsql.ItemNotFoundError.Error(), "Invalid Target":
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// GetTree returns tag with its ancestors and descendants
+func (r *tagHandler) GetTree(c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Tag ID"})
+		return
+	}
+	tree, err := models.TagAPI.GetTagTree(id)
+	if err != nil {
+		switch err {
+		case rrsql.ItemNotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": tree})
+}
+
+// Suggest ranks existing tags and new keywords for title and content, or for the post of post_id
+func (r *tagHandler) Suggest(c *gin.Context) {
+
+	args := models.SuggestTagsArgs{}
+	if err := c.ShouldBindJSON(&args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if args.PostID <= 0 && strings.TrimSpace(args.Title) == "" && strings.TrimSpace(args.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Empty Content"})
+		return
+	}
+
+	suggestions, err := models.TagSuggestAPI.Suggest(args)
+	if err != nil {
+		switch err.Error() {
+		case rrsql.ItemNotFoundError.Error():
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		case "Suggestion Model Not Ready":
+			c.JSON(http.StatusServiceUnavailable, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": suggestions})
+}
+
+// PutSuggest rebuilds the suggestion model, and is expected to be called periodically
+func (r *tagHandler) PutSuggest(c *gin.Context) {
+	if err := models.TagSuggestAPI.Build(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 func (r *tagHandler) Count(c *gin.Context) {
 	args := models.DefaultGetTagsArgs()
 	err := c.Bind(&args)
@@ -257,6 +343,9 @@ func bindGetPostReportArgs(c *gin.Context, args *models.GetPostReportArgs) (err
 	if c.Param("tag_id") != "" {
 		args.TagID, _ = strconv.Atoi(c.Param("tag_id"))
 	}
+	if c.Query("types") != "" {
+		args.Types = strings.Split(c.Query("types"), ",")
+	}
 	if c.Query("filter") != "" {
 		filters := parseFilter(c.Query("filter"))
 		if val, ok := filters["pnr"]; ok {
@@ -290,47 +379,45 @@ func (r *tagHandler) GetPostReport(c *gin.Context) {
 		return
 	}
 	// Format result
-	// cut result, create next if more than max_result
-	lastField := func(sorting string, in models.LastPNRInterface) string {
-
-		switch sorting {
-		case "published_at", "-published_at":
-			return in.ReturnPublishedAt().Format(time.RFC3339)
-		case "created_at", "-created_at":
-			return in.ReturnCreatedAt().Format(time.RFC3339)
-		case "updated_at", "-updated_at":
-			return in.ReturnUpdatedAt().Format(time.RFC3339)
-		}
-		return ""
-	}
-
+	// cut result, create next with cursor of the last item if more than max_result
 	links := make(map[string]interface{})
 	var nextLink = struct {
-		Next string `json:"url,omitempty"`
-		Page int    `json:"page,omitempty"`
+		Next   string `json:"url,omitempty"`
+		Cursor string `json:"cursor,omitempty"`
 	}{}
 	if len(result) > args.MaxResult {
 
-		result = result[:len(result)-1]
-		var nextLinkField string
-		if strings.HasPrefix(args.Sorting, "-") {
-			nextLinkField = strings.TrimPrefix(args.Sorting, "-")
+		result = result[:args.MaxResult]
+		nextLink.Cursor = models.EncodePNRCursor(args.Sorting, result[len(result)-1])
+		query := url.Values{}
+		query.Set("max_result", strconv.Itoa(args.MaxResult))
+		query.Set("sort", args.Sorting)
+		query.Set("types", strings.Join(args.Types, ","))
+		query.Set("cursor", nextLink.Cursor)
+		if c.Query("filter") != "" {
+			query.Set("filter", c.Query("filter"))
 		}
-		nextLink.Page = args.Page + 1
-		nextLink.Next = fmt.Sprintf("/tags/pnr/%d?max_result=%d&page=%d&sort=%s&filter=pnr:%s%s%s", args.TagID, args.MaxResult, nextLink.Page, args.Sorting, nextLinkField, "<=", lastField(args.Sorting, result[len(result)-1]))
+		nextLink.Next = fmt.Sprintf("/tags/pnr/%d?%s", args.TagID, query.Encode())
 		links["next"] = nextLink
 	}
-	c.JSON(http.StatusOK, gin.H{"_items": result, "_links": links})
+	items := make([]models.PNRItem, len(result))
+	for i, item := range result {
+		items[i] = models.PNRItem{LastPNRInterface: item}
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": items, "_links": links})
 }
 
 func (r *tagHandler) SetRoutes(router *gin.Engine) {
 
 	tagRouter := router.Group("/tags")
 	{
-		tagRouter.GET("", r.Get)
+		tagRouter.GET("", rt.Conditional("tags"), r.Get)
 		tagRouter.POST("", r.Post)
 		tagRouter.PUT("", r.Put)
 		tagRouter.DELETE("", r.Delete)
+		tagRouter.POST("/merge", r.Merge)
+		tagRouter.POST("/suggest", r.Suggest)
+		tagRouter.PUT("/suggest", r.PutSuggest)
 
 		tagRouter.GET("/count", r.Count)
 		tagRouter.GET("/hot", r.Hot)
@@ -338,6 +425,8 @@ func (r *tagHandler) SetRoutes(router *gin.Engine) {
 
 		tagRouter.GET("/pnr/:tag_id", r.GetPostReport)
 	}
+	// Routes of single tag are under /tag, as /tags/:id conflicts with routes above
+	router.GET("/tag/:id/tree", r.GetTree)
 }
 
 func (r *tagHandler) validate(target string, paradigm string) bool {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/readr-media/readr-restful/internal/rrsql"
 	"github.com/readr-media/readr-restful/models"
@@ -14,6 +15,7 @@ import (
 
 var mockTagDS []models.Tag
 var mockPostTagDS []map[string]int
+var mockPNRItems []models.LastPNRInterface
 
 type mockTagAPI struct{}
 
@@ -37,15 +39,21 @@ func (t *mockTagAPI) GetTags(args models.GetTagsArgs) (tags []models.TagRelatedR
 	var offset = int(args.Page-1) * int(args.MaxResult)
 
 	for _, t := range mockTagDS {
-		if t.Active.Int != 0 {
+		if t.Active.Int != 0 && !t.AliasOf.Valid {
 			result = append(result, models.TagRelatedResources{Tag: t})
 		}
 	}
 
 	if args.Keyword != "" {
+		canonical := map[int]bool{}
+		for _, t := range mockTagDS {
+			if t.AliasOf.Valid && strings.HasPrefix(t.Text, args.Keyword) {
+				canonical[int(t.AliasOf.Int)] = true
+			}
+		}
 		newResult := []models.TagRelatedResources{}
 		for _, t := range result {
-			if strings.HasPrefix(t.Text, args.Keyword) {
+			if strings.HasPrefix(t.Text, args.Keyword) || canonical[t.ID] {
 				newResult = append(newResult, t)
 			}
 		}
@@ -77,6 +85,9 @@ func (t *mockTagAPI) GetTags(args models.GetTagsArgs) (tags []models.TagRelatedR
 func (t *mockTagAPI) InsertTag(tag models.Tag) (int, error) {
 	index := len(mockTagDS) + 1
 	for _, t := range mockTagDS {
+		if t.Text == tag.Text && t.Active.Int == 1 && t.AliasOf.Valid {
+			return int(t.AliasOf.Int), nil
+		}
 		if t.Text == tag.Text && t.Active.Int == 1 {
 			return 0, errors.New(`Duplicate Entry`)
 		}
@@ -94,7 +105,17 @@ func (t *mockTagAPI) UpdateTag(tag models.Tag) error {
 	if tag.ID > len(mockTagDS) {
 		return rrsql.ItemNotFoundError
 	}
-	mockTagDS[tag.ID-1].Text = tag.Text
+	if tag.ParentID.Valid {
+		for parent := int(tag.ParentID.Int); parent != 0; parent = int(mockTagDS[parent-1].ParentID.Int) {
+			if parent == tag.ID || parent > len(mockTagDS) {
+				return errors.New("Invalid Parent")
+			}
+		}
+		mockTagDS[tag.ID-1].ParentID = tag.ParentID
+	}
+	if tag.Text != "" {
+		mockTagDS[tag.ID-1].Text = tag.Text
+	}
 	return nil
 }
 
@@ -133,8 +154,79 @@ func (t *mockTagAPI) GetHotTags() ([]models.TagRelatedResources, error) {
 func (t *mockTagAPI) UpdateHotTags() error { return nil }
 
 func (t *mockTagAPI) GetPostReport(args *models.GetPostReportArgs) ([]models.LastPNRInterface, error) {
-	return []models.LastPNRInterface{}, nil
+	result := []models.LastPNRInterface{}
+	for _, item := range mockPNRItems {
+		if len(result) > args.MaxResult {
+			break
+		}
+		for _, resourceType := range args.Types {
+			if item.ReturnResourceType() == resourceType {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, nil
+}
+func (t *mockTagAPI) MergeTags(args models.MergeTagsArgs) error {
+	if args.Target > len(mockTagDS) {
+		return rrsql.ItemNotFoundError
+	}
+	if mockTagDS[args.Target-1].AliasOf.Valid {
+		return errors.New("Invalid Target")
+	}
+	for _, id := range args.Sources {
+		if id > len(mockTagDS) {
+			return rrsql.ItemNotFoundError
+		}
+	}
+	for parent := int(mockTagDS[args.Target-1].ParentID.Int); parent != 0; parent = int(mockTagDS[parent-1].ParentID.Int) {
+		if sliceContainsInt(args.Sources, parent) {
+			return errors.New("Invalid Target")
+		}
+	}
+	for i, tag := range mockTagDS {
+		if tag.ParentID.Valid && sliceContainsInt(args.Sources, int(tag.ParentID.Int)) && !sliceContainsInt(args.Sources, tag.ID) {
+			mockTagDS[i].ParentID = rrsql.NullInt{int64(args.Target), true}
+		}
+	}
+	for i, tag := range mockTagDS {
+		if tag.AliasOf.Valid && sliceContainsInt(args.Sources, int(tag.AliasOf.Int)) || sliceContainsInt(args.Sources, tag.ID) {
+			mockTagDS[i].AliasOf = rrsql.NullInt{int64(args.Target), true}
+		}
+	}
+	return nil
+}
+
+func (t *mockTagAPI) GetTagTree(id int) (models.TagTree, error) {
+	if id > len(mockTagDS) || mockTagDS[id-1].AliasOf.Valid {
+		return models.TagTree{}, rrsql.ItemNotFoundError
+	}
+	var build func(tag models.Tag) *models.TagTree
+	build = func(tag models.Tag) *models.TagTree {
+		node := &models.TagTree{Tag: tag, Children: []*models.TagTree{}}
+		for _, child := range mockTagDS {
+			if child.ParentID.Int == int64(tag.ID) && !child.AliasOf.Valid {
+				node.Children = append(node.Children, build(child))
+			}
+		}
+		return node
+	}
+	tree := build(mockTagDS[id-1])
+	for parent := mockTagDS[id-1].ParentID.Int; parent != 0; parent = mockTagDS[parent-1].ParentID.Int {
+		tree.Ancestors = append([]models.Tag{mockTagDS[parent-1]}, tree.Ancestors...)
+	}
+	return *tree, nil
+}
+
+func sliceContainsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
 }
+
 func TestRouteTags(t *testing.T) {
 
 	tags := []models.Tag{
@@ -273,6 +365,39 @@ func TestRouteTags(t *testing.T) {
 			genericDoTest(testcase, t, asserter)
 		}
 	})
+	t.Run("MergeTags", func(t *testing.T) {
+		for _, testcase := range []genericTestcase{
+			genericTestcase{"MergeTagsOK", "POST", "/tags/merge", `{"target":1, "sources":[2], "updated_by":931}`, http.StatusOK, ``},
+			genericTestcase{"MergeTagsIntoAlias", "POST", "/tags/merge", `{"target":2, "sources":[3], "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Target"}`},
+			genericTestcase{"MergeTagsNoSuchTag", "POST", "/tags/merge", `{"target":1, "sources":[3, 99], "updated_by":931}`, http.StatusBadRequest, `{"Error":"Item Not Found"}`},
+			genericTestcase{"MergeTagsIntoItself", "POST", "/tags/merge", `{"target":1, "sources":[1], "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Sources"}`},
+			genericTestcase{"MergeTagsNoSources", "POST", "/tags/merge", `{"target":1, "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Sources"}`},
+			genericTestcase{"MergeTagsNoUpdater", "POST", "/tags/merge", `{"target":1, "sources":[3]}`, http.StatusBadRequest, `{"Error":"Updater Not Sepcified"}`},
+			genericTestcase{"GetTagByAlias", "GET", "/tags?keyword=tag2", ``, http.StatusOK, []models.Tag{
+				models.Tag{ID: 1, Text: "tag1", Active: rrsql.NullInt{1, true}},
+			}},
+			genericTestcase{"PostAliasTag", "POST", "/tags", `{"text":"tag2", "updated_by":931}`, http.StatusOK, `{"tag_id":1}`},
+		} {
+			genericDoTest(testcase, t, asserter)
+		}
+	})
+	t.Run("TagTree", func(t *testing.T) {
+		for _, testcase := range []genericTestcase{
+			genericTestcase{"UpdateTagParentOK", "PUT", "/tags", `{"id":3, "parent_id":1, "updated_by":931}`, http.StatusOK, ``},
+			genericTestcase{"UpdateTagChildParentOK", "PUT", "/tags", `{"id":4, "parent_id":3, "updated_by":931}`, http.StatusOK, ``},
+			genericTestcase{"UpdateTagParentCycle", "PUT", "/tags", `{"id":1, "parent_id":4, "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Parent"}`},
+			genericTestcase{"UpdateTagParentItself", "PUT", "/tags", `{"id":3, "parent_id":3, "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Parent"}`},
+			genericTestcase{"GetTagTreeOK", "GET", "/tag/3/tree", ``, http.StatusOK, `{"_items":{"id":3,"text":"tag3","created_at":null,"updated_at":null,"updated_by":null,"active":1,"related_reviews":1,"related_news":null,"related_projects":null,"alias_of":null,"parent_id":1,"ancestors":[{"id":1,"text":"tag1","created_at":null,"updated_at":null,"updated_by":null,"active":1,"related_reviews":2,"related_news":null,"related_projects":null,"alias_of":null,"parent_id":null}],"children":[{"id":4,"text":"tag4","created_at":null,"updated_at":null,"updated_by":null,"active":1,"related_reviews":null,"related_news":null,"related_projects":null,"alias_of":null,"parent_id":3,"children":[]}]}}`},
+			genericTestcase{"GetTagTreeNotFound", "GET", "/tag/99/tree", ``, http.StatusNotFound, `{"Error":"Item Not Found"}`},
+			genericTestcase{"GetTagTreeInvalidID", "GET", "/tag/tag1/tree", ``, http.StatusBadRequest, `{"Error":"Invalid Tag ID"}`},
+			genericTestcase{"MergeTagsIntoDescendant", "POST", "/tags/merge", `{"target":4, "sources":[1], "updated_by":931}`, http.StatusBadRequest, `{"Error":"Invalid Target"}`},
+			genericTestcase{"MergeTagsWithChildrenOK", "POST", "/tags/merge", `{"target":5, "sources":[3], "updated_by":931}`, http.StatusOK, ``},
+			genericTestcase{"GetMergedTagTree", "GET", "/tag/5/tree", ``, http.StatusOK, `{"_items":{"id":5,"text":"text5566","created_at":null,"updated_at":null,"updated_by":null,"active":1,"related_reviews":null,"related_news":null,"related_projects":null,"alias_of":null,"parent_id":null,"children":[{"id":4,"text":"tag4","created_at":null,"updated_at":null,"updated_by":null,"active":1,"related_reviews":null,"related_news":null,"related_projects":null,"alias_of":null,"parent_id":5,"children":[]}]}}`},
+			genericTestcase{"GetMergedTagTreeSource", "GET", "/tag/3/tree", ``, http.StatusNotFound, `{"Error":"Item Not Found"}`},
+		} {
+			genericDoTest(testcase, t, nil)
+		}
+	})
 	t.Run("DaleteTags", func(t *testing.T) {
 		for _, testcase := range []genericTestcase{
 			genericTestcase{"DeleteTagOK", "DELETE", "/tags?ids=[1, 2, 3, 4]&updated_by=AMI@mirrormedia.mg", ``, http.StatusOK, ``},
@@ -299,6 +424,27 @@ func TestRouteTags(t *testing.T) {
 			genericTestcase{"InvalidPNRFilter", "GET", "/tags/pnr/94?filter=pnr:published_at<<2018-06-26T08:07:27Z", ``, http.StatusBadRequest, `{"Error":"Invalid PNR Filter"}`},
 			genericTestcase{"InvalidTimeFilter", "GET", "/tags/pnr/1?filter=pnr:published_at<=2018:06:26T08-07-27Z", ``, http.StatusBadRequest, `{"Error":"Invalid Filter Time Condition"}`},
 			genericTestcase{"InconsistentFilterField", "GET", "/tags/pnr/1?sort=updated_at&filter=pnr:published_at<=2018-06-26T08:07:27Z", ``, http.StatusBadRequest, `{"Error":"Inconsistent Filter Field"}`},
+			genericTestcase{"InvalidTypes", "GET", "/tags/pnr/1?types=post,memo", ``, http.StatusBadRequest, `{"Error":"Invalid Types"}`},
+			genericTestcase{"InvalidCursor", "GET", "/tags/pnr/1?cursor=abc", ``, http.StatusBadRequest, `{"Error":"Invalid Cursor"}`},
+			genericTestcase{"InvalidMaxResult", "GET", "/tags/pnr/1?max_result=0", ``, http.StatusBadRequest, `{"Error":"Invalid Max Result"}`},
+		} {
+			genericDoTest(testcase, t, nil)
+		}
+	})
+	t.Run("GetPostReportCursor", func(t *testing.T) {
+		published := func(s string) rrsql.NullTime {
+			at, _ := time.Parse(time.RFC3339, s)
+			return rrsql.NullTime{Time: at, Valid: true}
+		}
+		mockPNRItems = []models.LastPNRInterface{
+			models.TaggedPoll{ID: 2, Active: 1, MaxChoice: 1, Title: rrsql.NullString{String: "poll2", Valid: true}, PublishedAt: published("2018-05-17T02:54:25Z")},
+			models.TaggedPoll{ID: 1, Active: 1, MaxChoice: 1, Title: rrsql.NullString{String: "poll1", Valid: true}, PublishedAt: published("2018-05-16T00:00:00Z")},
+		}
+		defer func() { mockPNRItems = nil }()
+		for _, testcase := range []genericTestcase{
+			genericTestcase{"NextCursor", "GET", "/tags/pnr/1?max_result=1&types=poll", ``, http.StatusOK, `{"_items":[{"active":1,"changeable":0,"created_at":null,"created_by":null,"description":null,"end_at":null,"frequency":null,"id":2,"max_choice":1,"published_at":"2018-05-17T02:54:25Z","resource_type":"poll","start_at":null,"status":0,"title":"poll2","total_vote":0,"updated_at":null,"updated_by":null}],"_links":{"next":{"url":"/tags/pnr/1?cursor=eyJhdCI6IjIwMTgtMDUtMTdUMDI6NTQ6MjVaIiwidHlwZSI6InBvbGwiLCJpZCI6Mn0\u0026max_result=1\u0026sort=-published_at\u0026types=poll","cursor":"eyJhdCI6IjIwMTgtMDUtMTdUMDI6NTQ6MjVaIiwidHlwZSI6InBvbGwiLCJpZCI6Mn0"}}}`},
+			genericTestcase{"LastPage", "GET", "/tags/pnr/1?max_result=2&types=poll", ``, http.StatusOK, nil},
+			genericTestcase{"OtherTypes", "GET", "/tags/pnr/1?types=post,report", ``, http.StatusOK, `{"_items":[],"_links":{}}`},
 		} {
 			genericDoTest(testcase, t, nil)
 		}
@@ -313,3 +459,43 @@ func TestRouteTags(t *testing.T) {
 		})
 	*/
 }
+
+type mockTagSuggestAPI struct {
+	built bool
+}
+
+func (a *mockTagSuggestAPI) Build() error {
+	a.built = true
+	return nil
+}
+
+func (a *mockTagSuggestAPI) Suggest(args models.SuggestTagsArgs) (models.TagSuggestions, error) {
+	if !a.built {
+		return models.TagSuggestions{}, errors.New("Suggestion Model Not Ready")
+	}
+	if args.PostID > 0 && args.PostID != 42 {
+		return models.TagSuggestions{}, rrsql.ItemNotFoundError
+	}
+	return models.TagSuggestions{
+		Tags:     []models.TagSuggestion{{ID: 1, Text: "健保", Score: 0.2934}},
+		Keywords: []models.KeywordSuggestion{{Keyword: "調整", Score: 0.2286}},
+	}, nil
+}
+
+func TestRouteTagSuggest(t *testing.T) {
+
+	backup := models.TagSuggestAPI
+	models.TagSuggestAPI = new(mockTagSuggestAPI)
+	defer func() { models.TagSuggestAPI = backup }()
+
+	for _, testcase := range []genericTestcase{
+		genericTestcase{"SuggestNotReady", "POST", "/tags/suggest", `{"title":"全民健保費率調整"}`, http.StatusServiceUnavailable, `{"Error":"Suggestion Model Not Ready"}`},
+		genericTestcase{"BuildOK", "PUT", "/tags/suggest", ``, http.StatusOK, ``},
+		genericTestcase{"SuggestOK", "POST", "/tags/suggest", `{"title":"全民健保費率調整","content":"<p>健保費率調整</p>"}`, http.StatusOK, `{"_items":{"tags":[{"id":1,"text":"健保","score":0.2934}],"keywords":[{"keyword":"調整","score":0.2286}]}}`},
+		genericTestcase{"SuggestPostOK", "POST", "/tags/suggest", `{"post_id":42}`, http.StatusOK, `{"_items":{"tags":[{"id":1,"text":"健保","score":0.2934}],"keywords":[{"keyword":"調整","score":0.2286}]}}`},
+		genericTestcase{"SuggestPostNotFound", "POST", "/tags/suggest", `{"post_id":43}`, http.StatusNotFound, `{"Error":"Item Not Found"}`},
+		genericTestcase{"SuggestEmpty", "POST", "/tags/suggest", `{"title":" "}`, http.StatusBadRequest, `{"Error":"Empty Content"}`},
+	} {
+		genericDoTest(testcase, t, nil)
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/models"
+)
+
+type trashHandler struct{}
+
+// Get lists deleted resources of a type, such as /trash?type=post
+func (r *trashHandler) Get(c *gin.Context) {
+
+	args := &models.GetTrashArgs{MaxResult: 20, Page: 1}
+	if err := c.ShouldBindQuery(args); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err := models.ValidateTrashType(args.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if args.MaxResult <= 0 || args.Page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Paging"})
+		return
+	}
+
+	items, err := models.TrashAPI.Get(args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"_items": items})
+}
+
+// Restore reactivates deleted resources, and feeds restored posts to search and caches again
+func (r *trashHandler) Restore(c *gin.Context) {
+
+	var payload struct {
+		Type string `json:"type"`
+		IDs  []int  `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || len(payload.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Request Body"})
+		return
+	}
+	if err := models.ValidateTrashType(payload.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	restored, err := models.TrashAPI.Restore(payload.Type, payload.IDs)
+	if err != nil {
+		switch err.Error() {
+		case "Trash Not Found":
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		}
+		return
+	}
+
+	if payload.Type == "post" {
+		ids := make([]uint32, 0, len(restored))
+		for _, id := range restored {
+			ids = append(ids, uint32(id))
+		}
+		go PostHandler.publishPipeline(ids, false)
+	}
+	c.JSON(http.StatusOK, gin.H{"restored": restored})
+}
+
+// Purge hard-deletes resources out of retention, and is expected to be called periodically
+func (r *trashHandler) Purge(c *gin.Context) {
+
+	purged, err := models.TrashAPI.Purge()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+func (r *trashHandler) SetRoutes(router *gin.Engine) {
+	router.GET("/trash", r.Get)
+	router.POST("/trash/restore", r.Restore)
+	router.PUT("/trash/purge", r.Purge)
+}
+
+var TrashHandler trashHandler
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/readr-media/readr-restful/internal/rrsql"
+	"github.com/readr-media/readr-restful/models"
+)
+
+type mockTrashAPI struct {
+	trash map[string][]int
+}
+
+func (a *mockTrashAPI) Get(args *models.GetTrashArgs) ([]models.TrashItem, error) {
+	items := make([]models.TrashItem, 0)
+	for _, id := range a.trash[args.Type] {
+		items = append(items, models.TrashItem{ID: int64(id), Type: args.Type, Name: rrsql.NullString{String: "deleted", Valid: true}})
+	}
+	return items, nil
+}
+
+func (a *mockTrashAPI) Insert(resource string, ids []int) error {
+	a.trash[resource] = append(a.trash[resource], ids...)
+	return nil
+}
+
+func (a *mockTrashAPI) Restore(resource string, ids []int) ([]int, error) {
+	restored := make([]int, 0)
+	remain := make([]int, 0)
+	for _, trashed := range a.trash[resource] {
+		found := false
+		for _, id := range ids {
+			if id == trashed {
+				found = true
+			}
+		}
+		if found {
+			restored = append(restored, trashed)
+		} else {
+			remain = append(remain, trashed)
+		}
+	}
+	if len(restored) == 0 {
+		return nil, errors.New("Trash Not Found")
+	}
+	a.trash[resource] = remain
+	return restored, nil
+}
+
+func (a *mockTrashAPI) Purge() (int, error) {
+	purged := 0
+	for resource, ids := range a.trash {
+		purged += len(ids)
+		delete(a.trash, resource)
+	}
+	return purged, nil
+}
+
+func TestRouteTrash(t *testing.T) {
+
+	backup := models.TrashAPI
+	models.TrashAPI = &mockTrashAPI{trash: map[string][]int{"tag": []int{1, 2}, "member": []int{3}}}
+	defer func() { models.TrashAPI = backup }()
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		httpcode int
+		resp     string
+	}{
+		{"Get", "GET", "/trash?type=tag", ``, http.StatusOK, `{"_items":[{"id":1,"type":"tag","name":"deleted","deleted_at":null},{"id":2,"type":"tag","name":"deleted","deleted_at":null}]}`},
+		{"GetEmpty", "GET", "/trash?type=asset", ``, http.StatusOK, `{"_items":[]}`},
+		{"GetInvalidType", "GET", "/trash?type=comment", ``, http.StatusBadRequest, `{"Error":"Invalid Type"}`},
+		{"GetInvalidPaging", "GET", "/trash?type=tag&page=0", ``, http.StatusBadRequest, `{"Error":"Invalid Paging"}`},
+		{"Restore", "POST", "/trash/restore", `{"type":"tag","ids":[2,5]}`, http.StatusOK, `{"restored":[2]}`},
+		{"RestoreNotFound", "POST", "/trash/restore", `{"type":"tag","ids":[5]}`, http.StatusNotFound, `{"Error":"Trash Not Found"}`},
+		{"RestoreInvalidType", "POST", "/trash/restore", `{"type":"comment","ids":[1]}`, http.StatusBadRequest, `{"Error":"Invalid Type"}`},
+		{"RestoreEmpty", "POST", "/trash/restore", `{"type":"tag","ids":[]}`, http.StatusBadRequest, `{"Error":"Invalid Request Body"}`},
+		{"Purge", "PUT", "/trash/purge", ``, http.StatusOK, `{"purged":2}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			if w.Body.String() != tc.resp {
+				t.Errorf("%s expect response %v but get %v", tc.name, tc.resp, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/readr-media/readr-restful/config"
+	"github.com/readr-media/readr-restful/models"
+)
+
+type viewHandler struct{}
+
+// Post counts a batch of view beacons. Requests from bots are accepted but not counted.
+func (r *viewHandler) Post(c *gin.Context) {
+
+	var payload struct {
+		Views []models.ViewBeacon `json:"views"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || len(payload.Views) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid Request Body"})
+		return
+	}
+	if max := config.Config.Views.MaxBatch; max > 0 && len(payload.Views) > max {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Too Many Views"})
+		return
+	}
+	for _, view := range payload.Views {
+		if err := view.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	if models.IsBot(userAgent) {
+		c.JSON(http.StatusOK, gin.H{"counted": 0})
+		return
+	}
+
+	// Anonymous visitors are told apart by IP and user agent
+	visitor := fmt.Sprintf("%x", sha1.Sum([]byte(c.ClientIP()+userAgent)))
+	if err := models.ViewAPI.Count(payload.Views, visitor); err != nil {
+		log.Println("Count Views Error: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal Server Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"counted": len(payload.Views)})
+}
+
+// Flush writes buffered views back to database, and is expected to be called periodically
+func (r *viewHandler) Flush(c *gin.Context) {
+
+	flushed, err := models.ViewAPI.Flush()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flushed": flushed})
+}
+
+func (r *viewHandler) SetRoutes(router *gin.Engine) {
+	router.POST("/views", r.Post)
+	router.PUT("/views", r.Flush)
+}
+
+var ViewHandler viewHandler
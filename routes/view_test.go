@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/readr-media/readr-restful/models"
+)
+
+type mockViewAPI struct {
+	counted []models.ViewBeacon
+}
+
+func (a *mockViewAPI) Count(beacons []models.ViewBeacon, visitor string) error {
+	a.counted = append(a.counted, beacons...)
+	return nil
+}
+
+func (a *mockViewAPI) Flush() (int, error) {
+	flushed := len(a.counted)
+	a.counted = nil
+	return flushed, nil
+}
+
+func TestRouteViews(t *testing.T) {
+
+	backup := models.ViewAPI
+	mock := &mockViewAPI{}
+	models.ViewAPI = mock
+	defer func() { models.ViewAPI = backup }()
+
+	browser := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_4) AppleWebKit/537.36"
+	for _, tc := range []struct {
+		name      string
+		method    string
+		body      string
+		userAgent string
+		httpcode  int
+		resp      string
+	}{
+		{"Count", "POST", `{"views":[{"resource":"post","id":1},{"resource":"project","id":2,"member_id":3}]}`, browser, http.StatusOK, `{"counted":2}`},
+		{"Bot", "POST", `{"views":[{"resource":"post","id":1}]}`, "Googlebot/2.1", http.StatusOK, `{"counted":0}`},
+		{"InvalidResource", "POST", `{"views":[{"resource":"memo","id":1}]}`, browser, http.StatusBadRequest, `{"Error":"Invalid Resource"}`},
+		{"InvalidID", "POST", `{"views":[{"resource":"video","id":0}]}`, browser, http.StatusBadRequest, `{"Error":"Invalid ID"}`},
+		{"Empty", "POST", `{"views":[]}`, browser, http.StatusBadRequest, `{"Error":"Invalid Request Body"}`},
+		{"Flush", "PUT", ``, browser, http.StatusOK, `{"flushed":2}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, "/views", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", tc.userAgent)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.httpcode {
+				t.Errorf("%s want HTTP code %d but get %d", tc.name, tc.httpcode, w.Code)
+			}
+			if w.Body.String() != tc.resp {
+				t.Errorf("%s expect response %v but get %v", tc.name, tc.resp, w.Body.String())
+			}
+		})
+	}
+}
@@ -72,6 +72,10 @@ func GenerateResourceInfo(resourceType string, resourceID int, slug string) (res
 		return fmt.Sprintf("%s/project/%s", resStringPrefix, slug)
 	case "memo":
 		return fmt.Sprintf("%s/series/%s/%d", resStringPrefix, slug, resourceID)
+	case "tag":
+		return fmt.Sprintf("%s/tag/%d", resStringPrefix, resourceID)
+	case "member":
+		return fmt.Sprintf("%s/profile/%d", resStringPrefix, resourceID)
 	default:
 		return resourceString
 	}